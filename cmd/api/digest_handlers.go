@@ -0,0 +1,365 @@
+package main
+
+import (
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"leetcode-rss/internal/api"
+	"leetcode-rss/internal/digest"
+	"leetcode-rss/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxEmailLength is the longest address SMTP allows in a forward path.
+const maxEmailLength = 254
+
+// GET /feeds/:id/digest
+func (app *app) getDigest(c *gin.Context) {
+	feed, ok := app.ownedFeed(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	d, err := app.store.GetDigest(ctx, feed.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			api.AbortJSONError(c, http.StatusNotFound, api.ErrorCodeNotFound, "digest not configured")
+			return
+		}
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to fetch digest")
+		return
+	}
+	recipients, err := app.store.ListDigestRecipients(ctx, feed.ID)
+	if err != nil {
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to list digest recipients")
+		return
+	}
+
+	c.JSON(http.StatusOK, digestJSON(d, recipients))
+}
+
+// PUT /feeds/:id/digest
+//
+// Creates or replaces the feed's digest settings and recipient list. New
+// recipients are sent a confirmation email and get digests once they
+// confirm.
+func (app *app) putDigest(c *gin.Context) {
+	feed, ok := app.ownedFeed(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Frequency  string   `json:"frequency"`
+		Recipients []string `json:"recipients"`
+		Enabled    *bool    `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.AbortJSONErrorWithDetails(c, http.StatusBadRequest, api.ErrorCodeValidation, "invalid request body", err.Error())
+		return
+	}
+
+	frequency := strings.ToLower(strings.TrimSpace(req.Frequency))
+	if frequency != digest.FrequencyDaily && frequency != digest.FrequencyWeekly {
+		api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, "frequency must be daily or weekly")
+		return
+	}
+	emails, ok := app.validateEmails(c, req.Recipients)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	existing, err := app.store.GetDigest(ctx, feed.ID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to fetch digest")
+		return
+	}
+
+	now := time.Now()
+	d := &store.Digest{
+		FeedID:    feed.ID,
+		Frequency: frequency,
+		Enabled:   true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.Enabled != nil {
+		d.Enabled = *req.Enabled
+	}
+	if existing != nil && existing.Frequency == d.Frequency && existing.Enabled == d.Enabled {
+		d.NextRunAt = existing.NextRunAt
+	} else {
+		d.NextRunAt = app.digests.NextRun(d.Frequency, now)
+	}
+
+	previous, err := app.store.ListDigestRecipients(ctx, feed.ID)
+	if err != nil {
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to list digest recipients")
+		return
+	}
+	known := make(map[string]bool, len(previous))
+	for _, r := range previous {
+		known[r.Email] = true
+	}
+
+	recipients := make([]store.DigestRecipient, 0, len(emails))
+	for _, email := range emails {
+		token, err := generateSecret()
+		if err != nil {
+			api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to generate unsubscribe token")
+			return
+		}
+		recipients = append(recipients, store.DigestRecipient{
+			FeedID:           feed.ID,
+			Email:            email,
+			UnsubscribeToken: token,
+			CreatedAt:        now,
+		})
+	}
+
+	if err := app.store.UpsertDigest(ctx, d); err != nil {
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to save digest")
+		return
+	}
+	if err := app.store.SetDigestRecipients(ctx, feed.ID, recipients); err != nil {
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to save digest recipients")
+		return
+	}
+
+	saved, err := app.store.GetDigest(ctx, feed.ID)
+	if err != nil {
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to fetch digest")
+		return
+	}
+	stored, err := app.store.ListDigestRecipients(ctx, feed.ID)
+	if err != nil {
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to list digest recipients")
+		return
+	}
+	// only addresses added now are asked, so saving the list again does not
+	// mail everyone who has not confirmed yet
+	for _, r := range stored {
+		if known[r.Email] || r.ConfirmedAt != nil || r.UnsubscribedAt != nil {
+			continue
+		}
+		if err := app.digests.SendConfirmation(feed.Name, saved.Frequency, r); err != nil {
+			log.Printf("warning: failed to send digest confirmation to %s for feed %s: %v", r.Email, feed.ID, err)
+		}
+	}
+	c.JSON(http.StatusOK, digestJSON(saved, stored))
+}
+
+// DELETE /feeds/:id/digest
+func (app *app) deleteDigest(c *gin.Context) {
+	feed, ok := app.ownedFeed(c)
+	if !ok {
+		return
+	}
+
+	if err := app.store.DeleteDigest(c.Request.Context(), feed.ID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			api.AbortJSONError(c, http.StatusNotFound, api.ErrorCodeNotFound, "digest not configured")
+			return
+		}
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to delete digest")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// validateEmails normalizes digest recipient addresses, dropping duplicates.
+// On failure it aborts the request and returns false.
+func (app *app) validateEmails(c *gin.Context, raw []string) ([]string, bool) {
+	if len(raw) == 0 {
+		api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, "at least one recipient is required")
+		return nil, false
+	}
+
+	emails := make([]string, 0, len(raw))
+	seen := make(map[string]struct{}, len(raw))
+	var invalid []string
+	for _, r := range raw {
+		email := strings.ToLower(strings.TrimSpace(r))
+		addr, err := mail.ParseAddress(email)
+		if err != nil || addr.Address != email || len(email) > maxEmailLength {
+			invalid = append(invalid, r)
+			continue
+		}
+		if _, ok := seen[email]; ok {
+			continue
+		}
+		seen[email] = struct{}{}
+		emails = append(emails, email)
+	}
+	if len(invalid) > 0 {
+		api.AbortJSONErrorWithDetails(c, http.StatusBadRequest, api.ErrorCodeValidation, "invalid recipient address(es)", invalid)
+		return nil, false
+	}
+	if len(emails) > app.cfg().Digest.MaxRecipients {
+		api.AbortJSONError(c, http.StatusForbidden, api.ErrorCodeQuota, "Digest recipient limit reached")
+		return nil, false
+	}
+	return emails, true
+}
+
+func digestJSON(d *store.Digest, recipients []store.DigestRecipient) gin.H {
+	list := make([]gin.H, 0, len(recipients))
+	for _, r := range recipients {
+		list = append(list, gin.H{
+			"email":        r.Email,
+			"confirmed":    r.ConfirmedAt != nil,
+			"unsubscribed": r.UnsubscribedAt != nil,
+		})
+	}
+
+	var lastSentAt *string
+	if d.LastSentAt != nil {
+		s := d.LastSentAt.Format(time.RFC3339)
+		lastSentAt = &s
+	}
+	return gin.H{
+		"feed_id":         d.FeedID,
+		"frequency":       d.Frequency,
+		"enabled":         d.Enabled,
+		"recipients":      list,
+		"next_run_at":     d.NextRunAt.Format(time.RFC3339),
+		"last_sent_at":    lastSentAt,
+		"last_item_count": d.LastItemCount,
+		"last_error":      d.LastError,
+		"created_at":      d.CreatedAt.Format(time.RFC3339),
+		"updated_at":      d.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+var digestPage = template.Must(template.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>{{.Title}}</title></head>
+<body style="font-family: -apple-system, Segoe UI, Helvetica, Arial, sans-serif; max-width: 480px; margin: 48px auto;">
+<p>{{.Message}}</p>
+{{if .Token}}<form method="post" action="{{.Action}}">
+<input type="hidden" name="token" value="{{.Token}}">
+<button type="submit">{{.Title}}</button>
+</form>{{end}}
+</body>
+</html>
+`))
+
+type digestPageData struct {
+	Title   string // page title and button label
+	Message string
+	Action  string // form target, with Token
+	Token   string // set to ask for confirmation
+}
+
+// GET /digest/confirm?token=...
+//
+// Like the unsubscribe page, asks for a click so link scanners do not
+// confirm addresses on the recipient's behalf.
+func (app *app) confirmDigestPage(c *gin.Context) {
+	page := digestPageData{Title: "Confirm"}
+	token := c.Query("token")
+	if token == "" {
+		page.Message = "This confirmation link is incomplete."
+		renderDigestPage(c, http.StatusBadRequest, page)
+		return
+	}
+	page.Message = "Receive this feed's email digest at your address?"
+	page.Action = "/digest/confirm"
+	page.Token = token
+	renderDigestPage(c, http.StatusOK, page)
+}
+
+// POST /digest/confirm?token=...
+func (app *app) confirmDigest(c *gin.Context) {
+	page := digestPageData{Title: "Confirm"}
+	token := c.Query("token")
+	if token == "" {
+		token = c.PostForm("token")
+	}
+	if token == "" {
+		page.Message = "This confirmation link is incomplete."
+		renderDigestPage(c, http.StatusBadRequest, page)
+		return
+	}
+
+	r, err := app.store.ConfirmDigestRecipient(c.Request.Context(), token, time.Now())
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			page.Message = "This confirmation link is not valid anymore."
+			renderDigestPage(c, http.StatusNotFound, page)
+			return
+		}
+		page.Message = "Something went wrong. Please try again later."
+		renderDigestPage(c, http.StatusInternalServerError, page)
+		return
+	}
+	if r.UnsubscribedAt != nil {
+		page.Message = "You unsubscribed from this digest and will not receive it."
+	} else {
+		page.Message = "Your address is confirmed. You will receive this feed's digest."
+	}
+	renderDigestPage(c, http.StatusOK, page)
+}
+
+// GET /digest/unsubscribe?token=...
+//
+// Asks for confirmation so link scanners that follow every URL in an email
+// do not unsubscribe the recipient.
+func (app *app) unsubscribeDigestPage(c *gin.Context) {
+	page := digestPageData{Title: "Unsubscribe"}
+	token := c.Query("token")
+	if token == "" {
+		page.Message = "This unsubscribe link is incomplete."
+		renderDigestPage(c, http.StatusBadRequest, page)
+		return
+	}
+	page.Message = "Stop receiving this feed's email digest?"
+	page.Action = "/digest/unsubscribe"
+	page.Token = token
+	renderDigestPage(c, http.StatusOK, page)
+}
+
+// POST /digest/unsubscribe?token=...
+//
+// Also the one-click unsubscribe target of the List-Unsubscribe header, and
+// how a recipient declines the confirmation email.
+func (app *app) unsubscribeDigest(c *gin.Context) {
+	page := digestPageData{Title: "Unsubscribe"}
+	token := c.Query("token")
+	if token == "" {
+		token = c.PostForm("token")
+	}
+	if token == "" {
+		page.Message = "This unsubscribe link is incomplete."
+		renderDigestPage(c, http.StatusBadRequest, page)
+		return
+	}
+
+	if _, err := app.store.UnsubscribeDigestRecipient(c.Request.Context(), token, time.Now()); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			page.Message = "This unsubscribe link is not valid anymore."
+			renderDigestPage(c, http.StatusNotFound, page)
+			return
+		}
+		page.Message = "Something went wrong. Please try again later."
+		renderDigestPage(c, http.StatusInternalServerError, page)
+		return
+	}
+	page.Message = "You have been unsubscribed and will not receive this digest anymore."
+	renderDigestPage(c, http.StatusOK, page)
+}
+
+func renderDigestPage(c *gin.Context, status int, data digestPageData) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	_ = digestPage.Execute(c.Writer, data)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"leetcode-rss/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unsubscribeStore is a store that only knows digest recipient tokens; any
+// other call panics through the nil embedded Store.
+type unsubscribeStore struct {
+	store.Store
+	tokens map[string]*store.DigestRecipient
+}
+
+func (s *unsubscribeStore) ConfirmDigestRecipient(_ context.Context, token string, at time.Time) (*store.DigestRecipient, error) {
+	r, ok := s.tokens[token]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	if r.ConfirmedAt == nil {
+		r.ConfirmedAt = &at
+	}
+	return r, nil
+}
+
+func (s *unsubscribeStore) UnsubscribeDigestRecipient(_ context.Context, token string, at time.Time) (*store.DigestRecipient, error) {
+	r, ok := s.tokens[token]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	if r.UnsubscribedAt == nil {
+		r.UnsubscribedAt = &at
+	}
+	return r, nil
+}
+
+func newUnsubscribeRouter(s store.Store) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	app := &app{store: s}
+	r := gin.New()
+	r.GET("/digest/confirm", app.confirmDigestPage)
+	r.POST("/digest/confirm", app.confirmDigest)
+	r.GET("/digest/unsubscribe", app.unsubscribeDigestPage)
+	r.POST("/digest/unsubscribe", app.unsubscribeDigest)
+	return r
+}
+
+func TestUnsubscribeDigest(t *testing.T) {
+	recipient := &store.DigestRecipient{FeedID: "feed-1", Email: "reader@example.com", UnsubscribeToken: "tok+en/="}
+	s := &unsubscribeStore{tokens: map[string]*store.DigestRecipient{recipient.UnsubscribeToken: recipient}}
+	r := newUnsubscribeRouter(s)
+	target := "/digest/unsubscribe?token=" + url.QueryEscape(recipient.UnsubscribeToken)
+
+	// a link scanner following the URL only gets the confirmation page
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET: status %d", w.Code)
+	}
+	if recipient.UnsubscribedAt != nil {
+		t.Fatal("GET unsubscribed the recipient")
+	}
+	if !strings.Contains(w.Body.String(), `value="tok&#43;en/="`) {
+		t.Errorf("confirmation form does not carry the token:\n%s", w.Body.String())
+	}
+
+	// RFC 8058 one-click: POST to the List-Unsubscribe URL
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader("List-Unsubscribe=One-Click"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("one-click POST: status %d", w.Code)
+	}
+	if recipient.UnsubscribedAt == nil {
+		t.Fatal("one-click POST did not unsubscribe the recipient")
+	}
+	first := *recipient.UnsubscribedAt
+
+	// the confirmation form posts the token in the body
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/digest/unsubscribe", strings.NewReader("token="+url.QueryEscape(recipient.UnsubscribeToken)))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !recipient.UnsubscribedAt.Equal(first) {
+		t.Errorf("repeated unsubscribe: status %d, unsubscribed at %s, want %s", w.Code, recipient.UnsubscribedAt, first)
+	}
+}
+
+func TestUnsubscribeDigestRejectsUnknownToken(t *testing.T) {
+	r := newUnsubscribeRouter(&unsubscribeStore{tokens: map[string]*store.DigestRecipient{}})
+
+	for _, tt := range []struct {
+		target string
+		want   int
+	}{
+		{"/digest/unsubscribe?token=nope", http.StatusNotFound},
+		{"/digest/unsubscribe", http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.target, nil))
+		if w.Code != tt.want {
+			t.Errorf("POST %s: status %d, want %d", tt.target, w.Code, tt.want)
+		}
+	}
+}
+
+func TestConfirmDigest(t *testing.T) {
+	recipient := &store.DigestRecipient{FeedID: "feed-1", Email: "reader@example.com", UnsubscribeToken: "tok+en/="}
+	s := &unsubscribeStore{tokens: map[string]*store.DigestRecipient{recipient.UnsubscribeToken: recipient}}
+	r := newUnsubscribeRouter(s)
+	target := "/digest/confirm?token=" + url.QueryEscape(recipient.UnsubscribeToken)
+
+	// a link scanner following the URL does not confirm the address
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET: status %d", w.Code)
+	}
+	if recipient.ConfirmedAt != nil {
+		t.Fatal("GET confirmed the recipient")
+	}
+	if !strings.Contains(w.Body.String(), `action="/digest/confirm"`) || !strings.Contains(w.Body.String(), `value="tok&#43;en/="`) {
+		t.Errorf("confirmation form does not post the token to /digest/confirm:\n%s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/digest/confirm", strings.NewReader("token="+url.QueryEscape(recipient.UnsubscribeToken)))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || recipient.ConfirmedAt == nil {
+		t.Fatalf("POST: status %d, confirmed at %v", w.Code, recipient.ConfirmedAt)
+	}
+
+	// unknown tokens and missing ones
+	for _, tt := range []struct {
+		target string
+		want   int
+	}{
+		{"/digest/confirm?token=nope", http.StatusNotFound},
+		{"/digest/confirm", http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.target, nil))
+		if w.Code != tt.want {
+			t.Errorf("POST %s: status %d, want %d", tt.target, w.Code, tt.want)
+		}
+	}
+}
@@ -3,14 +3,18 @@ package main
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"time"
 
 	"leetcode-rss/internal/api"
+	"leetcode-rss/internal/config"
 	"leetcode-rss/internal/leetcode"
+	"leetcode-rss/internal/rss"
 	"leetcode-rss/internal/store"
 
 	"github.com/gin-gonic/gin"
@@ -20,9 +24,10 @@ import (
 const (
 	defaultFirstPerUser = 15
 	minFirstPerUser     = 1
-	maxFirstPerUser     = 50
+	maxFirstPerUser     = 500
 	maxFeedNameLength   = 100
 	secretBytes         = 32
+	defaultTimezone     = "UTC"
 )
 
 func (app *app) getCurrentUser(c *gin.Context) {
@@ -69,17 +74,17 @@ func (app *app) listFeeds(c *gin.Context) {
 		return
 	}
 
+	statuses, err := app.store.ListFeedCacheStatusByUserID(c.Request.Context(), userID)
+	if err != nil {
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to fetch feed status")
+		return
+	}
+
 	result := make([]gin.H, 0, len(feeds))
 	for _, feed := range feeds {
-		result = append(result, gin.H{
-			"id":             feed.ID,
-			"name":           feed.Name,
-			"usernames":      feed.Usernames,
-			"first_per_user": feed.FirstPerUser,
-			"enabled":        feed.Enabled,
-			"url":            app.feedURL(feed.ID, feed.Secret),
-			"created_at":     feed.CreatedAt.Format(time.RFC3339),
-		})
+		out := app.feedJSON(&feed)
+		out["health"] = feedHealthSummaryJSON(&feed, statuses[feed.ID])
+		result = append(result, out)
 	}
 
 	c.JSON(http.StatusOK, result)
@@ -97,82 +102,16 @@ func (app *app) createFeed(c *gin.Context) {
 		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to count feeds")
 		return
 	}
-	if feedCount >= app.config.Limits.MaxFeedsPerUser {
+	if feedCount >= app.cfg().Limits.MaxFeedsPerUser {
 		api.AbortJSONError(c, http.StatusForbidden, api.ErrorCodeQuota, "Feed limit reached")
 		return
 	}
 
-	var req struct {
-		Name         string   `json:"name"`
-		Usernames    []string `json:"usernames"`
-		FirstPerUser *int     `json:"first_per_user"`
-		Enabled      *bool    `json:"enabled"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		api.AbortJSONErrorWithDetails(c, http.StatusBadRequest, api.ErrorCodeValidation, "invalid request body", err.Error())
-		return
-	}
-
-	req.Name = strings.TrimSpace(req.Name)
-	if req.Name == "" {
-		api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, "name is required")
-		return
-	}
-	if len(req.Name) > maxFeedNameLength {
-		api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, fmt.Sprintf("name must be at most %d characters", maxFeedNameLength))
-		return
-	}
-
-	if len(req.Usernames) == 0 {
-		api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, "at least one username is required")
-		return
-	}
-
-	validUsernames := make([]string, 0, len(req.Usernames))
-	seen := make(map[string]struct{})
-	invalidUsernames := make([]string, 0)
-
-	for _, username := range req.Usernames {
-		username = strings.TrimSpace(username)
-		if username == "" {
-			continue
-		}
-		if err := leetcode.ValidateUsername(username); err != nil {
-			invalidUsernames = append(invalidUsernames, username)
-			continue
-		}
-		if _, exists := seen[username]; !exists {
-			seen[username] = struct{}{}
-			validUsernames = append(validUsernames, username)
-		}
-	}
-
-	if len(invalidUsernames) > 0 {
-		api.AbortJSONErrorWithDetails(c, http.StatusBadRequest, api.ErrorCodeValidation, "invalid usernames", invalidUsernames)
-		return
-	}
-
-	if len(validUsernames) == 0 {
-		api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, "at least one valid username is required")
-		return
-	}
-
-	if len(validUsernames) > app.config.Limits.MaxUsernamesPerFeed {
-		api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, fmt.Sprintf("maximum %d usernames per feed", app.config.Limits.MaxUsernamesPerFeed))
+	feed, ok := app.bindNewFeed(c, userID, true)
+	if !ok {
 		return
 	}
 
-	firstPerUser := defaultFirstPerUser
-	if req.FirstPerUser != nil {
-		firstPerUser = clampInt(*req.FirstPerUser, minFirstPerUser, maxFirstPerUser)
-	}
-
-	enabled := true
-	if req.Enabled != nil {
-		enabled = *req.Enabled
-	}
-
 	secret, err := generateSecret()
 	if err != nil {
 		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to generate secret")
@@ -180,97 +119,44 @@ func (app *app) createFeed(c *gin.Context) {
 	}
 
 	now := time.Now()
-	feed := &store.Feed{
-		ID:           uuid.NewString(),
-		UserID:       userID,
-		Name:         req.Name,
-		Secret:       secret,
-		Usernames:    validUsernames,
-		FirstPerUser: firstPerUser,
-		Enabled:      enabled,
-		CreatedAt:    now,
-		UpdatedAt:    now,
-	}
+	feed.ID = uuid.NewString()
+	feed.Secret = secret
+	feed.CreatedAt = now
+	feed.UpdatedAt = now
 
 	if err := app.store.CreateFeed(c.Request.Context(), feed); err != nil {
 		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to create feed")
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"id":             feed.ID,
-		"name":           feed.Name,
-		"usernames":      feed.Usernames,
-		"first_per_user": feed.FirstPerUser,
-		"enabled":        feed.Enabled,
-		"url":            app.feedURL(feed.ID, feed.Secret),
-		"created_at":     feed.CreatedAt.Format(time.RFC3339),
-	})
+	c.JSON(http.StatusCreated, app.feedJSON(feed))
 }
 
 func (app *app) getFeed(c *gin.Context) {
-	userID, ok := api.GetUserID(c)
+	feed, ok := app.ownedFeed(c)
 	if !ok {
-		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "missing user context")
-		return
-	}
-
-	feedID := c.Param("id")
-	feed, err := app.store.GetFeedByID(c.Request.Context(), feedID)
-	if err != nil {
-		if errors.Is(err, store.ErrNotFound) {
-			api.AbortJSONError(c, http.StatusNotFound, api.ErrorCodeNotFound, "feed not found")
-			return
-		}
-		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to fetch feed")
 		return
 	}
 
-	if feed.UserID != userID {
-		api.AbortJSONError(c, http.StatusForbidden, api.ErrorCodeForbidden, "you do not own this feed")
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"id":             feed.ID,
-		"name":           feed.Name,
-		"usernames":      feed.Usernames,
-		"first_per_user": feed.FirstPerUser,
-		"enabled":        feed.Enabled,
-		"url":            app.feedURL(feed.ID, feed.Secret),
-		"created_at":     feed.CreatedAt.Format(time.RFC3339),
-		"updated_at":     feed.UpdatedAt.Format(time.RFC3339),
-	})
+	c.JSON(http.StatusOK, app.feedJSON(feed))
 }
 
 func (app *app) updateFeed(c *gin.Context) {
-	userID, ok := api.GetUserID(c)
+	feed, ok := app.ownedFeed(c)
 	if !ok {
-		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "missing user context")
-		return
-	}
-
-	feedID := c.Param("id")
-	feed, err := app.store.GetFeedByID(c.Request.Context(), feedID)
-	if err != nil {
-		if errors.Is(err, store.ErrNotFound) {
-			api.AbortJSONError(c, http.StatusNotFound, api.ErrorCodeNotFound, "feed not found")
-			return
-		}
-		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to fetch feed")
-		return
-	}
-
-	if feed.UserID != userID {
-		api.AbortJSONError(c, http.StatusForbidden, api.ErrorCodeForbidden, "you do not own this feed")
 		return
 	}
 
 	var req struct {
-		Name         *string  `json:"name"`
-		Usernames    []string `json:"usernames"`
-		FirstPerUser *int     `json:"first_per_user"`
-		Enabled      *bool    `json:"enabled"`
+		Name           *string         `json:"name"`
+		Usernames      []string        `json:"usernames"`
+		FirstPerUser   *int            `json:"first_per_user"`
+		Since          *string         `json:"since"`
+		IncludeContent *bool           `json:"include_content"`
+		Filter         json.RawMessage `json:"filter"`
+		GroupBy        *string         `json:"group_by"`
+		Timezone       *string         `json:"timezone"`
+		Enabled        *bool           `json:"enabled"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -294,53 +180,58 @@ func (app *app) updateFeed(c *gin.Context) {
 	}
 
 	if req.Usernames != nil {
-		if len(req.Usernames) == 0 {
-			api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, "at least one username is required")
+		validUsernames, ok := app.validateUsernames(c, req.Usernames)
+		if !ok {
 			return
 		}
 
-		validUsernames := make([]string, 0, len(req.Usernames))
-		seen := make(map[string]struct{})
-		invalidUsernames := make([]string, 0)
-
-		for _, username := range req.Usernames {
-			username = strings.TrimSpace(username)
-			if username == "" {
-				continue
-			}
-			if err := leetcode.ValidateUsername(username); err != nil {
-				invalidUsernames = append(invalidUsernames, username)
-				continue
-			}
-			if _, exists := seen[username]; !exists {
-				seen[username] = struct{}{}
-				validUsernames = append(validUsernames, username)
-			}
-		}
+		feed.Usernames = validUsernames
+		needsCacheInvalidation = true
+	}
 
-		if len(invalidUsernames) > 0 {
-			api.AbortJSONErrorWithDetails(c, http.StatusBadRequest, api.ErrorCodeValidation, "invalid usernames", invalidUsernames)
-			return
+	if req.FirstPerUser != nil {
+		newFirstPerUser := clampInt(*req.FirstPerUser, minFirstPerUser, maxFirstPerUser)
+		if newFirstPerUser != feed.FirstPerUser {
+			feed.FirstPerUser = newFirstPerUser
+			needsCacheInvalidation = true
 		}
+	}
 
-		if len(validUsernames) > app.config.Limits.MaxUsernamesPerFeed {
-			api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, fmt.Sprintf("maximum %d usernames per feed", app.config.Limits.MaxUsernamesPerFeed))
+	if req.Since != nil {
+		since, err := parseSince(*req.Since)
+		if err != nil {
+			api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, "since must be an RFC3339 timestamp or YYYY-MM-DD date")
 			return
 		}
-
-		if len(validUsernames) == 0 {
-			api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, "at least one valid username is required")
-			return
+		if !equalTimePtr(since, feed.Since) {
+			feed.Since = since
+			needsCacheInvalidation = true
 		}
+	}
 
-		feed.Usernames = validUsernames
+	if req.IncludeContent != nil && *req.IncludeContent != feed.IncludeContent {
+		feed.IncludeContent = *req.IncludeContent
 		needsCacheInvalidation = true
 	}
 
-	if req.FirstPerUser != nil {
-		newFirstPerUser := clampInt(*req.FirstPerUser, minFirstPerUser, maxFirstPerUser)
-		if newFirstPerUser != feed.FirstPerUser {
-			feed.FirstPerUser = newFirstPerUser
+	if len(req.Filter) > 0 {
+		filter, problems := app.parseFilter(req.Filter)
+		if len(problems) > 0 {
+			api.AbortJSONErrorWithDetails(c, http.StatusBadRequest, api.ErrorCodeValidation, "invalid filter", problems)
+			return
+		}
+		if !equalFilter(filter, feed.Filter) {
+			feed.Filter = filter
+			needsCacheInvalidation = true
+		}
+	}
+
+	if req.GroupBy != nil || req.Timezone != nil {
+		groupBy, timezone := feed.GroupBy, feed.Timezone
+		if !applyGrouping(c, feed, req.GroupBy, req.Timezone) {
+			return
+		}
+		if feed.GroupBy != groupBy || feed.Timezone != timezone {
 			needsCacheInvalidation = true
 		}
 	}
@@ -358,40 +249,17 @@ func (app *app) updateFeed(c *gin.Context) {
 
 	if needsCacheInvalidation {
 		_ = app.store.InvalidateFeedCache(c.Request.Context(), feed.ID)
+		// the next build may surface older articles; treat it as a new
+		// baseline instead of announcing them to webhooks
+		_ = app.store.ResetFeedItems(c.Request.Context(), feed.ID)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"id":             feed.ID,
-		"name":           feed.Name,
-		"usernames":      feed.Usernames,
-		"first_per_user": feed.FirstPerUser,
-		"enabled":        feed.Enabled,
-		"url":            app.feedURL(feed.ID, feed.Secret),
-		"created_at":     feed.CreatedAt.Format(time.RFC3339),
-		"updated_at":     feed.UpdatedAt.Format(time.RFC3339),
-	})
+	c.JSON(http.StatusOK, app.feedJSON(feed))
 }
 
 func (app *app) rotateFeedSecret(c *gin.Context) {
-	userID, ok := api.GetUserID(c)
+	feed, ok := app.ownedFeed(c)
 	if !ok {
-		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "missing user context")
-		return
-	}
-
-	feedID := c.Param("id")
-	feed, err := app.store.GetFeedByID(c.Request.Context(), feedID)
-	if err != nil {
-		if errors.Is(err, store.ErrNotFound) {
-			api.AbortJSONError(c, http.StatusNotFound, api.ErrorCodeNotFound, "feed not found")
-			return
-		}
-		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to fetch feed")
-		return
-	}
-
-	if feed.UserID != userID {
-		api.AbortJSONError(c, http.StatusForbidden, api.ErrorCodeForbidden, "you do not own this feed")
 		return
 	}
 
@@ -409,51 +277,238 @@ func (app *app) rotateFeedSecret(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"id":             feed.ID,
-		"name":           feed.Name,
-		"usernames":      feed.Usernames,
-		"first_per_user": feed.FirstPerUser,
-		"enabled":        feed.Enabled,
-		"url":            app.feedURL(feed.ID, feed.Secret),
-		"created_at":     feed.CreatedAt.Format(time.RFC3339),
-		"updated_at":     feed.UpdatedAt.Format(time.RFC3339),
-	})
+	// WebSub topics embed the old secret and no longer resolve
+	if err := app.store.DeleteWebSubSubscriptionsByFeed(c.Request.Context(), feed.ID); err != nil {
+		log.Printf("warning: failed to drop websub subscriptions of feed %s: %v", feed.ID, err)
+	}
+
+	c.JSON(http.StatusOK, app.feedJSON(feed))
 }
 
 func (app *app) deleteFeed(c *gin.Context) {
+	feed, ok := app.ownedFeed(c)
+	if !ok {
+		return
+	}
+
+	if err := app.store.DeleteFeed(c.Request.Context(), feed.ID); err != nil {
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to delete feed")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// feedRequest is the body of POST /feeds and POST /feeds/preview.
+type feedRequest struct {
+	Name           string          `json:"name"`
+	Usernames      []string        `json:"usernames"`
+	FirstPerUser   *int            `json:"first_per_user"`
+	Since          *string         `json:"since"`
+	IncludeContent *bool           `json:"include_content"`
+	Filter         json.RawMessage `json:"filter"`
+	GroupBy        *string         `json:"group_by"`
+	Timezone       *string         `json:"timezone"`
+	Enabled        *bool           `json:"enabled"`
+}
+
+// bindNewFeed validates a feedRequest body into an unsaved feed owned by
+// userID, applying defaults for omitted settings. ID, secret and timestamps
+// are left for the caller. On failure it aborts the request and returns false.
+func (app *app) bindNewFeed(c *gin.Context, userID string, requireName bool) (*store.Feed, bool) {
+	var req feedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.AbortJSONErrorWithDetails(c, http.StatusBadRequest, api.ErrorCodeValidation, "invalid request body", err.Error())
+		return nil, false
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" && requireName {
+		api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, "name is required")
+		return nil, false
+	}
+	if len(req.Name) > maxFeedNameLength {
+		api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, fmt.Sprintf("name must be at most %d characters", maxFeedNameLength))
+		return nil, false
+	}
+
+	usernames, ok := app.validateUsernames(c, req.Usernames)
+	if !ok {
+		return nil, false
+	}
+
+	feed := &store.Feed{
+		UserID:       userID,
+		Name:         req.Name,
+		Usernames:    usernames,
+		FirstPerUser: defaultFirstPerUser,
+		Enabled:      true,
+	}
+	if req.FirstPerUser != nil {
+		feed.FirstPerUser = clampInt(*req.FirstPerUser, minFirstPerUser, maxFirstPerUser)
+	}
+
+	if req.Since != nil {
+		since, err := parseSince(*req.Since)
+		if err != nil {
+			api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, "since must be an RFC3339 timestamp or YYYY-MM-DD date")
+			return nil, false
+		}
+		feed.Since = since
+	}
+
+	if req.IncludeContent != nil {
+		feed.IncludeContent = *req.IncludeContent
+	}
+
+	filter, problems := app.parseFilter(req.Filter)
+	if len(problems) > 0 {
+		api.AbortJSONErrorWithDetails(c, http.StatusBadRequest, api.ErrorCodeValidation, "invalid filter", problems)
+		return nil, false
+	}
+	feed.Filter = filter
+
+	feed.Timezone = defaultTimezone
+	if !applyGrouping(c, feed, req.GroupBy, req.Timezone) {
+		return nil, false
+	}
+
+	if req.Enabled != nil {
+		feed.Enabled = *req.Enabled
+	}
+	return feed, true
+}
+
+// applyGrouping validates and sets the digest mode settings of a feed,
+// leaving those not given unchanged, and reports whether they were valid.
+// On failure it aborts the request.
+func applyGrouping(c *gin.Context, feed *store.Feed, groupBy, timezone *string) bool {
+	if groupBy != nil {
+		g := strings.ToLower(strings.TrimSpace(*groupBy))
+		if g != "" && g != api.GroupByDay && g != api.GroupByWeek {
+			api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, "group_by must be day, week or empty")
+			return false
+		}
+		feed.GroupBy = g
+	}
+	if timezone != nil {
+		tz := strings.TrimSpace(*timezone)
+		if tz == "" {
+			tz = defaultTimezone
+		}
+		// Local would depend on the server's zone
+		if _, err := time.LoadLocation(tz); err != nil || tz == "Local" {
+			api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, fmt.Sprintf("unknown timezone %q: expected an IANA name such as Europe/Berlin", tz))
+			return false
+		}
+		feed.Timezone = tz
+	}
+	return true
+}
+
+// validateUsernames trims and deduplicates usernames and checks them against
+// LeetCode's rules and the per-feed limit. On failure it aborts the request
+// and returns false.
+func (app *app) validateUsernames(c *gin.Context, usernames []string) ([]string, bool) {
+	if len(usernames) == 0 {
+		api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, "at least one username is required")
+		return nil, false
+	}
+
+	validUsernames := make([]string, 0, len(usernames))
+	seen := make(map[string]struct{})
+	invalidUsernames := make([]string, 0)
+
+	for _, username := range usernames {
+		username = strings.TrimSpace(username)
+		if username == "" {
+			continue
+		}
+		if err := leetcode.ValidateUsername(username); err != nil {
+			invalidUsernames = append(invalidUsernames, username)
+			continue
+		}
+		if _, exists := seen[username]; !exists {
+			seen[username] = struct{}{}
+			validUsernames = append(validUsernames, username)
+		}
+	}
+
+	if len(invalidUsernames) > 0 {
+		api.AbortJSONErrorWithDetails(c, http.StatusBadRequest, api.ErrorCodeValidation, "invalid usernames", invalidUsernames)
+		return nil, false
+	}
+
+	if len(validUsernames) == 0 {
+		api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, "at least one valid username is required")
+		return nil, false
+	}
+
+	if len(validUsernames) > app.cfg().Limits.MaxUsernamesPerFeed {
+		api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, fmt.Sprintf("maximum %d usernames per feed", app.cfg().Limits.MaxUsernamesPerFeed))
+		return nil, false
+	}
+	return validUsernames, true
+}
+
+// ownedFeed loads the feed named by the :id path parameter and checks that
+// the current user owns it. On failure it aborts the request and returns false.
+func (app *app) ownedFeed(c *gin.Context) (*store.Feed, bool) {
 	userID, ok := api.GetUserID(c)
 	if !ok {
 		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "missing user context")
-		return
+		return nil, false
 	}
 
-	feedID := c.Param("id")
-	feed, err := app.store.GetFeedByID(c.Request.Context(), feedID)
+	feed, err := app.store.GetFeedByID(c.Request.Context(), c.Param("id"))
 	if err != nil {
 		if errors.Is(err, store.ErrNotFound) {
 			api.AbortJSONError(c, http.StatusNotFound, api.ErrorCodeNotFound, "feed not found")
-			return
+			return nil, false
 		}
 		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to fetch feed")
-		return
+		return nil, false
 	}
 
 	if feed.UserID != userID {
 		api.AbortJSONError(c, http.StatusForbidden, api.ErrorCodeForbidden, "you do not own this feed")
-		return
+		return nil, false
 	}
+	return feed, true
+}
 
-	if err := app.store.DeleteFeed(c.Request.Context(), feedID); err != nil {
-		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to delete feed")
-		return
+func (app *app) feedJSON(feed *store.Feed) gin.H {
+	var since *string
+	if feed.Since != nil {
+		v := feed.Since.UTC().Format(time.RFC3339)
+		since = &v
+	}
+	return gin.H{
+		"id":              feed.ID,
+		"name":            feed.Name,
+		"usernames":       feed.Usernames,
+		"first_per_user":  feed.FirstPerUser,
+		"since":           since,
+		"include_content": feed.IncludeContent,
+		"filter":          feed.Filter,
+		"group_by":        feed.GroupBy,
+		"timezone":        feed.Timezone,
+		"enabled":         feed.Enabled,
+		"url":             app.feedURL(feed.ID, feed.Secret),
+		"atom_url":        app.feedBaseURL(feed.ID, feed.Secret) + rss.FormatAtom.Ext(),
+		"json_url":        app.feedBaseURL(feed.ID, feed.Secret) + rss.FormatJSON.Ext(),
+		"created_at":      feed.CreatedAt.Format(time.RFC3339),
+		"updated_at":      feed.UpdatedAt.Format(time.RFC3339),
 	}
-
-	c.Status(http.StatusNoContent)
 }
 
 func (app *app) feedURL(feedID, secret string) string {
-	return fmt.Sprintf("%s/f/%s/%s.xml", app.config.Database.PublicBaseURL, feedID, secret)
+	return app.feedBaseURL(feedID, secret) + rss.FormatRSS.Ext()
+}
+
+// feedBaseURL is the public feed URL without a format extension.
+func (app *app) feedBaseURL(feedID, secret string) string {
+	return fmt.Sprintf("%s/f/%s/%s", app.cfg().Database.PublicBaseURL, feedID, secret)
 }
 
 func generateSecret() (string, error) {
@@ -464,6 +519,86 @@ func generateSecret() (string, error) {
 	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
+// parseSince accepts the formats of config.ParseSince. An empty string
+// clears the cutoff.
+func parseSince(v string) (*time.Time, error) {
+	t, err := config.ParseSince(v)
+	if err != nil || t.IsZero() {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// filterRequest is the request shape of a feed's filter rules. Dates accept
+// the same formats as since.
+type filterRequest struct {
+	IncludeTitle    string   `json:"include_title"`
+	ExcludeTitle    string   `json:"exclude_title"`
+	QuestionSlugs   []string `json:"question_slugs"`
+	MinHitCount     int      `json:"min_hit_count"`
+	PublishedAfter  string   `json:"published_after"`
+	PublishedBefore string   `json:"published_before"`
+	Difficulties    []string `json:"difficulties"`
+}
+
+// parseFilter validates a filter from a request body. An absent or null
+// filter, or one that filters nothing, yields nil.
+func (app *app) parseFilter(raw json.RawMessage) (*store.FeedFilter, []api.ErrorDetails) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var req filterRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return nil, []api.ErrorDetails{{Field: "filter", Message: err.Error()}}
+	}
+
+	filter := &store.FeedFilter{
+		IncludeTitle:  req.IncludeTitle,
+		ExcludeTitle:  req.ExcludeTitle,
+		QuestionSlugs: req.QuestionSlugs,
+		MinHitCount:   req.MinHitCount,
+		Difficulties:  req.Difficulties,
+	}
+	var problems []api.ErrorDetails
+	var err error
+	if filter.PublishedAfter, err = parseSince(req.PublishedAfter); err != nil {
+		problems = append(problems, api.ErrorDetails{Field: "published_after", Message: "must be an RFC3339 timestamp or YYYY-MM-DD date"})
+	}
+	if filter.PublishedBefore, err = parseSince(req.PublishedBefore); err != nil {
+		problems = append(problems, api.ErrorDetails{Field: "published_before", Message: "must be an RFC3339 timestamp or YYYY-MM-DD date"})
+	}
+	for _, t := range []*time.Time{filter.PublishedAfter, filter.PublishedBefore} {
+		if t != nil {
+			*t = t.UTC()
+		}
+	}
+	problems = append(problems, api.NormalizeFeedFilter(filter, app.questions != nil)...)
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	if filter.IsZero() {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+// equalFilter compares filters by their stored form.
+func equalFilter(a, b *store.FeedFilter) bool {
+	if a.IsZero() || b.IsZero() {
+		return a.IsZero() == b.IsZero()
+	}
+	aj, errA := json.Marshal(a)
+	bj, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aj) == string(bj)
+}
+
+func equalTimePtr(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
 func clampInt(v, min, max int) int {
 	if v < min {
 		return min
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"leetcode-rss/internal/api"
+	"leetcode-rss/internal/leetcode"
+	"leetcode-rss/internal/rss"
+
+	"github.com/gin-gonic/gin"
+)
+
+// POST /feeds/preview
+//
+// Builds a feed from a POST /feeds body without saving it, so it does not
+// count against MaxFeedsPerUser. Nothing the build fetches is stored, and
+// previews are limited per user like manual refreshes. ?format=rss|atom|json
+// also returns the rendered document.
+func (app *app) previewFeed(c *gin.Context) {
+	userID, ok := api.GetUserID(c)
+	if !ok {
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "missing user context")
+		return
+	}
+	if app.publicHandlers == nil {
+		api.AbortJSONError(c, http.StatusServiceUnavailable, api.ErrorCodeInternal, "public feeds are disabled")
+		return
+	}
+
+	var format rss.Format
+	if v := c.Query("format"); v != "" {
+		format = rss.Format(v)
+		if !isFeedFormat(format) {
+			api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, "format must be one of rss, atom or json")
+			return
+		}
+	}
+
+	feed, ok := app.bindNewFeed(c, userID, false)
+	if !ok {
+		return
+	}
+
+	if wait, ok := app.previewThrottle.allow(userID, "", time.Now()); !ok {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		api.AbortJSONError(c, http.StatusTooManyRequests, api.ErrorCodeRateLimited,
+			fmt.Sprintf("too many previews, try again in %s", wait.Round(time.Second)))
+		return
+	}
+
+	result, err := app.publicHandlers.PreviewFeed(c.Request.Context(), feed)
+	if err != nil {
+		log.Printf("feed preview for user %s failed: %v", userID, err)
+		if result != nil {
+			status, code := http.StatusBadGateway, api.ErrorCodeUpstream
+			var rateErr *leetcode.RateLimitError
+			if errors.As(err, &rateErr) {
+				status, code = http.StatusTooManyRequests, api.ErrorCodeRateLimited
+				if rateErr.RetryAfter > 0 {
+					c.Header("Retry-After", strconv.Itoa(int(rateErr.RetryAfter.Seconds())))
+				}
+			}
+			api.AbortJSONErrorWithDetails(c, status, code, "articles could not be fetched for any username", result.Users)
+			return
+		}
+		api.AbortUpstreamError(c, err)
+		return
+	}
+
+	built := result.Feed
+
+	items := make([]gin.H, 0, len(built.Items))
+	for _, item := range built.Items {
+		categories := item.Categories
+		if categories == nil {
+			categories = []string{}
+		}
+		var contentHTML *string
+		if item.ContentHTML != "" {
+			contentHTML = &item.ContentHTML
+		}
+		items = append(items, gin.H{
+			"title":        item.Title,
+			"link":         item.Link,
+			"guid":         item.GUID,
+			"published_at": formatOptionalTime(item.PubDate),
+			"author":       item.Author,
+			"summary":      item.Summary,
+			"categories":   categories,
+			"content_html": contentHTML,
+		})
+	}
+
+	out := gin.H{
+		"title":             built.Title,
+		"link":              built.Link,
+		"description":       built.Description,
+		"items":             items,
+		"item_count":        result.ItemCount,
+		"build_duration_ms": result.Duration.Milliseconds(),
+		"usernames":         result.Users,
+	}
+	if format != "" {
+		doc, err := rss.RenderFormat(built, format)
+		if err != nil {
+			api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to render feed")
+			return
+		}
+		out["format"] = format
+		out["document"] = string(doc)
+	}
+
+	c.JSON(http.StatusOK, out)
+}
+
+func isFeedFormat(f rss.Format) bool {
+	for _, known := range rss.Formats {
+		if f == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"leetcode-rss/internal/api"
+	"leetcode-rss/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Feed health values reported by the status endpoints.
+const (
+	feedHealthOK       = "ok"       // last build succeeded for every username
+	feedHealthDegraded = "degraded" // last build dropped usernames or fell back to history
+	feedHealthFailing  = "failing"  // last build failed; readers get stale content, if any
+	feedHealthPending  = "pending"  // never built
+	feedHealthDisabled = "disabled"
+)
+
+// GET /feeds/:id/status
+func (app *app) getFeedStatus(c *gin.Context) {
+	feed, ok := app.ownedFeed(c)
+	if !ok {
+		return
+	}
+
+	cache, err := app.store.GetFeedCacheStatus(c.Request.Context(), feed.ID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to fetch feed status")
+		return
+	}
+
+	c.JSON(http.StatusOK, feedStatusJSON(feed, cache, time.Now()))
+}
+
+func feedStatusJSON(feed *store.Feed, cache *store.FeedCache, now time.Time) gin.H {
+	usernames := []store.UsernameStatus{}
+	out := gin.H{
+		"feed_id":           feed.ID,
+		"enabled":           feed.Enabled,
+		"health":            feedHealth(feed, cache),
+		"fresh":             false,
+		"last_built_at":     nil,
+		"expires_at":        nil,
+		"last_error":        nil,
+		"item_count":        0,
+		"build_duration_ms": 0,
+		"usernames":         usernames,
+	}
+	if cache == nil {
+		return out
+	}
+
+	out["fresh"] = !cache.LastBuiltAt.IsZero() && cache.ExpiresAt.After(now)
+	out["last_built_at"] = formatOptionalTime(cache.LastBuiltAt)
+	out["expires_at"] = formatOptionalTime(cache.ExpiresAt)
+	out["last_error"] = cache.LastError
+	out["item_count"] = cache.ItemCount
+	out["build_duration_ms"] = cache.BuildTime.Milliseconds()
+	if cache.UserStatus != nil {
+		out["usernames"] = cache.UserStatus
+	}
+	return out
+}
+
+// feedHealthSummaryJSON is the compact health shown for each feed in GET /feeds.
+func feedHealthSummaryJSON(feed *store.Feed, cache *store.FeedCache) gin.H {
+	failed := []string{}
+	var lastBuiltAt *string
+	if cache != nil {
+		lastBuiltAt = formatOptionalTime(cache.LastBuiltAt)
+		for _, u := range cache.UserStatus {
+			if !u.OK {
+				failed = append(failed, u.Username)
+			}
+		}
+	}
+	return gin.H{
+		"status":           feedHealth(feed, cache),
+		"last_built_at":    lastBuiltAt,
+		"failed_usernames": failed,
+	}
+}
+
+func feedHealth(feed *store.Feed, cache *store.FeedCache) string {
+	if !feed.Enabled {
+		return feedHealthDisabled
+	}
+	if cache == nil {
+		return feedHealthPending
+	}
+
+	anyOK, anyDegraded := false, false
+	for _, u := range cache.UserStatus {
+		if u.OK {
+			anyOK = true
+		}
+		if !u.OK || u.FromHistory {
+			anyDegraded = true
+		}
+	}
+	switch {
+	case cache.LastError != nil && !anyOK:
+		return feedHealthFailing
+	case cache.LastError != nil || anyDegraded:
+		return feedHealthDegraded
+	case cache.LastBuiltAt.IsZero():
+		return feedHealthPending
+	default:
+		return feedHealthOK
+	}
+}
+
+func formatOptionalTime(t time.Time) *string {
+	if t.IsZero() {
+		return nil
+	}
+	v := t.UTC().Format(time.RFC3339)
+	return &v
+}
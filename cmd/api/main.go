@@ -1,48 +1,131 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	_ "time/tzdata" // feed time zones must not depend on the host's zoneinfo
 
 	"leetcode-rss/internal/api"
 	"leetcode-rss/internal/config"
+	"leetcode-rss/internal/digest"
 	"leetcode-rss/internal/leetcode"
+	"leetcode-rss/internal/netguard"
 	"leetcode-rss/internal/store"
+	"leetcode-rss/internal/webhook"
+	"leetcode-rss/internal/websub"
 
 	"github.com/clerk/clerk-sdk-go/v2"
 )
 
 type app struct {
-	config         *config.Config
-	store          store.Store
-	leetcodeClient *leetcode.Client
-	handlers       *api.Handlers
-	publicHandlers *api.PublicFeedHandlers
+	// config is replaced on reload; read it through cfg.
+	config          atomic.Pointer[config.Config]
+	configPath      string
+	store           store.Store
+	storeErr        error // why store is nil
+	leetcodeClient  *leetcode.Client
+	questions       *leetcode.QuestionCache
+	handlers        *api.Handlers
+	publicHandlers  *api.PublicFeedHandlers
+	refreshThrottle *refreshThrottle
+	previewThrottle *refreshThrottle
+	digests         *digest.Scheduler   // nil when email digests are disabled
+	hub             *websub.Hub         // nil when WebSub is disabled
+	webhooks        *webhook.Dispatcher // nil when webhooks are disabled
+	metrics         *appMetrics         // nil when metrics are disabled
+
+	// background tracks the loops started with spawn.
+	background sync.WaitGroup
+}
+
+func (app *app) cfg() *config.Config {
+	return app.config.Load()
 }
 
 func main() {
-	cfg, err := config.Load()
+	configPath := flag.String("config", "", "YAML or TOML config file layered under environment variables (default $CONFIG_FILE)")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
 	if err != nil {
 		log.Fatal(err)
 	}
+	static, err := staticFeeds(cfg.StaticFeeds, cfg.LeetCode.QuestionCacheTTL > 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
 	lc := leetcode.New(cfg.LeetCode.GraphQLEndpoint, cfg.LeetCode.Cookie, cfg.LeetCode.CSRF)
+	lc.Retry = leetcode.RetryPolicy{
+		MaxAttempts: cfg.LeetCode.RetryMaxAttempts,
+		BaseDelay:   cfg.LeetCode.RetryBaseDelay,
+		MaxDelay:    cfg.LeetCode.RetryMaxDelay,
+	}
+	lc.Limiter = leetcode.NewLimiter(cfg.LeetCode.RequestsPerSecond, cfg.LeetCode.Burst, cfg.LeetCode.MaxInFlight)
+	lc.Outcomes = &leetcode.Outcomes{}
 
-	svc := api.UGCFeedService{
-		Usernames: cfg.LeetCode.Usernames,
-		LC:        lc,
-		First:     cfg.LeetCode.MaxArticlesPerUser,
+	var m *appMetrics
+	if cfg.Metrics.Enabled {
+		m = newAppMetrics()
+		m.countLimiter(lc.Limiter)
+		lc.Observer = m
 	}
 
-	cache := api.NewCache(cfg.Cache.TTL)
-	handlers := api.NewHandlers(svc, cache)
+	var questions *leetcode.QuestionCache
+	if cfg.LeetCode.QuestionCacheTTL > 0 {
+		questions = leetcode.NewQuestionCache(lc, cfg.LeetCode.QuestionCacheTTL)
+	}
 
 	var publicHandlers *api.PublicFeedHandlers
-	s, err := store.NewStore(cfg.Database.URL)
-	if err != nil {
-		log.Printf("warning: failed to initialize database, public feeds disabled: %v", err)
+	var hub *websub.Hub
+	var webhooks *webhook.Dispatcher
+	s, storeErr := store.NewStore(cfg.Database.URL)
+	if storeErr != nil {
+		log.Printf("warning: failed to initialize database, public feeds disabled: %v", storeErr)
 	} else {
-		defer s.Close()
-		publicHandlers = api.NewPublicFeedHandlers(s, lc, cfg.Database.RSSCacheTTL)
+		if m != nil {
+			s = store.Instrument(s, m.observeStore)
+			m.countStore(s)
+		}
+
+		if cfg.WebSub.Enabled {
+			hub = websub.NewHub(s, cfg.Database.PublicBaseURL+"/websub", websub.Options{
+				DefaultLease: cfg.WebSub.DefaultLease,
+				MaxLease:     cfg.WebSub.MaxLease,
+				MaxPerTopic:  cfg.WebSub.MaxPerTopic,
+				Attempts:     cfg.WebSub.DeliveryAttempts,
+			})
+			log.Printf("websub hub enabled at %s", hub.URL)
+		}
+
+		if cfg.Webhooks.Enabled {
+			webhooks = webhook.NewDispatcher(s, webhook.Options{
+				Attempts: cfg.Webhooks.DeliveryAttempts,
+				Client:   netguard.NewClient(cfg.Webhooks.Timeout),
+			})
+		}
+
+		opts := publicFeedOptions(cfg)
+		opts.Questions = questions
+		opts.Hub = hub
+		opts.Webhooks = webhooks
+		opts.Metrics = m.feeds()
+		publicHandlers = api.NewPublicFeedHandlers(s, lc, opts)
 		log.Printf("database initialized, public feeds enabled")
 	}
 
@@ -52,14 +135,152 @@ func main() {
 	}
 
 	app := &app{
-		config:         cfg,
-		store:          s,
-		leetcodeClient: lc,
-		handlers:       handlers,
-		publicHandlers: publicHandlers,
+		configPath:      *configPath,
+		store:           s,
+		storeErr:        storeErr,
+		leetcodeClient:  lc,
+		questions:       questions,
+		publicHandlers:  publicHandlers,
+		refreshThrottle: newRefreshThrottle(cfg.Limits.ManualRefreshInterval, cfg.Limits.MaxManualRefreshesPerHour),
+		previewThrottle: newRefreshThrottle(0, cfg.Limits.MaxPreviewsPerHour),
+		hub:             hub,
+		webhooks:        webhooks,
+		metrics:         m,
+	}
+	app.config.Store(cfg)
+	app.handlers = api.NewHandlers(app.feedService(cfg), app.handlersOptions(cfg, static))
+	for _, f := range static {
+		log.Printf("static feed /static/%s%s (users=%v)", f.Name, f.Format.Ext(), f.Usernames)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		// a second signal kills the process without waiting
+		stop()
+	}()
+
+	app.spawn(ctx, app.watchConfig)
+
+	if publicHandlers != nil && cfg.Refresh.Enabled {
+		app.spawn(ctx, newRefresher(app).run)
+	}
+
+	if publicHandlers != nil && cfg.Digest.SMTPHost != "" {
+		mailer, err := digest.NewMailer(digest.SMTPOptions{
+			Host:     cfg.Digest.SMTPHost,
+			Port:     cfg.Digest.SMTPPort,
+			Username: cfg.Digest.SMTPUsername,
+			Password: cfg.Digest.SMTPPassword,
+			Timeout:  cfg.Digest.SMTPTimeout,
+			From:     cfg.Digest.From,
+		})
+		if err != nil {
+			log.Printf("warning: email digests disabled: %v", err)
+		} else {
+			app.digests = digest.NewScheduler(s, publicHandlers.DigestItems, mailer, digest.Options{
+				Interval:     cfg.Digest.Interval,
+				SendHour:     cfg.Digest.SendHour,
+				MaxItems:     cfg.Digest.MaxItems,
+				BuildTimeout: cfg.Server.HandlerTimeout,
+				BaseURL:      cfg.Database.PublicBaseURL,
+			})
+			app.spawn(ctx, app.digests.Run)
+		}
 	}
 
 	log.Printf("listening on :%d (users=%v)", cfg.Server.Port, cfg.LeetCode.Usernames)
 
-	log.Fatal(app.serve())
+	err = app.serve(ctx)
+	if s != nil {
+		if cerr := s.Close(); cerr != nil {
+			log.Printf("warning: failed to close database: %v", cerr)
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("shutdown complete")
+}
+
+// spawn runs fn until ctx is done; shutdown waits for it to return.
+func (app *app) spawn(ctx context.Context, fn func(context.Context)) {
+	app.background.Add(1)
+	go func() {
+		defer app.background.Done()
+		fn(ctx)
+	}()
+}
+
+// feedService configures the builds behind /leetcode.xml, its ad-hoc
+// variants and the static feeds.
+func (app *app) feedService(cfg *config.Config) api.UGCFeedService {
+	svc := api.UGCFeedService{
+		Usernames:     cfg.LeetCode.Usernames,
+		LC:            app.leetcodeClient,
+		First:         cfg.LeetCode.MaxArticlesPerUser,
+		Since:         cfg.LeetCode.Since,
+		Questions:     app.questions,
+		FailureNotice: cfg.LeetCode.FailureNotice,
+	}
+	if app.store != nil {
+		svc.ArticleCache = app.store
+		svc.CacheTTL = cfg.Database.ArticleCacheTTL
+		svc.History = app.store
+	}
+	return svc
+}
+
+func (app *app) handlersOptions(cfg *config.Config, static []api.StaticFeed) api.HandlersOptions {
+	return api.HandlersOptions{
+		CacheTTL:        cfg.Cache.TTL,
+		CacheSize:       cfg.Cache.MaxEntries,
+		AdHoc:           cfg.Cache.AdHocEnabled,
+		MaxUsernames:    cfg.Limits.MaxUsernamesPerFeed,
+		MaxArticles:     cfg.Cache.AdHocMaxArticles,
+		DefaultArticles: min(cfg.LeetCode.MaxArticlesPerUser, cfg.Cache.AdHocMaxArticles),
+		Static:          static,
+		Metrics:         app.metrics.feeds(),
+		BuildTimeout:    cfg.Server.HandlerTimeout,
+	}
+}
+
+// publicFeedOptions returns the reloadable options of the per-feed handlers.
+func publicFeedOptions(cfg *config.Config) api.PublicFeedOptions {
+	return api.PublicFeedOptions{
+		CacheTTL:        cfg.Database.RSSCacheTTL,
+		ArticleCacheTTL: cfg.Database.ArticleCacheTTL,
+		BuildTimeout:    cfg.Server.HandlerTimeout,
+		FailureNotice:   cfg.LeetCode.FailureNotice,
+	}
+}
+
+// staticFeeds converts the configured static feeds, checking their filters
+// like those of stored feeds. questions reports whether problem metadata is
+// enabled.
+func staticFeeds(feeds []config.StaticFeed, questions bool) ([]api.StaticFeed, error) {
+	static := make([]api.StaticFeed, 0, len(feeds))
+	var problems []string
+	for _, f := range feeds {
+		if f.Filter != nil {
+			for _, p := range api.NormalizeFeedFilter(f.Filter, questions) {
+				problems = append(problems, fmt.Sprintf("feed %q: filter.%s: %s", f.Name, p.Field, p.Message))
+			}
+			if f.Filter.IsZero() {
+				f.Filter = nil
+			}
+		}
+		static = append(static, api.StaticFeed{
+			Name:      f.Name,
+			Title:     f.Title,
+			Usernames: f.Usernames,
+			First:     f.Items,
+			Format:    f.Format,
+			Filter:    f.Filter,
+		})
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid STATIC_FEEDS_FILE:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return static, nil
 }
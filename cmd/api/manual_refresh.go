@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"leetcode-rss/internal/api"
+	"leetcode-rss/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// manualRefreshWindow is the window MaxManualRefreshesPerHour counts over.
+const manualRefreshWindow = time.Hour
+
+// POST /feeds/:id/refresh
+func (app *app) refreshFeed(c *gin.Context) {
+	feed, ok := app.ownedFeed(c)
+	if !ok {
+		return
+	}
+	if app.publicHandlers == nil {
+		api.AbortJSONError(c, http.StatusServiceUnavailable, api.ErrorCodeInternal, "public feeds are disabled")
+		return
+	}
+	if !feed.Enabled {
+		api.AbortJSONError(c, http.StatusConflict, api.ErrorCodeValidation, "feed is disabled")
+		return
+	}
+
+	if wait, ok := app.refreshThrottle.allow(feed.UserID, feed.ID, time.Now()); !ok {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		api.AbortJSONError(c, http.StatusTooManyRequests, api.ErrorCodeRateLimited,
+			fmt.Sprintf("feed was refreshed recently, try again in %s", wait.Round(time.Second)))
+		return
+	}
+
+	ctx := c.Request.Context()
+	if _, err := app.publicHandlers.RefetchFeed(ctx, feed, app.feedBaseURL(feed.ID, feed.Secret)); err != nil {
+		log.Printf("manual refresh of feed %s failed: %v", feed.ID, err)
+		api.AbortUpstreamError(c, err)
+		return
+	}
+
+	cache, err := app.store.GetFeedCacheStatus(ctx, feed.ID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to fetch feed status")
+		return
+	}
+	c.JSON(http.StatusOK, feedStatusJSON(feed, cache, time.Now()))
+}
+
+// refreshThrottle limits manual refreshes to one per feed every
+// feedInterval and perUser per user within manualRefreshWindow. Attempts
+// count whether or not the rebuild succeeds. An empty feed ID, as used for
+// previews, is only limited per user.
+type refreshThrottle struct {
+	mu           sync.Mutex
+	feedInterval time.Duration
+	perUser      int
+	feeds        map[string]time.Time   // feed ID -> last manual refresh
+	users        map[string][]time.Time // user ID -> refreshes within the window, oldest first
+}
+
+func newRefreshThrottle(feedInterval time.Duration, perUser int) *refreshThrottle {
+	return &refreshThrottle{
+		feedInterval: feedInterval,
+		perUser:      perUser,
+		feeds:        make(map[string]time.Time),
+		users:        make(map[string][]time.Time),
+	}
+}
+
+// setLimits changes both limits; refreshes already recorded keep counting.
+func (t *refreshThrottle) setLimits(feedInterval time.Duration, perUser int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.feedInterval = feedInterval
+	t.perUser = perUser
+}
+
+// allow records a refresh of feedID by userID at now if both limits permit
+// it. Otherwise it returns how long to wait before trying again.
+func (t *refreshThrottle) allow(userID, feedID string, now time.Time) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.prune(now)
+
+	if last, ok := t.feeds[feedID]; ok {
+		if wait := last.Add(t.feedInterval).Sub(now); wait > 0 {
+			return wait, false
+		}
+	}
+	recent := t.users[userID]
+	if len(recent) >= t.perUser {
+		return recent[0].Add(manualRefreshWindow).Sub(now), false
+	}
+
+	if feedID != "" {
+		t.feeds[feedID] = now
+	}
+	t.users[userID] = append(recent, now)
+	return 0, true
+}
+
+// prune drops entries that no longer limit anything so the maps stay small.
+func (t *refreshThrottle) prune(now time.Time) {
+	for id, last := range t.feeds {
+		if !now.Before(last.Add(t.feedInterval)) {
+			delete(t.feeds, id)
+		}
+	}
+	for id, times := range t.users {
+		i := 0
+		for i < len(times) && !now.Before(times[i].Add(manualRefreshWindow)) {
+			i++
+		}
+		if i == len(times) {
+			delete(t.users, id)
+		} else {
+			t.users[id] = times[i:]
+		}
+	}
+}
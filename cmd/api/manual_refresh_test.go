@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRefreshThrottle(t *testing.T) {
+	start := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		user     string
+		feed     string
+		at       time.Duration // after start
+		wantOK   bool
+		wantWait time.Duration
+	}{
+		{"first refresh", "u1", "f1", 0, true, 0},
+		{"same feed too soon", "u1", "f1", 30 * time.Second, false, 30 * time.Second},
+		{"other feed", "u1", "f2", 30 * time.Second, true, 0},
+		{"same feed after interval", "u1", "f1", time.Minute, true, 0},
+		{"user over hourly limit", "u1", "f3", 2 * time.Minute, false, 58 * time.Minute},
+		{"other user unaffected", "u2", "f3", 2 * time.Minute, true, 0},
+		{"window slides", "u1", "f3", time.Hour, true, 0},
+		{"empty feed id only limited per user", "u3", "", 0, true, 0},
+		{"empty feed id again", "u3", "", time.Second, true, 0},
+	}
+	th := newRefreshThrottle(time.Minute, 3)
+	for _, tt := range tests {
+		wait, ok := th.allow(tt.user, tt.feed, start.Add(tt.at))
+		if ok != tt.wantOK || wait != tt.wantWait {
+			t.Errorf("%s: allow = (%s, %v), want (%s, %v)", tt.name, wait, ok, tt.wantWait, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"leetcode-rss/internal/api"
+	"leetcode-rss/internal/leetcode"
+	"leetcode-rss/internal/metrics"
+	"leetcode-rss/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// appMetrics are the Prometheus metrics served at /metrics. It receives
+// the events of the feed handlers, the LeetCode client and the store.
+type appMetrics struct {
+	registry *metrics.Registry
+
+	httpRequests     *metrics.Counter
+	httpDuration     *metrics.Histogram
+	feedCache        *metrics.Counter
+	feedBuilds       *metrics.Counter
+	upstreamDuration *metrics.Histogram
+	upstreamErrors   *metrics.Counter
+	storeDuration    *metrics.Histogram
+	storeErrors      *metrics.Counter
+}
+
+func newAppMetrics() *appMetrics {
+	r := metrics.NewRegistry()
+	m := &appMetrics{
+		registry: r,
+		httpRequests: r.Counter("leetcode_rss_http_requests_total",
+			"HTTP requests by method, route and status code.", "method", "route", "status"),
+		httpDuration: r.Histogram("leetcode_rss_http_request_duration_seconds",
+			"HTTP request latency by method and route.", metrics.DefaultBuckets, "method", "route"),
+		feedCache: r.Counter("leetcode_rss_feed_cache_requests_total",
+			"Feed cache lookups by handler (legacy or feed) and result (hit, miss or stale).", "handler", "result"),
+		feedBuilds: r.Counter("leetcode_rss_feed_build_requests_total",
+			"Feed build requests by handler and whether they ran the build or shared one in flight.", "handler", "result"),
+		upstreamDuration: r.Histogram("leetcode_rss_upstream_request_duration_seconds",
+			"LeetCode GraphQL request latency, retries counted separately.", metrics.DefaultBuckets),
+		upstreamErrors: r.Counter("leetcode_rss_upstream_errors_total",
+			"LeetCode GraphQL request errors by type, retries included.", "type"),
+		storeDuration: r.Histogram("leetcode_rss_store_query_duration_seconds",
+			"Database call latency by store method.", metrics.DefaultBuckets, "method"),
+		storeErrors: r.Counter("leetcode_rss_store_errors_total",
+			"Failed database calls by store method, not found results excluded.", "method"),
+	}
+	r.GaugeFunc("go_goroutines", "Number of goroutines that currently exist.",
+		func(_ context.Context, set func(float64, ...string)) error {
+			set(float64(runtime.NumGoroutine()))
+			return nil
+		})
+	return m
+}
+
+// countStore adds gauges of the users and feeds in s, counted on every scrape.
+func (m *appMetrics) countStore(s store.Store) {
+	m.registry.GaugeFunc("leetcode_rss_users", "Registered users.",
+		func(ctx context.Context, set func(float64, ...string)) error {
+			stats, err := s.CountStats(ctx)
+			if err != nil {
+				return err
+			}
+			set(float64(stats.Users))
+			return nil
+		})
+	m.registry.GaugeFunc("leetcode_rss_feeds", "Feeds by state (enabled or disabled).",
+		func(ctx context.Context, set func(float64, ...string)) error {
+			stats, err := s.CountStats(ctx)
+			if err != nil {
+				return err
+			}
+			set(float64(stats.EnabledFeeds), "enabled")
+			set(float64(stats.Feeds-stats.EnabledFeeds), "disabled")
+			return nil
+		}, "state")
+}
+
+// countLimiter exposes the upstream limiter counters since process start.
+func (m *appMetrics) countLimiter(l *leetcode.Limiter) {
+	stat := func(v func(leetcode.LimiterStats) float64) func(context.Context, func(float64, ...string)) error {
+		return func(_ context.Context, set func(float64, ...string)) error {
+			set(v(l.Stats()))
+			return nil
+		}
+	}
+	m.registry.CounterFunc("leetcode_rss_upstream_limiter_requests_total",
+		"Requests that passed through the upstream limiter.",
+		stat(func(s leetcode.LimiterStats) float64 { return float64(s.Requests) }))
+	m.registry.CounterFunc("leetcode_rss_upstream_limiter_queued_total",
+		"Requests that waited for the upstream limiter.",
+		stat(func(s leetcode.LimiterStats) float64 { return float64(s.Queued) }))
+	m.registry.CounterFunc("leetcode_rss_upstream_limiter_queue_seconds_total",
+		"Time requests spent waiting for the upstream limiter.",
+		stat(func(s leetcode.LimiterStats) float64 { return s.TotalQueueTime.Seconds() }))
+	m.registry.GaugeFunc("leetcode_rss_upstream_limiter_max_queue_seconds",
+		"Longest wait for the upstream limiter since process start.",
+		stat(func(s leetcode.LimiterStats) float64 { return s.MaxQueueTime.Seconds() }))
+	m.registry.GaugeFunc("leetcode_rss_upstream_in_flight",
+		"LeetCode requests currently in flight.",
+		stat(func(s leetcode.LimiterStats) float64 { return float64(s.InFlight) }))
+}
+
+// feeds returns m as the metrics of the feed handlers, or nil when metrics
+// are disabled.
+func (m *appMetrics) feeds() api.Metrics {
+	if m == nil {
+		return nil
+	}
+	return m
+}
+
+func (m *appMetrics) FeedCache(handler, result string) {
+	m.feedCache.Inc(handler, result)
+}
+
+func (m *appMetrics) FeedBuild(handler string, shared bool) {
+	result := "built"
+	if shared {
+		result = "shared"
+	}
+	m.feedBuilds.Inc(handler, result)
+}
+
+func (m *appMetrics) ObserveRequest(d time.Duration) {
+	m.upstreamDuration.Observe(d.Seconds())
+}
+
+func (m *appMetrics) ObserveError(kind string) {
+	m.upstreamErrors.Inc(kind)
+}
+
+func (m *appMetrics) observeStore(method string, d time.Duration, err error) {
+	m.storeDuration.Observe(d.Seconds(), method)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		m.storeErrors.Inc(method)
+	}
+}
+
+// middleware records the count and latency of every request by route
+// pattern, so feed IDs and secrets never become label values.
+func (m *appMetrics) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := methodLabel(c.Request.Method)
+		m.httpRequests.Inc(method, route, strconv.Itoa(c.Writer.Status()))
+		m.httpDuration.Observe(time.Since(start).Seconds(), method, route)
+	}
+}
+
+// methodLabel returns method when it is a standard HTTP method and "other"
+// otherwise, so clients cannot create a series per made-up method.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "other"
+	}
+}
+
+// metricsHandler serves the metrics in the Prometheus text format. With
+// METRICS_TOKEN set, scrapers must send it as a bearer token.
+func (app *app) metricsHandler(c *gin.Context) {
+	if token := app.cfg().Metrics.Token; token != "" {
+		got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="metrics"`)
+			api.AbortJSONError(c, http.StatusUnauthorized, api.ErrorCodeUnauthorized, "a valid bearer token is required")
+			return
+		}
+	}
+
+	c.Header("Content-Type", metrics.ContentType)
+	c.Status(http.StatusOK)
+	if err := app.metrics.registry.Write(c.Request.Context(), c.Writer); err != nil {
+		log.Printf("warning: incomplete metrics: %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"leetcode-rss/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Component status values reported by /readyz. Only failing components
+// make the instance not ready: the LeetCode upstream is shared by every
+// instance, so taking them all out of rotation while it struggles would
+// stop serving cached feeds too.
+const (
+	checkOK       = "ok"
+	checkDegraded = "degraded"
+	checkFailing  = "failing"
+	checkDisabled = "disabled"
+)
+
+// readyCheckTimeout bounds the database round trips of one readiness check.
+const readyCheckTimeout = 2 * time.Second
+
+// minUpstreamRequests is how many requests within READY_UPSTREAM_WINDOW it
+// takes before the upstream success rate is judged.
+const minUpstreamRequests = 10
+
+// livezHandler reports that the process is up and serving requests.
+func (app *app) livezHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// readyzHandler reports whether this instance should receive traffic, with
+// the result of each component check.
+func (app *app) readyzHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readyCheckTimeout)
+	defer cancel()
+
+	checks := gin.H{
+		"store":    app.checkStore(ctx),
+		"clerk":    app.checkClerk(),
+		"upstream": app.checkUpstream(),
+	}
+
+	code, status := http.StatusOK, "ready"
+	for _, check := range checks {
+		if check.(gin.H)["status"] == checkFailing {
+			code, status = http.StatusServiceUnavailable, "not_ready"
+		}
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(code, gin.H{"status": status, "checks": checks})
+}
+
+// checkStore pings the database and compares its migration version with
+// the one this build expects.
+func (app *app) checkStore(ctx context.Context) gin.H {
+	if app.store == nil {
+		return gin.H{"status": checkFailing, "error": fmt.Sprintf("database unavailable: %v", app.storeErr)}
+	}
+
+	start := time.Now()
+	if err := app.store.Ping(ctx); err != nil {
+		return gin.H{"status": checkFailing, "error": fmt.Sprintf("ping database: %v", err)}
+	}
+	latency := time.Since(start)
+
+	version, err := app.store.MigrationVersion(ctx)
+	if err != nil {
+		return gin.H{"status": checkFailing, "error": err.Error(), "latency_ms": latency.Milliseconds()}
+	}
+	check := gin.H{
+		"status":                     checkOK,
+		"latency_ms":                 latency.Milliseconds(),
+		"migration_version":          version,
+		"expected_migration_version": store.SchemaVersion,
+	}
+	if version < store.SchemaVersion {
+		check["status"] = checkFailing
+		check["error"] = "pending migrations; run make migrate-up"
+	}
+	return check
+}
+
+// checkClerk reports whether authenticated routes are configured.
+func (app *app) checkClerk() gin.H {
+	key := app.cfg().Clerk.SecretKey
+	switch {
+	case key == "":
+		return gin.H{"status": checkDisabled}
+	case !strings.HasPrefix(key, "sk_"):
+		return gin.H{"status": checkFailing, "error": "CLERK_SECRET_KEY is not a Clerk secret key (sk_...)"}
+	case app.store == nil:
+		return gin.H{"status": checkFailing, "error": "authenticated routes need the database"}
+	default:
+		return gin.H{"status": checkOK}
+	}
+}
+
+// checkUpstream reports the share of recent LeetCode requests that
+// succeeded after retries.
+func (app *app) checkUpstream() gin.H {
+	cfg := app.cfg().Readiness
+	rate, requests := app.leetcodeClient.Outcomes.Rate(cfg.UpstreamWindow)
+	check := gin.H{
+		"status":       checkOK,
+		"success_rate": rate,
+		"requests":     requests,
+		"window":       cfg.UpstreamWindow.String(),
+	}
+	if requests >= minUpstreamRequests && rate < cfg.MinUpstreamSuccess {
+		check["status"] = checkDegraded
+	}
+	return check
+}
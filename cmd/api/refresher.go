@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"leetcode-rss/internal/store"
+)
+
+// refreshBatchSize bounds how many due feeds a single scan picks up.
+const refreshBatchSize = 100
+
+// refresher pre-warms feed_cache by rebuilding enabled feeds shortly before
+// their cache entry expires, so readers rarely pay for an upstream fetch.
+// Feeds that keep failing are retried with exponential backoff, kept in
+// feed_cache so it survives restarts and applies before the batch limit.
+// The lead and build timeout follow configuration reloads; the scan
+// interval, concurrency and backoff cap are fixed at startup.
+type refresher struct {
+	app         *app
+	interval    time.Duration
+	concurrency int
+	maxBackoff  time.Duration
+}
+
+func newRefresher(app *app) *refresher {
+	cfg := app.cfg().Refresh
+	interval := cfg.Interval
+	if interval < time.Second {
+		interval = time.Second
+	}
+	return &refresher{
+		app:         app,
+		interval:    interval,
+		concurrency: cfg.Concurrency,
+		maxBackoff:  cfg.MaxBackoff,
+	}
+}
+
+// lead returns how long before expiry a feed is rebuilt under the current
+// configuration.
+func (r *refresher) lead() time.Duration {
+	cfg := r.app.cfg()
+	lead := cfg.Refresh.Lead
+	// refreshing more than half a TTL early would rebuild feeds almost continuously
+	if ttl := cfg.Database.RSSCacheTTL; lead > ttl/2 {
+		lead = ttl / 2
+	}
+	return lead
+}
+
+func (r *refresher) run(ctx context.Context) {
+	log.Printf("background refresh enabled (interval=%s lead=%s concurrency=%d)", r.interval, r.lead(), r.concurrency)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for ctx.Err() == nil {
+		r.scan(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *refresher) scan(ctx context.Context) {
+	now := time.Now()
+	feeds, err := r.app.store.ListFeedsDueForRefresh(ctx, now.Add(r.lead()), now, refreshBatchSize)
+	if err != nil {
+		log.Printf("refresher: failed to list due feeds: %v", err)
+		return
+	}
+
+	sem := make(chan struct{}, r.concurrency)
+	var wg sync.WaitGroup
+	for i := range feeds {
+		feed := &feeds[i]
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			r.refresh(ctx, feed)
+		}()
+	}
+	wg.Wait()
+}
+
+func (r *refresher) refresh(ctx context.Context, feed *store.Feed) {
+	// a refresh under way completes when shutdown starts; run stops
+	// starting new ones
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.app.cfg().Server.HandlerTimeout)
+	defer cancel()
+
+	_, err := r.app.publicHandlers.RefreshFeed(ctx, feed, r.app.feedBaseURL(feed.ID, feed.Secret))
+	if err != nil {
+		backoff := r.postpone(ctx, feed.ID)
+		log.Printf("refresher: feed %s failed, retrying in %s: %v", feed.ID, backoff, err)
+	}
+}
+
+// backoff returns how long to wait after the given number of consecutive
+// failures: the scan interval, doubled per further failure up to maxBackoff.
+func (r *refresher) backoff(failures int) time.Duration {
+	backoff := r.interval
+	for i := 1; i < failures && backoff < r.maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, r.maxBackoff)
+}
+
+// postpone keeps the refresher away from a feed whose build just failed,
+// using the failure count the build recorded. A successful build, from the
+// refresher or a reader, clears both.
+func (r *refresher) postpone(ctx context.Context, feedID string) time.Duration {
+	failures := 1
+	if cache, err := r.app.store.GetFeedCacheStatus(ctx, feedID); err == nil && cache.Failures > 0 {
+		failures = cache.Failures
+	}
+	backoff := r.backoff(failures)
+	if err := r.app.store.DeferFeedRefresh(ctx, feedID, time.Now().Add(backoff)); err != nil {
+		log.Printf("warning: refresher: failed to defer feed %s: %v", feedID, err)
+	}
+	return backoff
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"leetcode-rss/internal/config"
+)
+
+// watchConfig reloads the configuration on SIGHUP and, every
+// CONFIG_WATCH_INTERVAL, when the config file or the static feeds file
+// changed on disk.
+func (app *app) watchConfig(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval := app.cfg().Server.WatchInterval; interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	stamps := fileStamps(app.cfg().Files())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Printf("SIGHUP received, reloading configuration")
+		case <-tick:
+			if maps.Equal(fileStamps(app.cfg().Files()), stamps) {
+				continue
+			}
+			log.Printf("configuration file changed, reloading")
+		}
+		app.reload()
+		stamps = fileStamps(app.cfg().Files())
+	}
+}
+
+// fileStamps identifies the current version of each file by modification
+// time and size, so changes are noticed without reading the files.
+func fileStamps(files []string) map[string]string {
+	stamps := make(map[string]string, len(files))
+	for _, f := range files {
+		if fi, err := os.Stat(f); err == nil {
+			stamps[f] = fmt.Sprintf("%d/%d", fi.ModTime().UnixNano(), fi.Size())
+		} else {
+			stamps[f] = ""
+		}
+	}
+	return stamps
+}
+
+// reload re-reads the configuration and applies the settings that can
+// change while serving. When the new configuration is invalid, the problems
+// are logged and the running configuration is kept.
+func (app *app) reload() {
+	next, err := config.Load(app.configPath)
+	if err != nil {
+		log.Printf("config reload failed, keeping the running configuration: %v", err)
+		return
+	}
+	static, err := staticFeeds(next.StaticFeeds, app.questions != nil)
+	if err != nil {
+		log.Printf("config reload failed, keeping the running configuration: %v", err)
+		return
+	}
+
+	cfg, applied, restart := config.Merge(app.cfg(), next)
+	app.config.Store(cfg)
+	app.leetcodeClient.SetCredentials(cfg.LeetCode.Cookie, cfg.LeetCode.CSRF)
+	app.handlers.Update(app.feedService(cfg), app.handlersOptions(cfg, static))
+	if app.publicHandlers != nil {
+		app.publicHandlers.Reconfigure(publicFeedOptions(cfg))
+	}
+	app.refreshThrottle.setLimits(cfg.Limits.ManualRefreshInterval, cfg.Limits.MaxManualRefreshesPerHour)
+	app.previewThrottle.setLimits(0, cfg.Limits.MaxPreviewsPerHour)
+
+	if len(applied) > 0 {
+		log.Printf("configuration reloaded, changed: %s", strings.Join(applied, ", "))
+	} else {
+		log.Printf("configuration reloaded")
+	}
+	if len(restart) > 0 {
+		log.Printf("warning: changes to %s take effect after a restart", strings.Join(restart, ", "))
+	}
+}
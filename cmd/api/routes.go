@@ -12,16 +12,25 @@ import (
 func (app *app) routes() http.Handler {
 	g := gin.Default()
 	g.Use(corsMiddleware())
+	if app.metrics != nil {
+		g.Use(app.metrics.middleware())
+		g.GET("/metrics", app.withTimeout(app.metricsHandler))
+	}
 
 	health := g.Group("/health")
 	{
 		health.GET("", app.healthHandler)
 	}
+	g.GET("/livez", app.livezHandler)
+	g.GET("/readyz", app.readyzHandler)
 
 	root := g.Group("/")
 	{
 		root.GET("", app.rootHandler)
 		root.GET("/leetcode.xml", app.withTimeout(app.handlers.RSS))
+		root.GET("/leetcode.atom", app.withTimeout(app.handlers.Atom))
+		root.GET("/leetcode.json", app.withTimeout(app.handlers.JSONFeed))
+		root.GET("/static/:name", app.withTimeout(app.handlers.Static))
 	}
 
 	if app.publicHandlers != nil {
@@ -29,19 +38,45 @@ func (app *app) routes() http.Handler {
 		{
 			feeds.GET("/:feedID/:secret", app.withTimeout(app.publicHandlers.PublicFeed))
 		}
+		if app.cfg().WebSub.Enabled {
+			g.POST("/websub", app.withTimeout(app.publicHandlers.WebSubHub))
+		}
+		if app.digests != nil {
+			g.GET("/digest/confirm", app.confirmDigestPage)
+			g.POST("/digest/confirm", app.confirmDigest)
+			g.GET("/digest/unsubscribe", app.unsubscribeDigestPage)
+			g.POST("/digest/unsubscribe", app.unsubscribeDigest)
+		}
 	}
 
-	if app.config.Clerk.SecretKey != "" && app.store != nil {
+	if app.cfg().Clerk.SecretKey != "" && app.store != nil {
 		protected := g.Group("/")
 		protected.Use(api.ClerkAuthMiddleware(app.store))
 		{
 			protected.GET("/me", app.getCurrentUser)
 			protected.GET("/feeds", app.listFeeds)
 			protected.POST("/feeds", app.createFeed)
+			protected.POST("/feeds/preview", app.withTimeout(app.previewFeed))
 			protected.GET("/feeds/:id", app.getFeed)
+			protected.GET("/feeds/:id/status", app.getFeedStatus)
 			protected.PATCH("/feeds/:id", app.updateFeed)
 			protected.POST("/feeds/:id/rotate", app.rotateFeedSecret)
+			protected.POST("/feeds/:id/refresh", app.withTimeout(app.refreshFeed))
 			protected.DELETE("/feeds/:id", app.deleteFeed)
+
+			if app.cfg().Webhooks.Enabled {
+				protected.GET("/feeds/:id/webhooks", app.listWebhooks)
+				protected.POST("/feeds/:id/webhooks", app.createWebhook)
+				protected.PATCH("/feeds/:id/webhooks/:webhookID", app.updateWebhook)
+				protected.DELETE("/feeds/:id/webhooks/:webhookID", app.deleteWebhook)
+				protected.GET("/feeds/:id/webhooks/:webhookID/deliveries", app.listWebhookDeliveries)
+			}
+
+			if app.digests != nil {
+				protected.GET("/feeds/:id/digest", app.getDigest)
+				protected.PUT("/feeds/:id/digest", app.putDigest)
+				protected.DELETE("/feeds/:id/digest", app.deleteDigest)
+			}
 		}
 	}
 
@@ -53,13 +88,12 @@ func (app *app) healthHandler(c *gin.Context) {
 }
 
 func (app *app) rootHandler(c *gin.Context) {
-	c.String(http.StatusOK, "OK. RSS at /leetcode.xml\n")
+	c.String(http.StatusOK, "OK. RSS at /leetcode.xml, Atom at /leetcode.atom, JSON Feed at /leetcode.json\n")
 }
 
 func (app *app) withTimeout(fn gin.HandlerFunc) gin.HandlerFunc {
-	timeout := app.config.Server.HandlerTimeout
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), app.cfg().Server.HandlerTimeout)
 		defer cancel()
 		c.Request = c.Request.WithContext(ctx)
 		fn(c)
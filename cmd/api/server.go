@@ -1,16 +1,69 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 )
 
-func (app *app) serve() error {
+// serve runs the HTTP server until ctx is done, then shuts down gracefully:
+// the listener is closed, and in-flight requests, background loops and
+// pending WebSub and webhook deliveries get SHUTDOWN_TIMEOUT to finish.
+func (app *app) serve(ctx context.Context) error {
 	server := &http.Server{
-		Addr:              fmt.Sprintf(":%d", app.config.Server.Port),
+		Addr:              fmt.Sprintf(":%d", app.cfg().Server.Port),
 		Handler:           app.routes(),
 		ReadHeaderTimeout: 5 * time.Second,
 	}
-	return server.ListenAndServe()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- server.ListenAndServe()
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	timeout := app.cfg().Server.ShutdownTimeout
+	log.Printf("shutting down, waiting up to %s for in-flight work", timeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	shutdownErr := server.Shutdown(shutdownCtx)
+	if err := <-errc; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	if shutdownErr != nil {
+		log.Printf("warning: shutdown deadline reached with requests still in flight")
+		return nil
+	}
+	app.drain(shutdownCtx)
+	return nil
+}
+
+// drain waits for the background loops to return, then for the deliveries
+// they and the last requests started, or until ctx is done.
+func (app *app) drain(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		app.background.Wait()
+		if app.hub != nil {
+			app.hub.Wait()
+		}
+		if app.webhooks != nil {
+			app.webhooks.Wait()
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Printf("warning: background work still running at the shutdown deadline")
+	}
 }
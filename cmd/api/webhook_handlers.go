@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"leetcode-rss/internal/api"
+	"leetcode-rss/internal/store"
+	"leetcode-rss/internal/webhook"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 100
+)
+
+// GET /feeds/:id/webhooks
+func (app *app) listWebhooks(c *gin.Context) {
+	feed, ok := app.ownedFeed(c)
+	if !ok {
+		return
+	}
+
+	hooks, err := app.store.ListWebhooksByFeedID(c.Request.Context(), feed.ID)
+	if err != nil {
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to list webhooks")
+		return
+	}
+
+	result := make([]gin.H, 0, len(hooks))
+	for i := range hooks {
+		result = append(result, webhookJSON(&hooks[i]))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// POST /feeds/:id/webhooks
+func (app *app) createWebhook(c *gin.Context) {
+	feed, ok := app.ownedFeed(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		URL           string `json:"url"`
+		PayloadFormat string `json:"payload_format"`
+		Enabled       *bool  `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.AbortJSONErrorWithDetails(c, http.StatusBadRequest, api.ErrorCodeValidation, "invalid request body", err.Error())
+		return
+	}
+
+	ctx := c.Request.Context()
+	hooks, err := app.store.ListWebhooksByFeedID(ctx, feed.ID)
+	if err != nil {
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to list webhooks")
+		return
+	}
+	if len(hooks) >= app.cfg().Webhooks.MaxPerFeed {
+		api.AbortJSONError(c, http.StatusForbidden, api.ErrorCodeQuota, "Webhook limit reached")
+		return
+	}
+
+	hook := &store.Webhook{
+		ID:            uuid.NewString(),
+		FeedID:        feed.ID,
+		URL:           strings.TrimSpace(req.URL),
+		PayloadFormat: webhook.FormatJSON,
+		Enabled:       true,
+	}
+	if err := api.ValidateCallbackURL(hook.URL); err != nil {
+		api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, "invalid url: "+err.Error())
+		return
+	}
+	if req.PayloadFormat != "" {
+		hook.PayloadFormat = strings.ToLower(strings.TrimSpace(req.PayloadFormat))
+		if !isPayloadFormat(hook.PayloadFormat) {
+			api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, "payload_format must be one of json, slack or discord")
+			return
+		}
+	}
+	if req.Enabled != nil {
+		hook.Enabled = *req.Enabled
+	}
+
+	hook.Secret, err = generateSecret()
+	if err != nil {
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to generate secret")
+		return
+	}
+	now := time.Now()
+	hook.CreatedAt = now
+	hook.UpdatedAt = now
+
+	if hook.Enabled {
+		app.rebaselineFeedItems(ctx, feed.ID, hooks)
+	}
+	if err := app.store.CreateWebhook(ctx, hook); err != nil {
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to create webhook")
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhookJSON(hook))
+}
+
+// PATCH /feeds/:id/webhooks/:webhookID
+func (app *app) updateWebhook(c *gin.Context) {
+	feed, hook, ok := app.ownedWebhook(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		URL           *string `json:"url"`
+		PayloadFormat *string `json:"payload_format"`
+		Enabled       *bool   `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.AbortJSONErrorWithDetails(c, http.StatusBadRequest, api.ErrorCodeValidation, "invalid request body", err.Error())
+		return
+	}
+
+	if req.URL != nil {
+		url := strings.TrimSpace(*req.URL)
+		if err := api.ValidateCallbackURL(url); err != nil {
+			api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, "invalid url: "+err.Error())
+			return
+		}
+		hook.URL = url
+	}
+	if req.PayloadFormat != nil {
+		format := strings.ToLower(strings.TrimSpace(*req.PayloadFormat))
+		if !isPayloadFormat(format) {
+			api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, "payload_format must be one of json, slack or discord")
+			return
+		}
+		hook.PayloadFormat = format
+	}
+
+	ctx := c.Request.Context()
+	if req.Enabled != nil && *req.Enabled && !hook.Enabled {
+		hooks, err := app.store.ListWebhooksByFeedID(ctx, feed.ID)
+		if err != nil {
+			api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to list webhooks")
+			return
+		}
+		app.rebaselineFeedItems(ctx, feed.ID, hooks)
+	}
+	if req.Enabled != nil {
+		hook.Enabled = *req.Enabled
+	}
+	hook.UpdatedAt = time.Now()
+
+	if err := app.store.UpdateWebhook(ctx, hook); err != nil {
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to update webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, webhookJSON(hook))
+}
+
+// DELETE /feeds/:id/webhooks/:webhookID
+func (app *app) deleteWebhook(c *gin.Context) {
+	_, hook, ok := app.ownedWebhook(c)
+	if !ok {
+		return
+	}
+
+	if err := app.store.DeleteWebhook(c.Request.Context(), hook.ID); err != nil {
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to delete webhook")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GET /feeds/:id/webhooks/:webhookID/deliveries
+func (app *app) listWebhookDeliveries(c *gin.Context) {
+	_, hook, ok := app.ownedWebhook(c)
+	if !ok {
+		return
+	}
+
+	limit := defaultDeliveryLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			api.AbortJSONError(c, http.StatusBadRequest, api.ErrorCodeValidation, "limit must be an integer")
+			return
+		}
+		limit = clampInt(n, 1, maxDeliveryLimit)
+	}
+
+	deliveries, err := app.store.ListWebhookDeliveries(c.Request.Context(), hook.ID, limit)
+	if err != nil {
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to list webhook deliveries")
+		return
+	}
+
+	result := make([]gin.H, 0, len(deliveries))
+	for _, d := range deliveries {
+		var status *int
+		if d.ResponseStatus != 0 {
+			status = &d.ResponseStatus
+		}
+		var errMsg *string
+		if d.Error != "" {
+			errMsg = &d.Error
+		}
+		result = append(result, gin.H{
+			"id":              d.ID,
+			"item_guid":       d.ItemGUID,
+			"success":         d.Success,
+			"attempts":        d.Attempts,
+			"response_status": status,
+			"error":           errMsg,
+			"duration_ms":     d.Duration.Milliseconds(),
+			"created_at":      d.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ownedWebhook loads the webhook named by :webhookID on the feed named by
+// :id, checking that the current user owns the feed. On failure it aborts
+// the request and returns false.
+func (app *app) ownedWebhook(c *gin.Context) (*store.Feed, *store.Webhook, bool) {
+	feed, ok := app.ownedFeed(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	hook, err := app.store.GetWebhookByID(c.Request.Context(), c.Param("webhookID"))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			api.AbortJSONError(c, http.StatusNotFound, api.ErrorCodeNotFound, "webhook not found")
+			return nil, nil, false
+		}
+		api.AbortJSONError(c, http.StatusInternalServerError, api.ErrorCodeInternal, "failed to fetch webhook")
+		return nil, nil, false
+	}
+	if hook.FeedID != feed.ID {
+		api.AbortJSONError(c, http.StatusNotFound, api.ErrorCodeNotFound, "webhook not found")
+		return nil, nil, false
+	}
+	return feed, hook, true
+}
+
+// rebaselineFeedItems forgets the items a feed has produced when none of
+// its existing webhooks is enabled. Items are not tracked while a feed has
+// no enabled webhook, so without this the first webhook would be sent
+// everything published since tracking stopped.
+func (app *app) rebaselineFeedItems(ctx context.Context, feedID string, hooks []store.Webhook) {
+	for _, h := range hooks {
+		if h.Enabled {
+			return
+		}
+	}
+	_ = app.store.ResetFeedItems(ctx, feedID)
+}
+
+func webhookJSON(hook *store.Webhook) gin.H {
+	return gin.H{
+		"id":             hook.ID,
+		"feed_id":        hook.FeedID,
+		"url":            hook.URL,
+		"secret":         hook.Secret,
+		"payload_format": hook.PayloadFormat,
+		"enabled":        hook.Enabled,
+		"created_at":     hook.CreatedAt.Format(time.RFC3339),
+		"updated_at":     hook.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func isPayloadFormat(format string) bool {
+	for _, f := range webhook.Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
@@ -1,33 +1,71 @@
 package api
 
 import (
+	"container/list"
 	"sync"
 	"time"
 )
 
+// Cache is an in-memory LRU of rendered feeds keyed by feed and format.
+// Entries expire after ttl; once maxEntries is reached, adding an entry
+// evicts the least recently used one.
 type Cache struct {
-	mu  sync.Mutex
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	order      *list.List // front is most recently used
+	entries    map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key string
 	at  time.Time
 	val []byte
-	ttl time.Duration
 }
 
-func NewCache(ttl time.Duration) *Cache {
-	return &Cache{ttl: ttl}
+func NewCache(ttl time.Duration, maxEntries int) *Cache {
+	if maxEntries < 1 {
+		maxEntries = 1
+	}
+	return &Cache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
 }
 
-func (c *Cache) Get() ([]byte, bool) {
+func (c *Cache) Get(key string) ([]byte, bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	if c.val == nil || time.Since(c.at) > c.ttl {
+	el, ok := c.entries[key]
+	if !ok {
 		return nil, false
 	}
-	return c.val, true
+	e := el.Value.(*cacheEntry)
+	if time.Since(e.at) > c.ttl {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return e.val, true
 }
 
-func (c *Cache) Set(b []byte) {
+func (c *Cache) Set(key string, b []byte) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.val = b
-	c.at = time.Now()
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*cacheEntry)
+		e.val = b
+		e.at = time.Now()
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, at: time.Now(), val: b})
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
 }
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewCache(time.Minute, 3)
+	for _, k := range []string{"a", "b", "c"} {
+		c.Set(k, []byte(k))
+	}
+	// a becomes the most recently used, leaving b the oldest
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("a missing before the cache was full")
+	}
+	c.Set("d", []byte("d"))
+	if _, ok := c.Get("b"); ok {
+		t.Error("b was kept, want it evicted as least recently used")
+	}
+
+	// updating c refreshes it too, so a is evicted next
+	c.Set("c", []byte("c2"))
+	c.Set("e", []byte("e"))
+	if _, ok := c.Get("a"); ok {
+		t.Error("a was kept, want it evicted as least recently used")
+	}
+	for _, k := range []string{"c", "d", "e"} {
+		if _, ok := c.Get(k); !ok {
+			t.Errorf("%s was evicted", k)
+		}
+	}
+	if b, _ := c.Get("c"); string(b) != "c2" {
+		t.Errorf("c = %q, want the updated value", b)
+	}
+}
+
+func TestCacheSizeBound(t *testing.T) {
+	for _, size := range []int{0, 1, 5} {
+		c := NewCache(time.Minute, size)
+		for i := range 20 {
+			c.Set(fmt.Sprint(i), nil)
+		}
+		want := max(size, 1)
+		if n := c.order.Len(); n != want || len(c.entries) != want {
+			t.Errorf("NewCache(_, %d) holds %d list and %d map entries, want %d", size, n, len(c.entries), want)
+		}
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	c := NewCache(time.Minute, 2)
+	c.Set("a", []byte("a"))
+	c.entries["a"].Value.(*cacheEntry).at = time.Now().Add(-2 * time.Minute)
+	if _, ok := c.Get("a"); ok {
+		t.Error("expired entry was served")
+	}
+	if len(c.entries) != 0 || c.order.Len() != 0 {
+		t.Error("expired entry was not dropped")
+	}
+}
@@ -1,6 +1,14 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"leetcode-rss/internal/leetcode"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -65,3 +73,37 @@ func AbortJSONErrorWithDetails(c *gin.Context, status int, code, message string,
 	JSONErrorWithDetails(c, status, code, message, details)
 	c.Abort()
 }
+
+// AbortUpstreamError maps a feed build failure to a response without
+// leaking the raw upstream error to the client.
+func AbortUpstreamError(c *gin.Context, err error) {
+	status, code, message := classifyUpstreamError(err)
+	var rateErr *leetcode.RateLimitError
+	if errors.As(err, &rateErr) && rateErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(rateErr.RetryAfter.Seconds())))
+	}
+	AbortJSONError(c, status, code, message)
+}
+
+func classifyUpstreamError(err error) (int, string, string) {
+	var (
+		rateErr     *leetcode.RateLimitError
+		notFoundErr *leetcode.UserNotFoundError
+		authErr     *leetcode.AuthRequiredError
+		gqlErr      *leetcode.GraphQLError
+	)
+	switch {
+	case errors.As(err, &rateErr):
+		return http.StatusTooManyRequests, ErrorCodeRateLimited, "LeetCode is rate limiting requests, try again later"
+	case errors.As(err, &notFoundErr):
+		return http.StatusNotFound, ErrorCodeNotFound, fmt.Sprintf("LeetCode user %q not found", notFoundErr.Username)
+	case errors.As(err, &authErr):
+		return http.StatusBadGateway, ErrorCodeUpstream, "LeetCode rejected the request as unauthenticated"
+	case errors.As(err, &gqlErr):
+		return http.StatusBadGateway, ErrorCodeUpstream, "LeetCode returned an unexpected GraphQL error"
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, ErrorCodeUpstream, "timed out waiting for LeetCode"
+	default:
+		return http.StatusBadGateway, ErrorCodeUpstream, "failed to fetch articles from LeetCode"
+	}
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"leetcode-rss/internal/leetcode"
+	"leetcode-rss/internal/store"
+)
+
+const (
+	maxFilterPatternLength = 200
+	maxFilterQuestionSlugs = 100
+)
+
+var (
+	questionSlugRe = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	difficulties   = []string{"Easy", "Medium", "Hard"}
+)
+
+// NormalizeFeedFilter trims and canonicalizes f in place (deduplicated slugs,
+// difficulties spelled as LeetCode does) and reports every invalid field.
+// Difficulties are rejected unless problem metadata is enabled, since they
+// could never match otherwise.
+func NormalizeFeedFilter(f *store.FeedFilter, questions bool) []ErrorDetails {
+	var problems []ErrorDetails
+
+	f.IncludeTitle = strings.TrimSpace(f.IncludeTitle)
+	f.ExcludeTitle = strings.TrimSpace(f.ExcludeTitle)
+	for _, p := range []struct {
+		field, pattern string
+	}{
+		{"include_title", f.IncludeTitle},
+		{"exclude_title", f.ExcludeTitle},
+	} {
+		if len(p.pattern) > maxFilterPatternLength {
+			problems = append(problems, ErrorDetails{Field: p.field, Message: fmt.Sprintf("must be at most %d characters", maxFilterPatternLength)})
+			continue
+		}
+		if _, err := regexp.Compile(p.pattern); err != nil {
+			problems = append(problems, ErrorDetails{Field: p.field, Message: "invalid regular expression: " + err.Error()})
+		}
+	}
+
+	slugs := make([]string, 0, len(f.QuestionSlugs))
+	seen := make(map[string]struct{}, len(f.QuestionSlugs))
+	for _, slug := range f.QuestionSlugs {
+		slug = strings.ToLower(strings.TrimSpace(slug))
+		if slug == "" {
+			continue
+		}
+		if !questionSlugRe.MatchString(slug) {
+			problems = append(problems, ErrorDetails{Field: "question_slugs", Message: fmt.Sprintf("invalid question slug %q", slug)})
+			continue
+		}
+		if _, ok := seen[slug]; !ok {
+			seen[slug] = struct{}{}
+			slugs = append(slugs, slug)
+		}
+	}
+	if len(slugs) > maxFilterQuestionSlugs {
+		problems = append(problems, ErrorDetails{Field: "question_slugs", Message: fmt.Sprintf("maximum %d question slugs", maxFilterQuestionSlugs)})
+	}
+	f.QuestionSlugs = slugs
+
+	if f.MinHitCount < 0 {
+		problems = append(problems, ErrorDetails{Field: "min_hit_count", Message: "must not be negative"})
+	}
+
+	if f.PublishedAfter != nil && f.PublishedBefore != nil && !f.PublishedAfter.Before(*f.PublishedBefore) {
+		problems = append(problems, ErrorDetails{Field: "published_before", Message: "must be after published_after"})
+	}
+
+	normalized := make([]string, 0, len(f.Difficulties))
+	for _, d := range f.Difficulties {
+		canonical, ok := canonicalDifficulty(d)
+		if !ok {
+			problems = append(problems, ErrorDetails{Field: "difficulties", Message: fmt.Sprintf("unknown difficulty %q (expected Easy, Medium or Hard)", d)})
+			continue
+		}
+		if !containsString(normalized, canonical) {
+			normalized = append(normalized, canonical)
+		}
+	}
+	f.Difficulties = normalized
+	if len(f.Difficulties) > 0 && !questions {
+		problems = append(problems, ErrorDetails{Field: "difficulties", Message: "problem metadata is disabled on this server (QUESTION_CACHE_TTL=0)"})
+	}
+
+	return problems
+}
+
+func canonicalDifficulty(d string) (string, bool) {
+	for _, known := range difficulties {
+		if strings.EqualFold(strings.TrimSpace(d), known) {
+			return known, true
+		}
+	}
+	return "", false
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// itemFilter is a compiled store.FeedFilter.
+type itemFilter struct {
+	include, exclude *regexp.Regexp
+	slugs            map[string]struct{}
+	minHitCount      int
+	after, before    *time.Time
+	difficulties     []string
+	// noMetadata drops every article from a difficulty filter, as when
+	// problem metadata is disabled none can be checked
+	noMetadata bool
+}
+
+// compileFilter compiles f. questions reports whether problem metadata is
+// enabled.
+func compileFilter(f *store.FeedFilter, questions bool) (*itemFilter, error) {
+	if f.IsZero() {
+		return nil, nil
+	}
+	out := &itemFilter{
+		minHitCount:  f.MinHitCount,
+		after:        f.PublishedAfter,
+		before:       f.PublishedBefore,
+		difficulties: f.Difficulties,
+		noMetadata:   !questions,
+	}
+	var err error
+	if f.IncludeTitle != "" {
+		if out.include, err = regexp.Compile(f.IncludeTitle); err != nil {
+			return nil, fmt.Errorf("compile include_title: %w", err)
+		}
+	}
+	if f.ExcludeTitle != "" {
+		if out.exclude, err = regexp.Compile(f.ExcludeTitle); err != nil {
+			return nil, fmt.Errorf("compile exclude_title: %w", err)
+		}
+	}
+	if len(f.QuestionSlugs) > 0 {
+		out.slugs = make(map[string]struct{}, len(f.QuestionSlugs))
+		for _, slug := range f.QuestionSlugs {
+			out.slugs[slug] = struct{}{}
+		}
+	}
+	return out, nil
+}
+
+// match reports whether the article passes the filter. q may be nil when the
+// problem's metadata could not be fetched, in which case difficulty is not
+// checked; with metadata disabled altogether, difficulty filters match
+// nothing. Articles with an unparseable publication time are kept by date
+// rules.
+func (f *itemFilter) match(a leetcode.Article, q *leetcode.Question) bool {
+	if f == nil {
+		return true
+	}
+	if f.include != nil && !f.include.MatchString(a.Title) {
+		return false
+	}
+	if f.exclude != nil && f.exclude.MatchString(a.Title) {
+		return false
+	}
+	if f.slugs != nil {
+		if _, ok := f.slugs[a.QuestionSlug]; !ok {
+			return false
+		}
+	}
+	if a.HitCount < f.minHitCount {
+		return false
+	}
+	if t, ok := a.CreatedTime(); ok {
+		if f.after != nil && t.Before(*f.after) {
+			return false
+		}
+		if f.before != nil && !t.Before(*f.before) {
+			return false
+		}
+	}
+	if len(f.difficulties) > 0 && f.noMetadata {
+		return false
+	}
+	if len(f.difficulties) > 0 && q != nil && q.Difficulty != "" && !containsString(f.difficulties, q.Difficulty) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"leetcode-rss/internal/leetcode"
+	"leetcode-rss/internal/store"
+)
+
+func TestItemFilterMatch(t *testing.T) {
+	published := time.Date(2026, 1, 7, 12, 0, 0, 0, time.UTC)
+	before := published.Add(-time.Hour)
+	after := published.Add(time.Hour)
+	article := leetcode.Article{
+		Title:        "Two pointers, O(n)",
+		QuestionSlug: "two-sum",
+		HitCount:     40,
+		CreatedAt:    published.Format(time.RFC3339Nano),
+	}
+	medium := &leetcode.Question{Difficulty: "Medium"}
+
+	tests := []struct {
+		name      string
+		filter    store.FeedFilter
+		questions bool
+		article   leetcode.Article
+		q         *leetcode.Question
+		want      bool
+	}{
+		{"empty filter", store.FeedFilter{}, true, article, medium, true},
+		{"include title", store.FeedFilter{IncludeTitle: "(?i)pointers"}, true, article, nil, true},
+		{"include title misses", store.FeedFilter{IncludeTitle: "dp"}, true, article, nil, false},
+		{"exclude title", store.FeedFilter{ExcludeTitle: `O\(n\)`}, true, article, nil, false},
+		{"question slug", store.FeedFilter{QuestionSlugs: []string{"3sum", "two-sum"}}, true, article, nil, true},
+		{"other question slug", store.FeedFilter{QuestionSlugs: []string{"3sum"}}, true, article, nil, false},
+		{"hit count reached", store.FeedFilter{MinHitCount: 40}, true, article, nil, true},
+		{"hit count missed", store.FeedFilter{MinHitCount: 41}, true, article, nil, false},
+		{"published after", store.FeedFilter{PublishedAfter: &before}, true, article, nil, true},
+		{"published too early", store.FeedFilter{PublishedAfter: &after}, true, article, nil, false},
+		{"published before is exclusive", store.FeedFilter{PublishedBefore: &published}, true, article, nil, false},
+		{"unparseable date kept", store.FeedFilter{PublishedAfter: &after}, true, leetcode.Article{CreatedAt: "yesterday"}, nil, true},
+		{"difficulty matches", store.FeedFilter{Difficulties: []string{"Easy", "Medium"}}, true, article, medium, true},
+		{"difficulty misses", store.FeedFilter{Difficulties: []string{"Hard"}}, true, article, medium, false},
+		{"difficulty with nil question passes", store.FeedFilter{Difficulties: []string{"Hard"}}, true, article, nil, true},
+		{"difficulty unknown to question passes", store.FeedFilter{Difficulties: []string{"Hard"}}, true, article, &leetcode.Question{}, true},
+		{"difficulty without metadata matches nothing", store.FeedFilter{Difficulties: []string{"Medium"}}, false, article, medium, false},
+		{"difficulty without metadata and nil question", store.FeedFilter{Difficulties: []string{"Medium"}}, false, article, nil, false},
+		{"all rules pass", store.FeedFilter{IncludeTitle: "Two", QuestionSlugs: []string{"two-sum"}, MinHitCount: 10, PublishedAfter: &before, PublishedBefore: &after, Difficulties: []string{"Medium"}}, true, article, medium, true},
+	}
+	for _, tt := range tests {
+		f, err := compileFilter(&tt.filter, tt.questions)
+		if err != nil {
+			t.Fatalf("%s: compileFilter: %v", tt.name, err)
+		}
+		if got := f.match(tt.article, tt.q); got != tt.want {
+			t.Errorf("%s: match = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeFeedFilter(t *testing.T) {
+	after := time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		filter     store.FeedFilter
+		questions  bool
+		wantFields []string
+	}{
+		{"valid", store.FeedFilter{IncludeTitle: " dp ", QuestionSlugs: []string{"Two-Sum", "two-sum"}, Difficulties: []string{"easy"}}, true, nil},
+		{"bad pattern", store.FeedFilter{ExcludeTitle: "("}, true, []string{"exclude_title"}},
+		{"bad slug", store.FeedFilter{QuestionSlugs: []string{"two sum"}}, true, []string{"question_slugs"}},
+		{"negative hit count", store.FeedFilter{MinHitCount: -1}, true, []string{"min_hit_count"}},
+		{"empty date range", store.FeedFilter{PublishedAfter: &after, PublishedBefore: &after}, true, []string{"published_before"}},
+		{"unknown difficulty", store.FeedFilter{Difficulties: []string{"Insane"}}, true, []string{"difficulties"}},
+		{"difficulty without metadata", store.FeedFilter{Difficulties: []string{"Hard"}}, false, []string{"difficulties"}},
+	}
+	for _, tt := range tests {
+		problems := NormalizeFeedFilter(&tt.filter, tt.questions)
+		var fields []string
+		for _, p := range problems {
+			fields = append(fields, p.Field)
+		}
+		if len(fields) != len(tt.wantFields) {
+			t.Errorf("%s: problems on %v, want %v", tt.name, fields, tt.wantFields)
+			continue
+		}
+		for i := range fields {
+			if fields[i] != tt.wantFields[i] {
+				t.Errorf("%s: problems on %v, want %v", tt.name, fields, tt.wantFields)
+				break
+			}
+		}
+	}
+
+	f := store.FeedFilter{IncludeTitle: " dp ", QuestionSlugs: []string{"Two-Sum", "two-sum"}, Difficulties: []string{"easy", "EASY"}}
+	NormalizeFeedFilter(&f, true)
+	if f.IncludeTitle != "dp" || len(f.QuestionSlugs) != 1 || f.QuestionSlugs[0] != "two-sum" || len(f.Difficulties) != 1 || f.Difficulties[0] != "Easy" {
+		t.Errorf("normalized filter = %+v", f)
+	}
+}
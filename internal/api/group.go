@@ -0,0 +1,151 @@
+package api
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"leetcode-rss/internal/leetcode"
+	"leetcode-rss/internal/rss"
+)
+
+// GroupBy values select digest mode in UGCFeedService.
+const (
+	GroupByDay  = "day"
+	GroupByWeek = "week"
+)
+
+// Completed periods listed by a grouped feed.
+const (
+	groupedDays  = 14
+	groupedWeeks = 8
+)
+
+// periodStart returns the start of the day, or the week starting Monday,
+// that contains t in loc.
+func periodStart(t time.Time, groupBy string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	if groupBy == GroupByWeek {
+		start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	}
+	return start
+}
+
+// periodEnd returns the start of the period following the one at start.
+// AddDate keeps boundaries at local midnight across DST changes.
+func periodEnd(start time.Time, groupBy string) time.Time {
+	if groupBy == GroupByWeek {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// groupWindowStart returns the start of the oldest period a grouped feed
+// built at now lists.
+func groupWindowStart(now time.Time, groupBy string, loc *time.Location) time.Time {
+	current := periodStart(now, groupBy, loc)
+	if groupBy == GroupByWeek {
+		return current.AddDate(0, 0, -7*groupedWeeks)
+	}
+	return current.AddDate(0, 0, -groupedDays)
+}
+
+// articlePeriod is the articles published in one period.
+type articlePeriod struct {
+	start, end time.Time
+	articles   []timedArticle
+}
+
+// groupItems renders one item per completed period, newest first. The
+// period in progress at now is left out so an item never changes once
+// published, and articles without a publication time are dropped.
+func groupItems(timed []timedArticle, usernames []string, questions map[string]*leetcode.Question, groupBy string, loc *time.Location, now time.Time) []rss.Item {
+	windowStart := groupWindowStart(now, groupBy, loc)
+	byStart := make(map[int64]*articlePeriod)
+	for _, a := range timed {
+		if !a.OK || a.CreatedAt.Before(windowStart) {
+			continue
+		}
+		start := periodStart(a.CreatedAt, groupBy, loc)
+		end := periodEnd(start, groupBy)
+		if end.After(now) {
+			continue
+		}
+		p, ok := byStart[start.Unix()]
+		if !ok {
+			p = &articlePeriod{start: start, end: end}
+			byStart[start.Unix()] = p
+		}
+		p.articles = append(p.articles, a)
+	}
+
+	periods := make([]*articlePeriod, 0, len(byStart))
+	for _, p := range byStart {
+		periods = append(periods, p)
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].start.After(periods[j].start) })
+
+	items := make([]rss.Item, 0, len(periods))
+	for _, p := range periods {
+		items = append(items, periodItem(p, usernames, questions, groupBy, loc))
+	}
+	return items
+}
+
+// periodItem renders a period's articles grouped by username, in feed
+// order. Its GUID depends only on the period and time zone.
+func periodItem(p *articlePeriod, usernames []string, questions map[string]*leetcode.Question, groupBy string, loc *time.Location) rss.Item {
+	byUser := make(map[string][]timedArticle)
+	for _, a := range p.articles {
+		byUser[a.Username] = append(byUser[a.Username], a)
+	}
+	var authors []string
+	for _, u := range usernames {
+		if len(byUser[u]) > 0 {
+			authors = append(authors, u)
+		}
+	}
+
+	noun := "solution articles"
+	if len(p.articles) == 1 {
+		noun = "solution article"
+	}
+	title := fmt.Sprintf("%d %s on %s", len(p.articles), noun, p.start.Format("Mon, Jan 2, 2006"))
+	if groupBy == GroupByWeek {
+		title = fmt.Sprintf("%d %s in the week of %s", len(p.articles), noun, p.start.Format("Jan 2, 2006"))
+	}
+
+	var summary []string
+	var content strings.Builder
+	for _, u := range authors {
+		titles := make([]string, 0, len(byUser[u]))
+		fmt.Fprintf(&content, "<h3><a href=\"%s\">%s</a></h3>\n<ul>\n", html.EscapeString(profileLink(u)), html.EscapeString(u))
+		for _, a := range byUser[u] {
+			titles = append(titles, a.Article.Title)
+			fmt.Fprintf(&content, "<li><a href=\"%s\">%s</a>", html.EscapeString(articleLink(a.Article)), html.EscapeString(a.Article.Title))
+			if q := questions[a.Article.QuestionSlug]; q != nil && q.Difficulty != "" {
+				fmt.Fprintf(&content, " <small>(%s)</small>", html.EscapeString(q.Difficulty))
+			}
+			content.WriteString("</li>\n")
+		}
+		content.WriteString("</ul>\n")
+		summary = append(summary, fmt.Sprintf("%s (%d): %s.", u, len(titles), strings.Join(titles, "; ")))
+	}
+
+	item := rss.Item{
+		Title:       title,
+		Link:        profileLink(authors[0]),
+		GUID:        fmt.Sprintf("period:%s:%s:%s", groupBy, p.start.Format(time.DateOnly), loc.String()),
+		PubDate:     p.end.UTC(),
+		Author:      strings.Join(authors, ", "),
+		Summary:     strings.Join(summary, " "),
+		ContentHTML: content.String(),
+	}
+	if len(authors) == 1 {
+		item.AuthorURI = profileLink(authors[0])
+	}
+	return item
+}
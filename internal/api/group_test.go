@@ -0,0 +1,154 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"leetcode-rss/internal/leetcode"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s not available: %v", name, err)
+	}
+	return loc
+}
+
+func TestPeriodBounds(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	tests := []struct {
+		name      string
+		at        time.Time
+		groupBy   string
+		loc       *time.Location
+		wantStart time.Time
+		wantLen   time.Duration
+	}{
+		{"day", time.Date(2026, 1, 7, 12, 0, 0, 0, time.UTC), GroupByDay, time.UTC,
+			time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC), 24 * time.Hour},
+		{"day at midnight", time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC), GroupByDay, time.UTC,
+			time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC), 24 * time.Hour},
+		{"day in the feed's zone", time.Date(2026, 1, 7, 3, 0, 0, 0, time.UTC), GroupByDay, newYork,
+			time.Date(2026, 1, 6, 0, 0, 0, 0, newYork), 24 * time.Hour},
+		{"week from Wednesday", time.Date(2026, 1, 7, 12, 0, 0, 0, time.UTC), GroupByWeek, time.UTC,
+			time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), 7 * 24 * time.Hour},
+		{"week from Monday", time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), GroupByWeek, time.UTC,
+			time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), 7 * 24 * time.Hour},
+		{"week from Sunday", time.Date(2026, 1, 4, 23, 59, 0, 0, time.UTC), GroupByWeek, time.UTC,
+			time.Date(2025, 12, 29, 0, 0, 0, 0, time.UTC), 7 * 24 * time.Hour},
+		{"week in the feed's zone", time.Date(2026, 1, 5, 3, 0, 0, 0, time.UTC), GroupByWeek, newYork,
+			time.Date(2025, 12, 29, 0, 0, 0, 0, newYork), 7 * 24 * time.Hour},
+		{"day DST starts", time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC), GroupByDay, newYork,
+			time.Date(2026, 3, 8, 0, 0, 0, 0, newYork), 23 * time.Hour},
+		{"day DST ends", time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC), GroupByDay, newYork,
+			time.Date(2026, 11, 1, 0, 0, 0, 0, newYork), 25 * time.Hour},
+		{"week DST starts", time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC), GroupByWeek, newYork,
+			time.Date(2026, 3, 2, 0, 0, 0, 0, newYork), 7*24*time.Hour - time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := periodStart(tt.at, tt.groupBy, tt.loc)
+			if !start.Equal(tt.wantStart) {
+				t.Errorf("periodStart = %s, want %s", start, tt.wantStart)
+			}
+			end := periodEnd(start, tt.groupBy)
+			if got := end.Sub(start); got != tt.wantLen {
+				t.Errorf("period lasts %s, want %s", got, tt.wantLen)
+			}
+			if h, m, _ := end.In(tt.loc).Clock(); h != 0 || m != 0 {
+				t.Errorf("period ends at %s, want local midnight", end.In(tt.loc))
+			}
+		})
+	}
+}
+
+func TestGroupItems(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	at := func(username string, t time.Time) timedArticle {
+		return timedArticle{
+			Article:   leetcode.Article{Title: username + " " + t.Format(time.RFC3339)},
+			Username:  username,
+			CreatedAt: t,
+			OK:        true,
+		}
+	}
+	// now is Wednesday, Jan 7, 12:00 UTC (07:00 in New York)
+	now := time.Date(2026, 1, 7, 12, 0, 0, 0, time.UTC)
+	articles := []timedArticle{
+		at("alice", time.Date(2026, 1, 7, 9, 0, 0, 0, time.UTC)),   // today everywhere
+		at("carol", time.Date(2026, 1, 7, 3, 0, 0, 0, time.UTC)),   // Jan 6 in New York
+		at("bob", time.Date(2026, 1, 6, 20, 0, 0, 0, time.UTC)),    // Jan 6
+		at("alice", time.Date(2026, 1, 6, 10, 0, 0, 0, time.UTC)),  // Jan 6
+		at("alice", time.Date(2026, 1, 4, 8, 0, 0, 0, time.UTC)),   // Sunday of the previous week
+		at("alice", time.Date(2025, 12, 20, 8, 0, 0, 0, time.UTC)), // before the day window
+		{Username: "alice", CreatedAt: time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)},
+	}
+	usernames := []string{"alice", "bob", "carol"}
+
+	type wantItem struct {
+		title, guid, author string
+		pubDate             time.Time
+	}
+	tests := []struct {
+		name     string
+		articles []timedArticle
+		groupBy  string
+		loc      *time.Location
+		now      time.Time
+		want     []wantItem
+	}{
+		{
+			name: "days", articles: articles, groupBy: GroupByDay, loc: time.UTC, now: now,
+			want: []wantItem{
+				{"2 solution articles on Tue, Jan 6, 2026", "period:day:2026-01-06:UTC", "alice, bob", time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC)},
+				{"1 solution article on Sun, Jan 4, 2026", "period:day:2026-01-04:UTC", "alice", time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name: "days in the feed's zone", articles: articles, groupBy: GroupByDay, loc: newYork, now: now,
+			want: []wantItem{
+				{"3 solution articles on Tue, Jan 6, 2026", "period:day:2026-01-06:America/New_York", "alice, bob, carol", time.Date(2026, 1, 7, 5, 0, 0, 0, time.UTC)},
+				{"1 solution article on Sun, Jan 4, 2026", "period:day:2026-01-04:America/New_York", "alice", time.Date(2026, 1, 5, 5, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name: "weeks", articles: articles, groupBy: GroupByWeek, loc: time.UTC, now: now,
+			want: []wantItem{
+				{"1 solution article in the week of Dec 29, 2025", "period:week:2025-12-29:UTC", "alice", time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)},
+				{"1 solution article in the week of Dec 15, 2025", "period:week:2025-12-15:UTC", "alice", time.Date(2025, 12, 22, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name: "day ending after DST starts",
+			articles: []timedArticle{
+				at("alice", time.Date(2026, 3, 9, 4, 30, 0, 0, time.UTC)), // Mar 9, 00:30 EDT
+				at("bob", time.Date(2026, 3, 9, 3, 30, 0, 0, time.UTC)),   // Mar 8, 23:30 EDT
+			},
+			groupBy: GroupByDay, loc: newYork, now: time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC),
+			want: []wantItem{
+				{"1 solution article on Sun, Mar 8, 2026", "period:day:2026-03-08:America/New_York", "bob", time.Date(2026, 3, 9, 4, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:     "only the period in progress",
+			articles: articles[:1], groupBy: GroupByDay, loc: time.UTC, now: now,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := groupItems(tt.articles, usernames, nil, tt.groupBy, tt.loc, tt.now)
+			if len(items) != len(tt.want) {
+				t.Fatalf("got %d items, want %d: %+v", len(items), len(tt.want), items)
+			}
+			for i, want := range tt.want {
+				got := items[i]
+				if got.Title != want.title || got.GUID != want.guid || got.Author != want.author || !got.PubDate.Equal(want.pubDate) {
+					t.Errorf("item %d = %q %q by %q at %s, want %q %q by %q at %s", i,
+						got.Title, got.GUID, got.Author, got.PubDate, want.title, want.guid, want.author, want.pubDate)
+				}
+			}
+		})
+	}
+}
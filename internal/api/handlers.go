@@ -8,47 +8,321 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"leetcode-rss/internal/leetcode"
+	"leetcode-rss/internal/rss"
 	"leetcode-rss/internal/store"
+	"leetcode-rss/internal/webhook"
+	"leetcode-rss/internal/websub"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"golang.org/x/sync/singleflight"
 )
 
-type FeedService interface {
-	Build(ctx context.Context, selfURL string) ([]byte, error)
+// defaultBuildTimeout bounds a shared feed build when no timeout is
+// configured. The build outlives the request that started it, so it needs
+// a bound of its own.
+const defaultBuildTimeout = 30 * time.Second
+
+// HandlersOptions configures the legacy feed endpoints.
+type HandlersOptions struct {
+	CacheTTL        time.Duration
+	CacheSize       int  // rendered feeds kept in memory, across formats
+	AdHoc           bool // accept ?u= and ?n= to build feeds for other usernames
+	MaxUsernames    int  // usernames allowed in an ad-hoc feed
+	MaxArticles     int  // upper bound on ?n=
+	DefaultArticles int  // articles per username when ?n= is omitted
+	Static          []StaticFeed
+	Metrics         Metrics       // nil records nothing
+	BuildTimeout    time.Duration // bound on one shared build (defaultBuildTimeout)
 }
 
+// Metrics receives the cache and build-sharing events of the feed handlers.
+// handler is "legacy" for /leetcode.xml and the static feeds, and "feed"
+// for per-feed endpoints.
+type Metrics interface {
+	// FeedCache counts a cache lookup that was a hit, a miss, or served
+	// stale because the rebuild failed.
+	FeedCache(handler, result string)
+	// FeedBuild counts a request for a build that either ran it or shared
+	// one already in flight.
+	FeedBuild(handler string, shared bool)
+}
+
+type noMetrics struct{}
+
+func (noMetrics) FeedCache(string, string) {}
+func (noMetrics) FeedBuild(string, bool)   {}
+
+// StaticFeed is a named feed served at /static/<name>.xml, .atom and .json.
+type StaticFeed struct {
+	Name      string
+	Title     string // replaces the title built from the usernames when set
+	Usernames []string
+	First     int
+	Format    rss.Format // served at /static/<name> without an extension
+	Filter    *store.FeedFilter
+}
+
+// Handlers serves /leetcode.xml and its other formats. Without query
+// parameters they show the configured usernames; with ?u=alice,bob&n=20 an
+// ad-hoc feed is built from the same service with other usernames. Static
+// feeds are built from the same service too and share the cache.
 type Handlers struct {
-	svc   FeedService
-	cache *Cache
+	state atomic.Pointer[handlersState]
+	group singleflight.Group
+}
+
+// handlersState is what Update replaces at once.
+type handlersState struct {
+	svc    UGCFeedService
+	opts   HandlersOptions
+	static map[string]StaticFeed
+	cache  *Cache
+	// generation keeps builds started before an update from being shared
+	// with requests served after it.
+	generation uint64
 }
 
-func NewHandlers(svc FeedService, cache *Cache) *Handlers {
-	return &Handlers{
-		svc:   svc,
-		cache: cache,
+func NewHandlers(svc UGCFeedService, opts HandlersOptions) *Handlers {
+	h := &Handlers{}
+	h.Update(svc, opts)
+	return h
+}
+
+// Update replaces the service and options, for example after a config
+// reload. Feeds cached so far are dropped.
+func (h *Handlers) Update(svc UGCFeedService, opts HandlersOptions) {
+	if opts.Metrics == nil {
+		opts.Metrics = noMetrics{}
+	}
+	if opts.BuildTimeout <= 0 {
+		opts.BuildTimeout = defaultBuildTimeout
+	}
+	st := &handlersState{
+		svc:    svc,
+		opts:   opts,
+		static: make(map[string]StaticFeed, len(opts.Static)),
+		cache:  NewCache(opts.CacheTTL, opts.CacheSize),
 	}
+	for _, f := range opts.Static {
+		st.static[f.Name] = f
+	}
+	if prev := h.state.Load(); prev != nil {
+		st.generation = prev.generation + 1
+	}
+	h.state.Store(st)
 }
 
+// GET /leetcode.xml
 func (h *Handlers) RSS(c *gin.Context) {
-	if b, ok := h.cache.Get(); ok {
-		c.Data(200, "application/rss+xml", b)
+	h.serveFeed(c, rss.FormatRSS)
+}
+
+// GET /leetcode.atom
+func (h *Handlers) Atom(c *gin.Context) {
+	h.serveFeed(c, rss.FormatAtom)
+}
+
+// GET /leetcode.json
+func (h *Handlers) JSONFeed(c *gin.Context) {
+	h.serveFeed(c, rss.FormatJSON)
+}
+
+func (h *Handlers) serveFeed(c *gin.Context, format rss.Format) {
+	st := h.state.Load()
+	svc, query, ok := st.requestedFeed(c)
+	if !ok {
+		return
+	}
+	selfLink := selfURLFromRequest(c)
+	if query != "" {
+		selfLink += "?" + query
+	}
+	h.serve(c, st, svc, query, format, selfLink, "")
+}
+
+// GET /static/:name, with an optional .xml, .atom or .json extension
+func (h *Handlers) Static(c *gin.Context) {
+	st := h.state.Load()
+	param := c.Param("name")
+	name, format, ok := splitFeedExt(param)
+	feed, found := st.static[name]
+	if !ok || !found {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if path.Ext(param) == "" {
+		format = feed.Format
+	}
+
+	svc := st.svc
+	svc.Usernames = feed.Usernames
+	svc.First = feed.First
+	svc.Filter = feed.Filter
+	h.serve(c, st, svc, "static="+name, format, selfURLFromRequest(c), feed.Title)
+}
+
+// serve responds with the feed svc builds in format. key identifies the
+// feed across formats for the cache and for sharing builds; title, when
+// set, replaces the built title. Like PublicFeedHandlers.rebuildFeed, the
+// shared build runs detached from the request that started it, so a client
+// that disconnects does not fail it for the others waiting.
+func (h *Handlers) serve(c *gin.Context, st *handlersState, svc UGCFeedService, key string, format rss.Format, selfLink, title string) {
+	cacheKey := string(format) + "?" + key
+	if b, ok := st.cache.Get(cacheKey); ok {
+		st.opts.Metrics.FeedCache("legacy", "hit")
+		c.Data(200, format.ContentType(), b)
 		return
 	}
+	st.opts.Metrics.FeedCache("legacy", "miss")
 
-	b, err := h.svc.Build(c.Request.Context(), selfURLFromRequest(c))
+	// concurrent requests for the same feed share one build
+	ctx := c.Request.Context()
+	built := false
+	ch := h.group.DoChan(fmt.Sprintf("%d:%s", st.generation, key), func() (any, error) {
+		built = true
+		buildCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), st.opts.BuildTimeout)
+		defer cancel()
+		return svc.BuildFeed(buildCtx)
+	})
+
+	var res singleflight.Result
+	select {
+	case res = <-ch:
+		st.opts.Metrics.FeedBuild("legacy", !built)
+	case <-ctx.Done():
+		res.Err = ctx.Err()
+	}
+	if res.Err != nil {
+		log.Printf("error building feed: %v", res.Err)
+		AbortUpstreamError(c, res.Err)
+		return
+	}
+
+	feed := res.Val.(rss.Feed)
+	feed.SelfLink = selfLink
+	if title != "" {
+		feed.Title = title
+	}
+	b, err := rss.RenderFormat(feed, format)
 	if err != nil {
-		c.String(502, err.Error())
+		log.Printf("error rendering %s feed: %v", format, err)
+		AbortJSONError(c, http.StatusInternalServerError, ErrorCodeInternal, "failed to render feed")
 		return
 	}
 
-	h.cache.Set(b)
-	c.Data(200, "application/rss+xml", b)
+	st.cache.Set(cacheKey, b)
+	c.Data(200, format.ContentType(), b)
+}
+
+// requestedFeed returns the service building the feed the request asks for
+// and the canonical query string of that feed, which is empty for the
+// configured one. Ad-hoc parameters are validated like feed settings; on
+// failure the request is aborted and ok is false. Ad-hoc builds read the
+// shared article cache but write neither it nor the article history, so
+// anonymous requests cannot grow the database.
+func (st *handlersState) requestedFeed(c *gin.Context) (svc UGCFeedService, query string, ok bool) {
+	rawUsernames := c.QueryArray("u")
+	n := c.Query("n")
+	if len(rawUsernames) == 0 && n == "" {
+		if len(st.svc.Usernames) == 0 {
+			msg := "no default usernames configured; use a static feed"
+			if st.opts.AdHoc {
+				msg = "no default usernames configured; use ?u= or a static feed"
+			}
+			AbortJSONError(c, http.StatusNotFound, ErrorCodeNotFound, msg)
+			return svc, "", false
+		}
+		return st.svc, "", true
+	}
+	if !st.opts.AdHoc {
+		AbortJSONError(c, http.StatusBadRequest, ErrorCodeValidation, "ad-hoc feeds are disabled")
+		return svc, "", false
+	}
+
+	var usernames, invalid []string
+	seen := make(map[string]struct{})
+	for _, raw := range rawUsernames {
+		for _, u := range strings.Split(raw, ",") {
+			u = strings.TrimSpace(u)
+			if u == "" {
+				continue
+			}
+			if err := leetcode.ValidateUsername(u); err != nil {
+				invalid = append(invalid, u)
+				continue
+			}
+			if _, dup := seen[u]; !dup {
+				seen[u] = struct{}{}
+				usernames = append(usernames, u)
+			}
+		}
+	}
+	if len(invalid) > 0 {
+		AbortJSONErrorWithDetails(c, http.StatusBadRequest, ErrorCodeValidation, "invalid usernames", invalid)
+		return svc, "", false
+	}
+	if len(usernames) == 0 {
+		usernames = st.svc.Usernames
+	}
+	if len(usernames) == 0 {
+		AbortJSONError(c, http.StatusBadRequest, ErrorCodeValidation, "u is required")
+		return svc, "", false
+	}
+	if len(usernames) > st.opts.MaxUsernames {
+		AbortJSONError(c, http.StatusBadRequest, ErrorCodeValidation, fmt.Sprintf("at most %d usernames allowed", st.opts.MaxUsernames))
+		return svc, "", false
+	}
+
+	first := st.opts.DefaultArticles
+	if n != "" {
+		v, err := strconv.Atoi(n)
+		if err != nil {
+			AbortJSONError(c, http.StatusBadRequest, ErrorCodeValidation, "n must be an integer")
+			return svc, "", false
+		}
+		first = min(max(v, 1), st.opts.MaxArticles)
+	}
+
+	// the same usernames in any order are the same feed
+	sort.Strings(usernames)
+
+	svc = st.svc
+	svc.Usernames = usernames
+	svc.First = first
+	svc.History = nil
+	if svc.ArticleCache != nil {
+		svc.ArticleCache = readOnlyArticleCache{svc.ArticleCache}
+	}
+	return svc, fmt.Sprintf("u=%s&n=%d", strings.Join(usernames, ","), first), true
+}
+
+// readOnlyArticleCache serves lookups from an ArticleCache without storing
+// anything in it.
+type readOnlyArticleCache struct {
+	ArticleCache
+}
+
+func (readOnlyArticleCache) SetUserArticleCache(context.Context, *store.UserArticleCache) error {
+	return nil
+}
+
+// readOnlyArticleBodies serves cached bodies without storing newly fetched
+// ones.
+type readOnlyArticleBodies struct {
+	ArticleBodies
+}
+
+func (readOnlyArticleBodies) SetArticleBody(context.Context, *store.ArticleBody) error {
+	return nil
 }
 
 func selfURLFromRequest(c *gin.Context) string {
@@ -80,27 +354,74 @@ func forwardedFirst(v string) string {
 }
 
 type PublicFeedHandlers struct {
-	store    store.Store
-	lc       *leetcode.Client
-	sfGroup  singleflight.Group
-	cacheTTL time.Duration
+	store     store.Store
+	lc        *leetcode.Client
+	questions *leetcode.QuestionCache
+	sfGroup   singleflight.Group
+	settings  atomic.Pointer[publicFeedSettings]
+	hub       *websub.Hub
+	webhooks  *webhook.Dispatcher
+	metrics   Metrics
+}
+
+// publicFeedSettings are the options Reconfigure replaces.
+type publicFeedSettings struct {
+	cacheTTL        time.Duration
+	articleCacheTTL time.Duration
+	failureNotice   string
+	buildTimeout    time.Duration
+}
+
+// PublicFeedOptions configures how PublicFeedHandlers build and cache feeds.
+type PublicFeedOptions struct {
+	CacheTTL        time.Duration
+	ArticleCacheTTL time.Duration
+	BuildTimeout    time.Duration           // bounds a shared build; zero means defaultBuildTimeout
+	Questions       *leetcode.QuestionCache // nil disables problem metadata
+	FailureNotice   string                  // one of the FailureNotice* values
+	Hub             *websub.Hub             // nil disables WebSub publishing
+	Webhooks        *webhook.Dispatcher     // nil disables webhook notifications
+	Metrics         Metrics                 // nil records nothing
+}
+
+func NewPublicFeedHandlers(s store.Store, lc *leetcode.Client, opts PublicFeedOptions) *PublicFeedHandlers {
+	h := &PublicFeedHandlers{
+		store:     s,
+		lc:        lc,
+		questions: opts.Questions,
+		hub:       opts.Hub,
+		webhooks:  opts.Webhooks,
+		metrics:   opts.Metrics,
+	}
+	if h.metrics == nil {
+		h.metrics = noMetrics{}
+	}
+	h.Reconfigure(opts)
+	return h
 }
 
-func NewPublicFeedHandlers(s store.Store, lc *leetcode.Client, cacheTTL time.Duration) *PublicFeedHandlers {
-	return &PublicFeedHandlers{
-		store:    s,
-		lc:       lc,
-		cacheTTL: cacheTTL,
+// Reconfigure applies the TTLs, build timeout and failure notice of opts to
+// later builds and responses. Questions, Hub, Webhooks and Metrics are fixed
+// at construction.
+func (h *PublicFeedHandlers) Reconfigure(opts PublicFeedOptions) {
+	buildTimeout := opts.BuildTimeout
+	if buildTimeout <= 0 {
+		buildTimeout = defaultBuildTimeout
 	}
+	h.settings.Store(&publicFeedSettings{
+		cacheTTL:        opts.CacheTTL,
+		articleCacheTTL: opts.ArticleCacheTTL,
+		failureNotice:   opts.FailureNotice,
+		buildTimeout:    buildTimeout,
+	})
 }
 
-// GET /f/:feedID/:secret.xml
+// GET /f/:feedID/:secret.xml, .atom and .json
 func (h *PublicFeedHandlers) PublicFeed(c *gin.Context) {
 	feedID := c.Param("feedID")
-	secretParam := c.Param("secret")
-	secret := strings.TrimSuffix(secretParam, ".xml")
+	secret, format, ok := splitFeedExt(c.Param("secret"))
 
-	if !isValidUUID(feedID) {
+	if !ok || !isValidUUID(feedID) {
 		c.Status(http.StatusNotFound)
 		return
 	}
@@ -123,32 +444,48 @@ func (h *PublicFeedHandlers) PublicFeed(c *gin.Context) {
 		return
 	}
 
-	cache, cacheErr := h.store.GetFeedCache(ctx, feedID)
-	hasFreshCache := cacheErr == nil && cache != nil && cache.ExpiresAt.After(time.Now())
+	cache, cacheErr := h.store.GetFeedCache(ctx, feedID, string(format))
+	hasCache := cacheErr == nil && cache != nil && len(cache.XML) > 0
+	hasFreshCache := hasCache && cache.ExpiresAt.After(time.Now())
 
 	if hasFreshCache {
+		h.metrics.FeedCache("feed", "hit")
 		h.serveCachedFeed(c, cache, false)
 		return
 	}
 
-	hasStaleCache := cacheErr == nil && cache != nil
-
-	result, err, _ := h.sfGroup.Do(feedID, func() (interface{}, error) {
-		return h.refreshFeed(ctx, feed, selfURLFromRequest(c))
-	})
+	hasStaleCache := hasCache
 
+	baseURL := strings.TrimSuffix(selfURLFromRequest(c), format.Ext())
+	caches, err := h.RefreshFeed(ctx, feed, baseURL)
 	if err != nil {
 		log.Printf("error refreshing feed %s: %v", feedID, err)
 		if hasStaleCache {
+			h.metrics.FeedCache("feed", "stale")
 			h.serveCachedFeed(c, cache, true)
 			return
 		}
-		AbortJSONError(c, http.StatusBadGateway, ErrorCodeUpstream, err.Error())
+		h.metrics.FeedCache("feed", "miss")
+		AbortUpstreamError(c, err)
 		return
 	}
 
-	newCache := result.(*store.FeedCache)
-	h.serveCachedFeed(c, newCache, false)
+	h.metrics.FeedCache("feed", "miss")
+	h.serveCachedFeed(c, caches[format], false)
+}
+
+// splitFeedExt splits "secret.ext" into the secret and the requested format.
+// A missing extension means RSS.
+func splitFeedExt(param string) (string, rss.Format, bool) {
+	ext := path.Ext(param)
+	if ext == "" {
+		return param, rss.FormatRSS, true
+	}
+	format, ok := rss.FormatFromExt(ext)
+	if !ok {
+		return "", "", false
+	}
+	return strings.TrimSuffix(param, ext), format, true
 }
 
 func (h *PublicFeedHandlers) serveCachedFeed(c *gin.Context, cache *store.FeedCache, stale bool) {
@@ -170,42 +507,204 @@ func (h *PublicFeedHandlers) serveCachedFeed(c *gin.Context, cache *store.FeedCa
 	}
 	c.Header("ETag", cache.ETag)
 	c.Header("Last-Modified", cache.LastBuiltAt.UTC().Format(http.TimeFormat))
-	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.cacheTTL.Seconds())))
-	c.Data(http.StatusOK, "application/rss+xml", cache.XML)
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.settings.Load().cacheTTL.Seconds())))
+	c.Data(http.StatusOK, rss.Format(cache.Format).ContentType(), cache.XML)
 }
 
-func (h *PublicFeedHandlers) refreshFeed(ctx context.Context, feed *store.Feed, selfURL string) (*store.FeedCache, error) {
-	svc := UGCFeedService{
-		Usernames: feed.Usernames,
-		LC:        h.lc,
-		First:     feed.FirstPerUser,
+// RefreshFeed rebuilds a feed and stores every format in feed_cache.
+// baseURL is the feed URL without extension; each format's self link is
+// derived from it. Concurrent refreshes of the same feed share a single build.
+// With a WebSub hub configured, formats whose ETag changed are pushed to
+// their subscribers; with webhooks configured, new items are announced.
+func (h *PublicFeedHandlers) RefreshFeed(ctx context.Context, feed *store.Feed, baseURL string) (map[rss.Format]*store.FeedCache, error) {
+	return h.rebuildFeed(ctx, feed, baseURL, false)
+}
+
+// RefetchFeed is RefreshFeed fetching every username upstream instead of
+// from the shared article cache, for owners asking for fresh content. A
+// refetch arriving while a plain refresh of the feed is under way waits
+// for it and then builds again, so the two never write feed_cache at once.
+func (h *PublicFeedHandlers) RefetchFeed(ctx context.Context, feed *store.Feed, baseURL string) (map[rss.Format]*store.FeedCache, error) {
+	return h.rebuildFeed(ctx, feed, baseURL, true)
+}
+
+// rebuiltFeed is the outcome of a shared build and whether it refetched.
+type rebuiltFeed struct {
+	caches  map[rss.Format]*store.FeedCache
+	refetch bool
+}
+
+// rebuildFeed shares one build per feed between concurrent callers. The
+// build runs detached from ctx, bounded by the build timeout, so a caller
+// that gives up does not fail it for the others; ctx only bounds the wait.
+func (h *PublicFeedHandlers) rebuildFeed(ctx context.Context, feed *store.Feed, baseURL string, refetch bool) (map[rss.Format]*store.FeedCache, error) {
+	for {
+		built := false
+		ch := h.sfGroup.DoChan(feed.ID, func() (interface{}, error) {
+			built = true
+			buildCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), h.settings.Load().buildTimeout)
+			defer cancel()
+			caches, err := h.refreshFeed(buildCtx, feed, baseURL, refetch)
+			return rebuiltFeed{caches: caches, refetch: refetch}, err
+		})
+
+		var res singleflight.Result
+		select {
+		case res = <-ch:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if res.Err != nil {
+			h.metrics.FeedBuild("feed", !built)
+			return nil, res.Err
+		}
+		rebuilt := res.Val.(rebuiltFeed)
+		if refetch && !rebuilt.refetch {
+			// joined a refresh that may have read the article cache
+			continue
+		}
+		h.metrics.FeedBuild("feed", !built)
+		return rebuilt.caches, nil
 	}
+}
+
+// PreviewFeed builds feed without storing anything, so it works for feeds
+// that have not been saved.
+func (h *PublicFeedHandlers) PreviewFeed(ctx context.Context, feed *store.Feed) (*BuildResult, error) {
+	return h.readOnlyFeedService(feed).Build(ctx)
+}
 
-	xml, err := svc.Build(ctx, selfURL)
+// DigestItems builds the items an email digest of feed lists, newest
+// first: one per article even when the feed groups them by period, and
+// without a diagnostic item. Like PreviewFeed it stores nothing.
+func (h *PublicFeedHandlers) DigestItems(ctx context.Context, feed *store.Feed) ([]rss.Item, error) {
+	svc := h.readOnlyFeedService(feed)
+	svc.GroupBy = ""
+	svc.Location = nil
+	svc.IncludeContent = false
+	svc.FailureNotice = FailureNoticeNone
+	result, err := svc.Build(ctx)
 	if err != nil {
-		errStr := err.Error()
-		_ = h.store.SetFeedCache(ctx, &store.FeedCache{
-			FeedID:    feed.ID,
-			LastError: &errStr,
-		})
 		return nil, err
 	}
+	return result.ArticleItems(), nil
+}
 
-	now := time.Now()
-	cache := &store.FeedCache{
-		FeedID:      feed.ID,
-		XML:         xml,
-		ETag:        generateETag(xml),
-		LastBuiltAt: now,
-		ExpiresAt:   now.Add(h.cacheTTL),
-		LastError:   nil,
+func (h *PublicFeedHandlers) refreshFeed(ctx context.Context, feed *store.Feed, baseURL string, refetch bool) (map[rss.Format]*store.FeedCache, error) {
+	svc := h.feedService(feed)
+	svc.Refetch = refetch
+	result, err := svc.Build(ctx)
+	if err != nil {
+		var users []store.UsernameStatus
+		if result != nil {
+			users = result.Users
+		}
+		_ = h.store.SetFeedCacheError(ctx, feed.ID, err.Error(), users)
+		return nil, err
+	}
+
+	// a partial build still refreshes the cache; the failing usernames are
+	// recorded so the owner can see which one is broken
+	var lastError *string
+	if summary := result.FailureSummary(); summary != "" {
+		log.Printf("warning: feed %s built without some usernames: %s", feed.ID, summary)
+		lastError = &summary
 	}
 
-	if err := h.store.SetFeedCache(ctx, cache); err != nil {
-		log.Printf("warning: failed to cache feed %s: %v", feed.ID, err)
+	if h.webhooks != nil {
+		if err := h.webhooks.Notify(ctx, feed, result.ArticleItems()); err != nil {
+			log.Printf("warning: failed to notify webhooks of feed %s: %v", feed.ID, err)
+		}
+	}
+
+	built := result.Feed
+
+	var previousETags map[string]string
+	if h.hub != nil {
+		built.HubLink = h.hub.URL
+		if previousETags, err = h.store.GetFeedCacheETags(ctx, feed.ID); err != nil {
+			log.Printf("warning: failed to read etags of feed %s: %v", feed.ID, err)
+		}
 	}
 
-	return cache, nil
+	cacheTTL := h.settings.Load().cacheTTL
+	now := time.Now()
+	caches := make(map[rss.Format]*store.FeedCache, len(rss.Formats))
+	for _, format := range rss.Formats {
+		built.SelfLink = baseURL + format.Ext()
+		doc, err := rss.RenderFormat(built, format)
+		if err != nil {
+			return nil, fmt.Errorf("render %s: %w", format, err)
+		}
+
+		cache := &store.FeedCache{
+			FeedID:      feed.ID,
+			Format:      string(format),
+			XML:         doc,
+			ETag:        generateETag(doc),
+			LastBuiltAt: now,
+			ExpiresAt:   now.Add(cacheTTL),
+			LastError:   lastError,
+			UserStatus:  result.Users,
+			ItemCount:   result.ItemCount,
+			BuildTime:   result.Duration,
+		}
+		if err := h.store.SetFeedCache(ctx, cache); err != nil {
+			log.Printf("warning: failed to cache %s feed %s: %v", format, feed.ID, err)
+		}
+		caches[format] = cache
+
+		// a failed etag lookup leaves previousETags nil, so subscribers get
+		// a possibly redundant push rather than missing an update
+		if h.hub != nil && previousETags[string(format)] != cache.ETag {
+			h.hub.Publish(feed.ID, string(format), format.ContentType(), doc)
+		}
+	}
+
+	return caches, nil
+}
+
+// readOnlyFeedService configures a build of feed's articles that, like an
+// ad-hoc feed, reads the shared article and body caches but writes neither
+// them nor the article history.
+func (h *PublicFeedHandlers) readOnlyFeedService(feed *store.Feed) UGCFeedService {
+	svc := h.feedService(feed)
+	svc.History = nil
+	svc.ArticleCache = readOnlyArticleCache{svc.ArticleCache}
+	svc.Bodies = readOnlyArticleBodies{svc.Bodies}
+	return svc
+}
+
+// feedService configures a build of feed's articles.
+func (h *PublicFeedHandlers) feedService(feed *store.Feed) UGCFeedService {
+	settings := h.settings.Load()
+	svc := UGCFeedService{
+		Usernames:    feed.Usernames,
+		LC:           h.lc,
+		First:        feed.FirstPerUser,
+		ArticleCache: h.store,
+		CacheTTL:     settings.articleCacheTTL,
+		History:      h.store,
+
+		IncludeContent: feed.IncludeContent,
+		Bodies:         h.store,
+		Questions:      h.questions,
+		Filter:         feed.Filter,
+		FailureNotice:  settings.failureNotice,
+	}
+	if feed.Since != nil {
+		svc.Since = *feed.Since
+	}
+	if feed.GroupBy != "" {
+		svc.GroupBy = feed.GroupBy
+		loc, err := time.LoadLocation(feed.Timezone)
+		if err != nil {
+			log.Printf("warning: feed %s has invalid timezone %q, grouping in UTC: %v", feed.ID, feed.Timezone, err)
+			loc = time.UTC
+		}
+		svc.Location = loc
+	}
+	return svc
 }
 
 func generateETag(xml []byte) string {
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"leetcode-rss/internal/leetcode"
+	"leetcode-rss/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+// feedStore is the part of store.Store a feed build touches. Calls to
+// anything else panic on the nil embedded Store.
+type feedStore struct {
+	store.Store
+
+	writing    atomic.Int32
+	overlapped atomic.Bool
+	// persisted counts writes other than feed_cache.
+	persisted atomic.Int32
+}
+
+func (s *feedStore) GetUserArticleCache(context.Context, string) (*store.UserArticleCache, error) {
+	return nil, store.ErrNotFound
+}
+
+func (s *feedStore) SetUserArticleCache(context.Context, *store.UserArticleCache) error {
+	s.persisted.Add(1)
+	return nil
+}
+
+func (s *feedStore) UpsertArticles(context.Context, string, []leetcode.Article, time.Time) ([]string, error) {
+	s.persisted.Add(1)
+	return nil, nil
+}
+
+func (s *feedStore) ListArticlesByUsername(context.Context, string, int, *time.Time) ([]store.Article, error) {
+	return nil, errors.New("no history")
+}
+
+func (s *feedStore) SetFeedCache(context.Context, *store.FeedCache) error {
+	if s.writing.Add(1) > 1 {
+		s.overlapped.Store(true)
+	}
+	defer s.writing.Add(-1)
+	time.Sleep(time.Millisecond)
+	return nil
+}
+
+func (s *feedStore) SetFeedCacheError(context.Context, string, string, []store.UsernameStatus) error {
+	return nil
+}
+
+// gatedUpstream holds every request until release is closed and counts
+// the requests it has received.
+type gatedUpstream struct {
+	release  chan struct{}
+	received chan struct{}
+	calls    atomic.Int32
+}
+
+func newGatedUpstream(t *testing.T) (*gatedUpstream, *leetcode.Client) {
+	t.Helper()
+	g := &gatedUpstream{release: make(chan struct{}), received: make(chan struct{}, 16)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.calls.Add(1)
+		g.received <- struct{}{}
+		<-g.release
+		var env leetcode.UGCArticlesEnvelope
+		json.NewEncoder(w).Encode(env)
+	}))
+	t.Cleanup(srv.Close)
+	c := leetcode.New(srv.URL, "", "")
+	c.Retry = leetcode.RetryPolicy{MaxAttempts: 1}
+	return g, c
+}
+
+func TestRefetchWaitsForRunningRefresh(t *testing.T) {
+	up, lc := newGatedUpstream(t)
+	st := &feedStore{}
+	h := NewPublicFeedHandlers(st, lc, PublicFeedOptions{CacheTTL: time.Minute})
+	feed := &store.Feed{ID: "feed", Usernames: []string{"alice"}}
+
+	refreshDone := make(chan error, 1)
+	go func() {
+		_, err := h.RefreshFeed(context.Background(), feed, "https://feeds.example/f/feed/secret")
+		refreshDone <- err
+	}()
+	<-up.received
+
+	refetchDone := make(chan error, 1)
+	go func() {
+		_, err := h.RefetchFeed(context.Background(), feed, "https://feeds.example/f/feed/secret")
+		refetchDone <- err
+	}()
+	// give the refetch time to join the refresh in flight
+	time.Sleep(20 * time.Millisecond)
+	close(up.release)
+
+	if err := <-refreshDone; err != nil {
+		t.Fatalf("RefreshFeed: %v", err)
+	}
+	if err := <-refetchDone; err != nil {
+		t.Fatalf("RefetchFeed: %v", err)
+	}
+	if calls := up.calls.Load(); calls != 2 {
+		t.Errorf("upstream called %d times, want the refresh and then the refetch", calls)
+	}
+	if st.overlapped.Load() {
+		t.Error("refresh and refetch wrote feed_cache concurrently")
+	}
+}
+
+func TestSharedRefreshOutlivesCancelledCaller(t *testing.T) {
+	up, lc := newGatedUpstream(t)
+	h := NewPublicFeedHandlers(&feedStore{}, lc, PublicFeedOptions{CacheTTL: time.Minute})
+	feed := &store.Feed{ID: "feed", Usernames: []string{"alice"}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstDone := make(chan error, 1)
+	go func() {
+		_, err := h.RefreshFeed(ctx, feed, "https://feeds.example/f/feed/secret")
+		firstDone <- err
+	}()
+	<-up.received
+
+	secondDone := make(chan error, 1)
+	go func() {
+		_, err := h.RefreshFeed(context.Background(), feed, "https://feeds.example/f/feed/secret")
+		secondDone <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	if err := <-firstDone; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled caller got %v, want context.Canceled", err)
+	}
+	close(up.release)
+	if err := <-secondDone; err != nil {
+		t.Fatalf("remaining caller got %v, want the shared build", err)
+	}
+	if calls := up.calls.Load(); calls != 1 {
+		t.Errorf("upstream called %d times, want one shared build", calls)
+	}
+}
+
+func TestLegacyBuildOutlivesCancelledRequest(t *testing.T) {
+	up, lc := newGatedUpstream(t)
+	h := NewHandlers(UGCFeedService{Usernames: []string{"alice"}, LC: lc, First: 5}, HandlersOptions{CacheTTL: time.Minute, CacheSize: 4})
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/leetcode.xml", h.RSS)
+
+	get := func(ctx context.Context) <-chan int {
+		done := make(chan int, 1)
+		go func() {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/leetcode.xml", nil).WithContext(ctx))
+			done <- w.Code
+		}()
+		return done
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := get(ctx)
+	<-up.received
+	second := get(context.Background())
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	if code := <-first; code == http.StatusOK {
+		t.Fatal("cancelled request got the feed")
+	}
+	close(up.release)
+	if code := <-second; code != http.StatusOK {
+		t.Fatalf("remaining request got status %d, want the shared build", code)
+	}
+	if calls := up.calls.Load(); calls != 1 {
+		t.Errorf("upstream called %d times, want one shared build", calls)
+	}
+}
+
+func TestPreviewFeedStoresNothing(t *testing.T) {
+	up, lc := newGatedUpstream(t)
+	close(up.release)
+	st := &feedStore{}
+	h := NewPublicFeedHandlers(st, lc, PublicFeedOptions{CacheTTL: time.Minute, ArticleCacheTTL: time.Hour})
+
+	result, err := h.PreviewFeed(context.Background(), &store.Feed{Usernames: []string{"alice", "bob"}})
+	if err != nil {
+		t.Fatalf("PreviewFeed: %v", err)
+	}
+	if len(result.Users) != 2 {
+		t.Fatalf("got %d username results, want 2", len(result.Users))
+	}
+	if n := st.persisted.Load(); n != 0 {
+		t.Errorf("preview made %d article cache or history writes, want none", n)
+	}
+}
+
+func TestDigestItemsIgnoreGrouping(t *testing.T) {
+	up := &upstream{articles: testArticles(3)}
+	st := &feedStore{}
+	h := NewPublicFeedHandlers(st, up.client(t), PublicFeedOptions{CacheTTL: time.Minute, FailureNotice: FailureNoticeItem})
+	feed := &store.Feed{Usernames: []string{"alice"}, GroupBy: "week", Timezone: "Europe/Berlin"}
+
+	items, err := h.DigestItems(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("DigestItems: %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("got %d items, want one per article", len(items))
+	}
+	for i, it := range items {
+		if want := articleLink(testArticles(3)[i]); it.Link != want {
+			t.Errorf("item %d links to %s, want article %s", i, it.Link, want)
+		}
+	}
+	if n := st.persisted.Load(); n != 0 {
+		t.Errorf("digest build made %d article cache or history writes, want none", n)
+	}
+}
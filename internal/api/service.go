@@ -2,70 +2,236 @@ package api
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"leetcode-rss/internal/leetcode"
+	"leetcode-rss/internal/markdown"
 	"leetcode-rss/internal/rss"
+	"leetcode-rss/internal/store"
 
 	"golang.org/x/sync/errgroup"
 )
 
+const (
+	defaultArticlesPerUser = 15
+	maxArticlesPerUser     = 500
+
+	// maxBodyFetchesPerBuild and maxQuestionFetchesPerBuild bound how many
+	// uncached article bodies and problems a single build fetches; the rest
+	// are picked up by later rebuilds, newest first.
+	maxBodyFetchesPerBuild     = 50
+	maxQuestionFetchesPerBuild = 50
+	enrichFetchConcurrency     = 4
+)
+
+// UGCFeedService builds a feed from the solution articles of Usernames.
+//
+// First is the number of most recent articles fetched per user and Since
+// drops anything older than it. Either may exceed a single upstream page;
+// articles are paginated as needed. When both are unset First defaults to 15.
+//
+// When ArticleCache is set, each username is looked up there first so a
+// username shared by many feeds is fetched at most once per CacheTTL.
+// Refetch skips that lookup, fetching every username upstream and storing
+// the result for the next builds.
+// When History is set, every fetched article is recorded and the feed is
+// rendered from that history instead of straight from the upstream response.
+//
+// When IncludeContent is set, each item also carries the article's full body
+// converted to HTML. Bodies are cached in Bodies, if set, by article UUID.
+// When Questions is set, items are labelled with the problem's difficulty
+// and topics. Filter, if set, drops fetched articles that do not match it
+// before the feed is rendered.
+//
+// GroupBy switches the feed to digest mode: instead of one item per article
+// it emits one item per completed day or week, listing that period's
+// articles by username. Periods start at midnight in Location (UTC when
+// nil), weeks on Monday. Every article of the last few periods is loaded,
+// from History where possible, so First does not apply and
+// IncludeContent is ignored.
 type UGCFeedService struct {
-	Usernames []string
-	LC        *leetcode.Client
-	First     int
+	Usernames    []string
+	LC           *leetcode.Client
+	First        int
+	Since        time.Time
+	ArticleCache ArticleCache
+	CacheTTL     time.Duration
+	Refetch      bool
+	History      ArticleHistory
+
+	IncludeContent bool
+	Bodies         ArticleBodies
+	Questions      *leetcode.QuestionCache
+	Filter         *store.FeedFilter
+	FailureNotice  string
+
+	GroupBy  string
+	Location *time.Location
+}
+
+// FailureNotice values select how a feed tells readers that some usernames
+// could not be fetched.
+const (
+	FailureNoticeNone = "none" // only recorded in the build result
+	FailureNoticeNote = "note" // appended to the feed description
+	FailureNoticeItem = "item" // a diagnostic item at the top of the feed
+)
+
+// ArticleCache is the subset of store.Store used to share fetched articles
+// between feed builds.
+type ArticleCache interface {
+	GetUserArticleCache(ctx context.Context, username string) (*store.UserArticleCache, error)
+	SetUserArticleCache(ctx context.Context, cache *store.UserArticleCache) error
 }
 
-func (s UGCFeedService) Build(ctx context.Context, selfURL string) ([]byte, error) {
+// ArticleHistory is the subset of store.Store that persists every article seen.
+type ArticleHistory interface {
+	UpsertArticles(ctx context.Context, username string, articles []leetcode.Article, seenAt time.Time) ([]string, error)
+	ListArticlesByUsername(ctx context.Context, username string, limit int, since *time.Time) ([]store.Article, error)
+}
+
+// ArticleBodies is the subset of store.Store that caches article bodies.
+type ArticleBodies interface {
+	GetArticleBodies(ctx context.Context, uuids []string) (map[string]*store.ArticleBody, error)
+	SetArticleBody(ctx context.Context, body *store.ArticleBody) error
+}
+
+// BuildResult is a built feed together with the outcome for each username.
+type BuildResult struct {
+	Feed  rss.Feed
+	Users []store.UsernameStatus
+	// ItemCount is the number of article items, not counting a diagnostic item.
+	ItemCount int
+	Duration  time.Duration
+}
+
+// FailureSummary describes the usernames that could not be loaded, or
+// returns "" when every username succeeded.
+func (r *BuildResult) FailureSummary() string {
+	var failed []string
+	for _, u := range r.Users {
+		if !u.OK {
+			failed = append(failed, fmt.Sprintf("%s: %s", u.Username, u.Error))
+		}
+	}
+	if len(failed) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("fetch failed for %d of %d usernames: %s", len(failed), len(r.Users), strings.Join(failed, "; "))
+}
+
+// ArticleItems returns the feed's article items, leaving out the diagnostic
+// item a FailureNoticeItem build puts first.
+func (r *BuildResult) ArticleItems() []rss.Item {
+	return r.Feed.Items[len(r.Feed.Items)-r.ItemCount:]
+}
+
+// BuildFeed fetches articles for every username and assembles the
+// format-independent feed model. SelfLink is left for the caller to set.
+func (s UGCFeedService) BuildFeed(ctx context.Context) (rss.Feed, error) {
+	result, err := s.Build(ctx)
+	if err != nil {
+		return rss.Feed{}, err
+	}
+	return result.Feed, nil
+}
+
+// Build is BuildFeed with per-username outcomes. A username that fails does
+// not fail the build: the feed is assembled from the usernames that worked
+// and the failure is reported in the result. Build only returns an error
+// when every username failed, in which case it is the first username's and
+// the result carries just the statuses.
+func (s UGCFeedService) Build(ctx context.Context) (*BuildResult, error) {
 	first := s.First
-	if first <= 0 {
-		first = 15
+	if first <= 0 && s.Since.IsZero() {
+		first = defaultArticlesPerUser
 	}
-	if first > 50 {
-		first = 50
+	if first > maxArticlesPerUser {
+		first = maxArticlesPerUser
 	}
-	allArticles := make([]leetcode.Article, 0)
-	var mu sync.Mutex
-	g, ctx := errgroup.WithContext(ctx)
-	maxConcurrentFetches := 4
-	if len(s.Usernames) > 0 && len(s.Usernames) < maxConcurrentFetches {
-		maxConcurrentFetches = len(s.Usernames)
-	}
-	sem := make(chan struct{}, maxConcurrentFetches)
-	for _, username := range s.Usernames {
-		username := username
-		g.Go(func() error {
-			sem <- struct{}{}
-			defer func() { <-sem }()
-			articles, err := leetcode.FetchUserSolutionArticles(ctx, s.LC, username, first)
-			if err != nil {
-				return fmt.Errorf("error fetching articles for user %s: %w", username, err)
-			}
-			mu.Lock()
-			allArticles = append(allArticles, articles...)
-			mu.Unlock()
-			return nil
-		})
+	opts := leetcode.FetchOptions{
+		Limit: first,
+		Since: s.Since,
 	}
-	if err := g.Wait(); err != nil {
+	filter, err := compileFilter(s.Filter, s.Questions != nil)
+	if err != nil {
 		return nil, err
 	}
-	type timedArticle struct {
-		Article   leetcode.Article
-		CreatedAt time.Time
-		OK        bool
+	started := time.Now()
+	loc := s.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	if s.GroupBy != "" {
+		// whole periods, however many articles they hold
+		opts.Limit = maxArticlesPerUser
+		if start := groupWindowStart(started, s.GroupBy, loc); start.After(opts.Since) {
+			opts.Since = start
+		}
+	}
+
+	type userResult struct {
+		fetch    userFetch
+		err      error
+		duration time.Duration
+	}
+	results := make([]userResult, len(s.Usernames))
+	// upstream concurrency is bounded process-wide by the client's limiter
+	var wg sync.WaitGroup
+	for i, username := range s.Usernames {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fetchStarted := time.Now()
+			fetch, err := s.userArticles(ctx, username, opts)
+			results[i] = userResult{fetch: fetch, err: err, duration: time.Since(fetchStarted)}
+		}()
+	}
+	wg.Wait()
+
+	allArticles := make([]authoredArticle, 0)
+	statuses := make([]store.UsernameStatus, 0, len(s.Usernames))
+	var firstErr error
+	for i, username := range s.Usernames {
+		r := results[i]
+		status := store.UsernameStatus{Username: username, OK: r.err == nil, DurationMS: r.duration.Milliseconds()}
+		if r.err != nil {
+			status.Error = r.err.Error()
+			if firstErr == nil {
+				firstErr = fmt.Errorf("error fetching articles for user %s: %w", username, r.err)
+			}
+			log.Printf("warning: dropping %s from feed: %v", username, r.err)
+		} else {
+			status.Articles = len(r.fetch.articles)
+			if r.fetch.upstreamErr != nil {
+				status.Error = r.fetch.upstreamErr.Error()
+				status.FromHistory = true
+			}
+		}
+		statuses = append(statuses, status)
+		for _, a := range r.fetch.articles {
+			allArticles = append(allArticles, authoredArticle{Article: a, Username: username})
+		}
+	}
+	if firstErr != nil && len(failedUsernames(statuses)) == len(statuses) {
+		return &BuildResult{Users: statuses, Duration: time.Since(started)}, firstErr
 	}
 	timed := make([]timedArticle, 0, len(allArticles))
 	for _, a := range allArticles {
-		t, err := time.Parse(time.RFC3339Nano, a.CreatedAt) //createdAt is like 2026-01-07T03:52:30.464981+00:00
-		if err != nil {
-			timed = append(timed, timedArticle{Article: a})
+		t, ok := a.CreatedTime()
+		if !ok {
+			timed = append(timed, timedArticle{Article: a.Article, Username: a.Username})
 			continue
 		}
-		timed = append(timed, timedArticle{Article: a, CreatedAt: t, OK: true})
+		timed = append(timed, timedArticle{Article: a.Article, Username: a.Username, CreatedAt: t, OK: true})
 	}
 	sort.Slice(timed, func(i, j int) bool {
 		ai, aj := timed[i], timed[j]
@@ -80,6 +246,71 @@ func (s UGCFeedService) Build(ctx context.Context, selfURL string) ([]byte, erro
 		}
 		return ai.Article.TopicID > aj.Article.TopicID
 	})
+	var questions map[string]*leetcode.Question
+	if s.Questions != nil {
+		slugs := make([]string, 0, len(timed))
+		for _, a := range timed {
+			slugs = append(slugs, a.Article.QuestionSlug)
+		}
+		questions = s.questionMetadata(ctx, slugs)
+	}
+
+	if filter != nil {
+		kept := timed[:0]
+		for _, a := range timed {
+			if filter.match(a.Article, questions[a.Article.QuestionSlug]) {
+				kept = append(kept, a)
+			}
+		}
+		timed = kept
+	}
+
+	var bodies map[string]string
+	if s.IncludeContent && s.GroupBy == "" {
+		ordered := make([]leetcode.Article, 0, len(timed))
+		for _, a := range timed {
+			ordered = append(ordered, a.Article)
+		}
+		bodies = s.articleBodies(ctx, ordered)
+	}
+
+	var items []rss.Item
+	if s.GroupBy != "" {
+		items = groupItems(timed, s.Usernames, questions, s.GroupBy, loc, started)
+	} else {
+		items = articleItems(timed, questions, bodies)
+	}
+
+	feedTitle := buildFeedTitle(s.Usernames)
+	feedLink := buildFeedLink(s.Usernames)
+	description := "Auto-generated feed of LeetCode Solution Articles (Discuss)."
+	itemCount := len(items)
+
+	if failed := failedUsernames(statuses); len(failed) > 0 {
+		notice := fmt.Sprintf("Articles could not be fetched for: %s.", strings.Join(failed, ", "))
+		switch s.FailureNotice {
+		case FailureNoticeNote:
+			description += " " + notice
+		case FailureNoticeItem:
+			items = append([]rss.Item{failureItem(failed, notice)}, items...)
+		}
+	}
+
+	return &BuildResult{
+		Feed: rss.Feed{
+			Title:       feedTitle,
+			Link:        feedLink,
+			Description: description,
+			Items:       items,
+		},
+		Users:     statuses,
+		ItemCount: itemCount,
+		Duration:  time.Since(started),
+	}, nil
+}
+
+// articleItems renders one item per article.
+func articleItems(timed []timedArticle, questions map[string]*leetcode.Question, bodies map[string]string) []rss.Item {
 	items := make([]rss.Item, 0, len(timed))
 	for _, a := range timed {
 		t := time.Unix(0, 0).UTC()
@@ -90,26 +321,352 @@ func (s UGCFeedService) Build(ctx context.Context, selfURL string) ([]byte, erro
 		link := articleLink(a.Article)
 		guid := fmt.Sprintf("%d:%s", a.Article.TopicID, a.Article.UUID)
 
+		q := questions[a.Article.QuestionSlug]
 		items = append(items, rss.Item{
-			Title:   a.Article.Title,
-			Link:    link,
-			GUID:    guid,
-			PubDate: t,
-			Summary: fmt.Sprintf("Solution for %s (%s). Hits: %d", a.Article.QuestionTitle, a.Article.QuestionSlug, a.Article.HitCount),
+			Title:      a.Article.Title,
+			Link:       link,
+			GUID:       guid,
+			PubDate:    t,
+			Author:     a.Username,
+			AuthorURI:  profileLink(a.Username),
+			Summary:    itemSummary(a.Article, q),
+			Categories: questionCategories(q),
+
+			ContentHTML: bodies[a.Article.UUID],
 		})
 	}
+	return items
+}
 
-	feedTitle := buildFeedTitle(s.Usernames)
-	feedLink := buildFeedLink(s.Usernames)
+// failedUsernames lists the usernames that contributed no articles.
+func failedUsernames(statuses []store.UsernameStatus) []string {
+	var failed []string
+	for _, u := range statuses {
+		if !u.OK {
+			failed = append(failed, u.Username)
+		}
+	}
+	return failed
+}
 
-	feed := rss.Feed{
-		Title:       feedTitle,
-		Link:        feedLink,
-		SelfLink:    selfURL,
-		Description: "Auto-generated RSS feed of LeetCode Solution Articles (Discuss).",
-		Items:       items,
+// failureItem is the diagnostic item listing usernames that failed. Its GUID
+// depends only on the failing set and its date only on the day, so readers
+// see one item per outage instead of one per rebuild.
+func failureItem(failed []string, notice string) rss.Item {
+	sum := sha256.Sum256([]byte(strings.Join(failed, ",")))
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	return rss.Item{
+		Title:   "Some LeetCode usernames could not be fetched",
+		Link:    "https://leetcode.com/",
+		GUID:    "fetch-failure:" + today.Format(time.DateOnly) + ":" + hex.EncodeToString(sum[:8]),
+		PubDate: today,
+		Author:  "leetcode-rss",
+		Summary: notice + " Check that they still exist on LeetCode.",
 	}
-	return rss.Render(feed)
+}
+
+// articleBodies returns the HTML body of each article, keyed by UUID.
+// Bodies come from the Bodies cache where possible; the rest are fetched
+// upstream in the given order, up to maxBodyFetchesPerBuild. Articles whose
+// body could not be loaded are absent from the result, so a failed body
+// fetch only drops that item's content rather than failing the build.
+func (s UGCFeedService) articleBodies(ctx context.Context, articles []leetcode.Article) map[string]string {
+	markdownBodies := make(map[string]string, len(articles))
+	if s.Bodies != nil {
+		uuids := make([]string, 0, len(articles))
+		for _, a := range articles {
+			uuids = append(uuids, a.UUID)
+		}
+		cached, err := s.Bodies.GetArticleBodies(ctx, uuids)
+		if err != nil {
+			log.Printf("warning: failed to read cached article bodies: %v", err)
+		}
+		for id, body := range cached {
+			markdownBodies[id] = body.Content
+		}
+	}
+
+	missing := make([]leetcode.Article, 0)
+	for _, a := range articles {
+		if _, ok := markdownBodies[a.UUID]; !ok && a.UUID != "" {
+			missing = append(missing, a)
+		}
+	}
+	if len(missing) > maxBodyFetchesPerBuild {
+		missing = missing[:maxBodyFetchesPerBuild]
+	}
+
+	var mu sync.Mutex
+	var g errgroup.Group
+	g.SetLimit(enrichFetchConcurrency)
+	for _, a := range missing {
+		a := a
+		g.Go(func() error {
+			content, err := leetcode.FetchSolutionArticleContent(ctx, s.LC, a.TopicID)
+			if err != nil {
+				log.Printf("warning: failed to fetch body of article %s: %v", a.UUID, err)
+				return nil
+			}
+			if s.Bodies != nil {
+				if err := s.Bodies.SetArticleBody(ctx, &store.ArticleBody{
+					UUID:      a.UUID,
+					TopicID:   a.TopicID,
+					Content:   content.Content,
+					UpdatedAt: content.UpdatedAt,
+					FetchedAt: time.Now(),
+				}); err != nil {
+					log.Printf("warning: failed to cache body of article %s: %v", a.UUID, err)
+				}
+			}
+			mu.Lock()
+			markdownBodies[a.UUID] = content.Content
+			mu.Unlock()
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	out := make(map[string]string, len(markdownBodies))
+	for id, md := range markdownBodies {
+		out[id] = markdown.ToHTML(md)
+	}
+	return out
+}
+
+// questionMetadata returns problem metadata for slugs, keyed by slug. Cached
+// entries are used as is; up to maxQuestionFetchesPerBuild misses are
+// fetched in the given order. Slugs that could not be resolved are absent.
+func (s UGCFeedService) questionMetadata(ctx context.Context, slugs []string) map[string]*leetcode.Question {
+	out := make(map[string]*leetcode.Question)
+	missing := make([]string, 0)
+	seen := make(map[string]struct{}, len(slugs))
+	for _, slug := range slugs {
+		if _, ok := seen[slug]; ok || slug == "" {
+			continue
+		}
+		seen[slug] = struct{}{}
+		if q, ok := s.Questions.Lookup(slug); ok {
+			out[slug] = q
+		} else {
+			missing = append(missing, slug)
+		}
+	}
+	if len(missing) > maxQuestionFetchesPerBuild {
+		missing = missing[:maxQuestionFetchesPerBuild]
+	}
+
+	var mu sync.Mutex
+	var g errgroup.Group
+	g.SetLimit(enrichFetchConcurrency)
+	for _, slug := range missing {
+		slug := slug
+		g.Go(func() error {
+			q, err := s.Questions.Get(ctx, slug)
+			if err != nil {
+				log.Printf("warning: failed to fetch question %s: %v", slug, err)
+				return nil
+			}
+			mu.Lock()
+			out[slug] = q
+			mu.Unlock()
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return out
+}
+
+// itemSummary describes the solved problem, including its metadata when known.
+func itemSummary(a leetcode.Article, q *leetcode.Question) string {
+	if q == nil {
+		return fmt.Sprintf("Solution for %s (%s). Hits: %d", a.QuestionTitle, a.QuestionSlug, a.HitCount)
+	}
+
+	title := a.QuestionTitle
+	if q.FrontendID != "" {
+		title = q.FrontendID + ". " + title
+	}
+	summary := fmt.Sprintf("Solution for %s (%s)", title, a.QuestionSlug)
+	if q.Difficulty != "" {
+		summary += fmt.Sprintf(" [%s]", q.Difficulty)
+	}
+	summary += "."
+	if len(q.TopicTags) > 0 {
+		names := make([]string, 0, len(q.TopicTags))
+		for _, t := range q.TopicTags {
+			names = append(names, t.Name)
+		}
+		summary += " Topics: " + strings.Join(names, ", ") + "."
+	}
+	if q.ACRate > 0 {
+		summary += fmt.Sprintf(" Acceptance: %.1f%%.", q.ACRate)
+	}
+	return summary + fmt.Sprintf(" Hits: %d", a.HitCount)
+}
+
+// questionCategories lists the difficulty followed by the topic names.
+func questionCategories(q *leetcode.Question) []string {
+	if q == nil {
+		return nil
+	}
+	categories := make([]string, 0, len(q.TopicTags)+1)
+	if q.Difficulty != "" {
+		categories = append(categories, q.Difficulty)
+	}
+	for _, t := range q.TopicTags {
+		categories = append(categories, t.Name)
+	}
+	return categories
+}
+
+// authoredArticle pairs an article with the username it was fetched for.
+type authoredArticle struct {
+	leetcode.Article
+	Username string
+}
+
+// timedArticle is an authored article with its parsed publication time; OK
+// is false when the article carries no usable timestamp.
+type timedArticle struct {
+	Article   leetcode.Article
+	Username  string
+	CreatedAt time.Time
+	OK        bool
+}
+
+// userFetch is the outcome of loading one username's articles.
+type userFetch struct {
+	articles []leetcode.Article
+	// upstreamErr is the LeetCode error when the articles came from history
+	// instead.
+	upstreamErr error
+}
+
+// userArticles returns username's articles for the window in opts. With a
+// History configured they are read back from our own article history, which
+// also keeps the feed populated when LeetCode is unavailable.
+func (s UGCFeedService) userArticles(ctx context.Context, username string, opts leetcode.FetchOptions) (userFetch, error) {
+	articles, err := s.fetchUser(ctx, username, opts)
+	if s.History == nil {
+		return userFetch{articles: articles}, err
+	}
+
+	history, histErr := s.historyArticles(ctx, username, opts)
+	if err != nil {
+		if histErr != nil || len(history) == 0 {
+			return userFetch{}, err
+		}
+		log.Printf("warning: serving article history for %s after fetch error: %v", username, err)
+		return userFetch{articles: history, upstreamErr: err}, nil
+	}
+	if histErr != nil {
+		log.Printf("warning: failed to read article history for %s: %v", username, histErr)
+		return userFetch{articles: articles}, nil
+	}
+	return userFetch{articles: history}, nil
+}
+
+func (s UGCFeedService) historyArticles(ctx context.Context, username string, opts leetcode.FetchOptions) ([]leetcode.Article, error) {
+	var since *time.Time
+	if !opts.Since.IsZero() {
+		since = &opts.Since
+	}
+	stored, err := s.History.ListArticlesByUsername(ctx, username, opts.Limit, since)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]leetcode.Article, 0, len(stored))
+	for _, a := range stored {
+		out = append(out, a.Article)
+	}
+	return out, nil
+}
+
+// fetchUpstream fetches from LeetCode and records the result in History.
+func (s UGCFeedService) fetchUpstream(ctx context.Context, username string, opts leetcode.FetchOptions) ([]leetcode.Article, error) {
+	articles, err := leetcode.FetchAllUserSolutionArticles(ctx, s.LC, username, opts)
+	if err != nil {
+		return nil, err
+	}
+	if s.History != nil {
+		inserted, err := s.History.UpsertArticles(ctx, username, articles, time.Now())
+		if err != nil {
+			log.Printf("warning: failed to record article history for %s: %v", username, err)
+		} else if len(inserted) > 0 {
+			log.Printf("recorded %d new articles for %s", len(inserted), username)
+		}
+	}
+	return articles, nil
+}
+
+func (s UGCFeedService) fetchUser(ctx context.Context, username string, opts leetcode.FetchOptions) ([]leetcode.Article, error) {
+	if s.ArticleCache == nil || s.CacheTTL <= 0 {
+		return s.fetchUpstream(ctx, username, opts)
+	}
+
+	var since *time.Time
+	if !opts.Since.IsZero() {
+		since = &opts.Since
+	}
+
+	fetchOpts := opts
+	var cached *store.UserArticleCache
+	err := store.ErrNotFound
+	if !s.Refetch {
+		cached, err = s.ArticleCache.GetUserArticleCache(ctx, username)
+	}
+	if err == nil {
+		if cached.ExpiresAt.After(time.Now()) && cached.Covers(opts.Limit, since) {
+			return trimArticles(cached.Articles, opts), nil
+		}
+		// keep a wider count window another feed asked for instead of
+		// shrinking the entry and forcing that feed to refetch
+		if cached.Since == nil && since == nil && opts.Limit > 0 && cached.FetchLimit > opts.Limit {
+			fetchOpts.Limit = cached.FetchLimit
+		}
+	} else if !errors.Is(err, store.ErrNotFound) {
+		log.Printf("warning: failed to read article cache for %s: %v", username, err)
+	}
+
+	articles, err := s.fetchUpstream(ctx, username, fetchOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var fetchSince *time.Time
+	if !fetchOpts.Since.IsZero() {
+		fetchSince = &fetchOpts.Since
+	}
+	now := time.Now()
+	if err := s.ArticleCache.SetUserArticleCache(ctx, &store.UserArticleCache{
+		Username:   username,
+		FetchLimit: fetchOpts.Limit,
+		Since:      fetchSince,
+		Articles:   articles,
+		FetchedAt:  now,
+		ExpiresAt:  now.Add(s.CacheTTL),
+	}); err != nil {
+		log.Printf("warning: failed to cache articles for %s: %v", username, err)
+	}
+
+	return trimArticles(articles, opts), nil
+}
+
+// trimArticles narrows a most-recent-first article list to the window in opts.
+func trimArticles(articles []leetcode.Article, opts leetcode.FetchOptions) []leetcode.Article {
+	out := make([]leetcode.Article, 0, len(articles))
+	for _, a := range articles {
+		if !opts.Since.IsZero() {
+			if t, ok := a.CreatedTime(); ok && t.Before(opts.Since) {
+				break
+			}
+		}
+		if opts.Limit > 0 && len(out) >= opts.Limit {
+			break
+		}
+		out = append(out, a)
+	}
+	return out
 }
 
 func buildFeedTitle(usernames []string) string {
@@ -126,7 +683,11 @@ func buildFeedLink(usernames []string) string {
 	if len(usernames) == 0 {
 		return "https://leetcode.com/"
 	}
-	return fmt.Sprintf("https://leetcode.com/%s/", usernames[0])
+	return profileLink(usernames[0])
+}
+
+func profileLink(username string) string {
+	return fmt.Sprintf("https://leetcode.com/%s/", username)
 }
 
 func articleLink(a leetcode.Article) string {
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"leetcode-rss/internal/leetcode"
+	"leetcode-rss/internal/store"
+)
+
+var testEpoch = time.Date(2026, 1, 7, 12, 0, 0, 0, time.UTC)
+
+// testArticles returns n articles a day apart, the newest at testEpoch.
+func testArticles(n int) []leetcode.Article {
+	out := make([]leetcode.Article, n)
+	for i := range out {
+		out[i] = leetcode.Article{
+			TopicID:   i + 1,
+			UUID:      string(rune('a' + i)),
+			Slug:      "solution-" + string(rune('a'+i)),
+			CreatedAt: testEpoch.AddDate(0, 0, -i).Format(time.RFC3339Nano),
+		}
+	}
+	return out
+}
+
+// upstream serves articles for every username, or fails with status when
+// it is non-zero, and counts the requests it receives.
+type upstream struct {
+	articles []leetcode.Article
+	status   int
+	calls    atomic.Int32
+}
+
+func (u *upstream) client(t *testing.T) *leetcode.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u.calls.Add(1)
+		if u.status != 0 {
+			w.WriteHeader(u.status)
+			return
+		}
+		var env leetcode.UGCArticlesEnvelope
+		conn := &env.Data.UgcArticleUserSolutionArticles
+		conn.TotalNum = len(u.articles)
+		for _, a := range u.articles {
+			conn.Edges = append(conn.Edges, struct {
+				Node leetcode.Article `json:"node"`
+			}{a})
+		}
+		json.NewEncoder(w).Encode(env)
+	}))
+	t.Cleanup(srv.Close)
+	c := leetcode.New(srv.URL, "", "")
+	c.Retry = leetcode.RetryPolicy{MaxAttempts: 1}
+	return c
+}
+
+type memArticleCache struct {
+	entries map[string]*store.UserArticleCache
+}
+
+func (m *memArticleCache) GetUserArticleCache(_ context.Context, username string) (*store.UserArticleCache, error) {
+	if c, ok := m.entries[username]; ok {
+		return c, nil
+	}
+	return nil, store.ErrNotFound
+}
+
+func (m *memArticleCache) SetUserArticleCache(_ context.Context, c *store.UserArticleCache) error {
+	m.entries[c.Username] = c
+	return nil
+}
+
+type memHistory struct {
+	articles map[string][]leetcode.Article
+}
+
+func (m *memHistory) UpsertArticles(_ context.Context, username string, articles []leetcode.Article, _ time.Time) ([]string, error) {
+	m.articles[username] = articles
+	return nil, nil
+}
+
+func (m *memHistory) ListArticlesByUsername(_ context.Context, username string, limit int, _ *time.Time) ([]store.Article, error) {
+	var out []store.Article
+	for _, a := range m.articles[username] {
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+		out = append(out, store.Article{Article: a, Username: username})
+	}
+	return out, nil
+}
+
+func TestTrimArticles(t *testing.T) {
+	tests := []struct {
+		name string
+		opts leetcode.FetchOptions
+		want int
+	}{
+		{"unbounded", leetcode.FetchOptions{}, 5},
+		{"limit", leetcode.FetchOptions{Limit: 2}, 2},
+		{"limit beyond list", leetcode.FetchOptions{Limit: 9}, 5},
+		{"since", leetcode.FetchOptions{Since: testEpoch.AddDate(0, 0, -2)}, 3},
+		{"limit tighter than since", leetcode.FetchOptions{Limit: 1, Since: testEpoch.AddDate(0, 0, -2)}, 1},
+	}
+	for _, tt := range tests {
+		if got := trimArticles(testArticles(5), tt.opts); len(got) != tt.want {
+			t.Errorf("%s: trimArticles kept %d articles, want %d", tt.name, len(got), tt.want)
+		}
+	}
+}
+
+func TestFetchUserArticleCache(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		cached    *store.UserArticleCache
+		refetch   bool
+		opts      leetcode.FetchOptions
+		wantCalls int32
+		wantCount int
+		// wantLimit is the FetchLimit stored after the call.
+		wantLimit int
+	}{
+		{
+			name:      "miss fetches and stores",
+			opts:      leetcode.FetchOptions{Limit: 3},
+			wantCalls: 1,
+			wantCount: 3,
+			wantLimit: 3,
+		},
+		{
+			name:      "fresh covering entry is trimmed",
+			cached:    &store.UserArticleCache{FetchLimit: 5, Articles: testArticles(5), ExpiresAt: now.Add(time.Hour)},
+			opts:      leetcode.FetchOptions{Limit: 2},
+			wantCount: 2,
+			wantLimit: 5,
+		},
+		{
+			name:      "expired entry keeps its wider window",
+			cached:    &store.UserArticleCache{FetchLimit: 5, Articles: testArticles(5), ExpiresAt: now.Add(-time.Minute)},
+			opts:      leetcode.FetchOptions{Limit: 2},
+			wantCalls: 1,
+			wantCount: 2,
+			wantLimit: 5,
+		},
+		{
+			name:      "entry too narrow",
+			cached:    &store.UserArticleCache{FetchLimit: 2, Articles: testArticles(2), ExpiresAt: now.Add(time.Hour)},
+			opts:      leetcode.FetchOptions{Limit: 4},
+			wantCalls: 1,
+			wantCount: 4,
+			wantLimit: 4,
+		},
+		{
+			name:      "refetch skips a fresh entry",
+			cached:    &store.UserArticleCache{FetchLimit: 5, Articles: testArticles(5), ExpiresAt: now.Add(time.Hour)},
+			refetch:   true,
+			opts:      leetcode.FetchOptions{Limit: 2},
+			wantCalls: 1,
+			wantCount: 2,
+			wantLimit: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up := &upstream{articles: testArticles(6)}
+			cache := &memArticleCache{entries: map[string]*store.UserArticleCache{}}
+			if tt.cached != nil {
+				tt.cached.Username = "alice"
+				cache.entries["alice"] = tt.cached
+			}
+			svc := UGCFeedService{LC: up.client(t), ArticleCache: cache, CacheTTL: time.Hour, Refetch: tt.refetch}
+
+			got, err := svc.fetchUser(context.Background(), "alice", tt.opts)
+			if err != nil {
+				t.Fatalf("fetchUser: %v", err)
+			}
+			if len(got) != tt.wantCount {
+				t.Errorf("got %d articles, want %d", len(got), tt.wantCount)
+			}
+			if calls := up.calls.Load(); calls != tt.wantCalls {
+				t.Errorf("upstream called %d times, want %d", calls, tt.wantCalls)
+			}
+			if limit := cache.entries["alice"].FetchLimit; limit != tt.wantLimit {
+				t.Errorf("cached FetchLimit = %d, want %d", limit, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestUserArticlesHistoryFallback(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		history      []leetcode.Article
+		wantErr      bool
+		wantCount    int
+		wantFallback bool
+	}{
+		{"upstream ok reads history", 0, nil, false, 3, false},
+		{"upstream down serves history", http.StatusBadGateway, testArticles(2), false, 2, true},
+		{"upstream down without history", http.StatusBadGateway, nil, true, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up := &upstream{articles: testArticles(3), status: tt.status}
+			history := &memHistory{articles: map[string][]leetcode.Article{}}
+			if tt.history != nil {
+				history.articles["alice"] = tt.history
+			}
+			svc := UGCFeedService{LC: up.client(t), History: history}
+
+			got, err := svc.userArticles(context.Background(), "alice", leetcode.FetchOptions{Limit: 10})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("userArticles error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(got.articles) != tt.wantCount {
+				t.Errorf("got %d articles, want %d", len(got.articles), tt.wantCount)
+			}
+			if (got.upstreamErr != nil) != tt.wantFallback {
+				t.Errorf("upstreamErr = %v, want fallback %v", got.upstreamErr, tt.wantFallback)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"leetcode-rss/internal/netguard"
+	"leetcode-rss/internal/rss"
+	"leetcode-rss/internal/store"
+	"leetcode-rss/internal/websub"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxCallbackLength = 2048
+
+// POST /websub
+//
+// WebSub hub endpoint. Topics are the public feed URLs of this service; the
+// request is validated here and the subscriber's intent verified afterwards.
+func (h *PublicFeedHandlers) WebSubHub(c *gin.Context) {
+	if h.hub == nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	mode := c.PostForm("hub.mode")
+	if mode != websub.ModeSubscribe && mode != websub.ModeUnsubscribe {
+		AbortJSONError(c, http.StatusBadRequest, ErrorCodeValidation, "hub.mode must be subscribe or unsubscribe")
+		return
+	}
+
+	callback := strings.TrimSpace(c.PostForm("hub.callback"))
+	if err := ValidateCallbackURL(callback); err != nil {
+		AbortJSONError(c, http.StatusBadRequest, ErrorCodeValidation, "invalid hub.callback: "+err.Error())
+		return
+	}
+
+	req := websub.Request{
+		Mode:     mode,
+		Topic:    strings.TrimSpace(c.PostForm("hub.topic")),
+		Callback: callback,
+	}
+
+	if mode == websub.ModeSubscribe {
+		req.Secret = c.PostForm("hub.secret")
+		if len(req.Secret) > websub.MaxSecretLength {
+			AbortJSONError(c, http.StatusBadRequest, ErrorCodeValidation, fmt.Sprintf("hub.secret must be at most %d bytes", websub.MaxSecretLength))
+			return
+		}
+		if v := c.PostForm("hub.lease_seconds"); v != "" {
+			seconds, err := strconv.Atoi(v)
+			if err != nil || seconds <= 0 {
+				AbortJSONError(c, http.StatusBadRequest, ErrorCodeValidation, "hub.lease_seconds must be a positive integer")
+				return
+			}
+			req.Lease = time.Duration(seconds) * time.Second
+		}
+	}
+
+	feedID, secret, format, ok := parseFeedTopic(req.Topic)
+	if !ok {
+		AbortJSONError(c, http.StatusBadRequest, ErrorCodeValidation, "hub.topic is not a feed of this service")
+		return
+	}
+	feed, err := h.store.GetFeedByIDAndSecret(c.Request.Context(), feedID, secret)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			AbortJSONError(c, http.StatusNotFound, ErrorCodeNotFound, "feed not found")
+			return
+		}
+		log.Printf("error fetching feed %s: %v", feedID, err)
+		AbortJSONError(c, http.StatusInternalServerError, ErrorCodeInternal, "failed to fetch feed")
+		return
+	}
+	if !feed.Enabled && mode == websub.ModeSubscribe {
+		AbortJSONError(c, http.StatusNotFound, ErrorCodeNotFound, "feed not found")
+		return
+	}
+	req.FeedID = feed.ID
+	req.Format = string(format)
+
+	if err := h.hub.Accept(c.Request.Context(), req); err != nil {
+		switch {
+		case errors.Is(err, websub.ErrTooManySubscriptions):
+			AbortJSONError(c, http.StatusForbidden, ErrorCodeQuota, "subscription limit reached for hub.topic")
+		case errors.Is(err, websub.ErrBusy):
+			c.Header("Retry-After", "60")
+			AbortJSONError(c, http.StatusServiceUnavailable, ErrorCodeRateLimited, "too many pending subscription requests, try again later")
+		default:
+			log.Printf("error accepting websub request for feed %s: %v", feed.ID, err)
+			AbortJSONError(c, http.StatusInternalServerError, ErrorCodeInternal, "failed to accept subscription request")
+		}
+		return
+	}
+	c.Status(http.StatusAccepted)
+}
+
+// parseFeedTopic extracts the feed from a public feed URL ending in
+// /f/:feedID/:secret[.ext]. The host is not checked so topics keep working
+// behind proxies that rewrite it.
+func parseFeedTopic(topic string) (feedID, secret string, format rss.Format, ok bool) {
+	u, err := url.Parse(topic)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", "", "", false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	n := len(parts)
+	if n < 3 || parts[n-3] != "f" || !isValidUUID(parts[n-2]) {
+		return "", "", "", false
+	}
+	secret, format, ok = splitFeedExt(parts[n-1])
+	if !ok || secret == "" {
+		return "", "", "", false
+	}
+	return parts[n-2], secret, format, true
+}
+
+// ValidateCallbackURL checks that a URL the service will call back, such as
+// a WebSub callback or a webhook, is an absolute http(s) URL. Hosts that
+// are obviously internal are refused here; hostnames resolving to internal
+// addresses are refused when the callback is dialed.
+func ValidateCallbackURL(callback string) error {
+	if callback == "" {
+		return errors.New("required")
+	}
+	if len(callback) > maxCallbackLength {
+		return fmt.Errorf("must be at most %d characters", maxCallbackLength)
+	}
+	u, err := url.Parse(callback)
+	if err != nil {
+		return errors.New("not a URL")
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("must be an absolute http(s) URL")
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("must not point to this host")
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !netguard.Allowed(ip) {
+		return errors.New("must not point to a loopback, private or link-local address")
+	}
+	return nil
+}
@@ -3,7 +3,6 @@ package config
 import (
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 
@@ -13,18 +12,88 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig
-	LeetCode LeetCodeConfig
-	Cache    CacheConfig
-	Database DatabaseConfig
-	Clerk    ClerkConfig
-	Limits   LimitsConfig
+	Server    ServerConfig
+	LeetCode  LeetCodeConfig
+	Cache     CacheConfig
+	Database  DatabaseConfig
+	Clerk     ClerkConfig
+	Limits    LimitsConfig
+	Refresh   RefreshConfig
+	WebSub    WebSubConfig
+	Webhooks  WebhookConfig
+	Digest    DigestConfig
+	Readiness ReadinessConfig
+	Metrics   MetricsConfig
+	// StaticFeeds are the named feeds of STATIC_FEEDS_FILE, if any.
+	StaticFeeds []StaticFeed
+	// File is the config file the settings were layered over, if any.
+	File string
+
+	settings        []Setting
+	staticFeedsFile string
+}
+
+// MetricsConfig controls the Prometheus endpoint at /metrics.
+type MetricsConfig struct {
+	Enabled bool
+	Token   string // bearer token scrapers must send; empty leaves /metrics open
+}
+
+// ReadinessConfig controls when /readyz reports the LeetCode upstream as
+// degraded.
+type ReadinessConfig struct {
+	UpstreamWindow     time.Duration // how far back request outcomes are counted
+	MinUpstreamSuccess float64       // share of successful requests below which upstream is degraded
+}
+
+// DigestConfig controls email digests of new feed items. Digests are
+// enabled when SMTPHost is set.
+type DigestConfig struct {
+	SMTPHost      string
+	SMTPPort      int
+	SMTPUsername  string
+	SMTPPassword  string
+	SMTPTimeout   time.Duration
+	From          string
+	SendHour      int // hour of day, UTC, digests are sent at
+	MaxRecipients int
+	MaxItems      int
+	Interval      time.Duration
+}
+
+// WebhookConfig controls delivery of new feed items to owner webhooks.
+type WebhookConfig struct {
+	Enabled          bool
+	MaxPerFeed       int
+	DeliveryAttempts int
+	Timeout          time.Duration
+}
+
+// WebSubConfig controls the built-in WebSub hub that pushes feed updates to
+// subscribers.
+type WebSubConfig struct {
+	Enabled          bool
+	DefaultLease     time.Duration
+	MaxLease         time.Duration
+	MaxPerTopic      int
+	DeliveryAttempts int
+}
+
+// RefreshConfig controls the background worker that rebuilds feeds shortly
+// before their feed_cache entry expires.
+type RefreshConfig struct {
+	Enabled     bool
+	Interval    time.Duration
+	Lead        time.Duration
+	Concurrency int
+	MaxBackoff  time.Duration
 }
 
 type DatabaseConfig struct {
-	URL           string
-	PublicBaseURL string
-	RSSCacheTTL   time.Duration
+	URL             string
+	PublicBaseURL   string
+	RSSCacheTTL     time.Duration
+	ArticleCacheTTL time.Duration
 }
 
 type ClerkConfig struct {
@@ -32,75 +101,212 @@ type ClerkConfig struct {
 }
 
 type LimitsConfig struct {
-	MaxFeedsPerUser     int
-	MaxUsernamesPerFeed int
+	MaxFeedsPerUser           int
+	MaxUsernamesPerFeed       int
+	ManualRefreshInterval     time.Duration // minimum time between manual refreshes of a feed
+	MaxManualRefreshesPerHour int           // manual refreshes allowed per user per hour
+	MaxPreviewsPerHour        int           // feed previews allowed per user per hour
 }
 
 type ServerConfig struct {
 	Port           int
 	HandlerTimeout time.Duration
+	WatchInterval  time.Duration // how often config files are checked for changes; 0 disables
+	// ShutdownTimeout bounds how long a stopping server waits for requests
+	// and background work.
+	ShutdownTimeout time.Duration
 }
 
 type LeetCodeConfig struct {
 	Usernames          []string
 	MaxArticlesPerUser int
+	Since              time.Time
 	GraphQLEndpoint    string
 	Cookie             string
 	CSRF               string
+	RetryMaxAttempts   int
+	RetryBaseDelay     time.Duration
+	RetryMaxDelay      time.Duration
+	RequestsPerSecond  float64
+	Burst              int
+	MaxInFlight        int
+	QuestionCacheTTL   time.Duration
+	FailureNotice      string // how feeds report usernames that failed: none, note or item
 }
 
+// CacheConfig controls the in-memory cache of /leetcode.xml and the ad-hoc
+// feeds it serves for ?u= and ?n=.
 type CacheConfig struct {
-	TTL time.Duration
+	TTL              time.Duration
+	MaxEntries       int
+	AdHocEnabled     bool
+	AdHocMaxArticles int
 }
 
-func Load() (*Config, error) {
+// Load reads the configuration from the environment (and .env) layered
+// over the config file at path, or CONFIG_FILE when path is empty. Unlike a
+// plain env lookup, malformed values are not replaced by defaults: every
+// malformed value, unknown file key and invalid setting is listed in the
+// returned error.
+func Load(path string) (*Config, error) {
 	_ = godotenv.Load()
 
-	usernamesStr := os.Getenv("LEETCODE_USERNAMES")
-	if usernamesStr == "" {
-		username := os.Getenv("LEETCODE_USERNAME")
-		if username == "" {
-			return nil, fmt.Errorf("missing env LEETCODE_USERNAMES or LEETCODE_USERNAME")
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	src, err := newSource(path)
+	if err != nil {
+		return nil, err
+	}
+
+	port := src.int("PORT", 8080)
+	if port < 1 || port > 65535 {
+		src.invalid("PORT", "must be between 1 and 65535")
+	}
+
+	maxArticlesPerUser := src.boundedInt("LEETCODE_MAX_ARTICLES", 15, 1, 500)
+
+	staticFeedsFile := src.string("STATIC_FEEDS_FILE", "")
+	var staticFeeds []StaticFeed
+	if staticFeedsFile != "" {
+		var problems []string
+		staticFeeds, problems = loadStaticFeeds(staticFeedsFile, maxArticlesPerUser)
+		for _, p := range problems {
+			src.invalid("STATIC_FEEDS_FILE", "%s", p)
 		}
+	}
+
+	// with static feeds, /leetcode.xml may be left without default usernames
+	usernamesStr := src.string("LEETCODE_USERNAMES", "")
+	if username := src.string("LEETCODE_USERNAME", ""); usernamesStr == "" {
 		usernamesStr = username
 	}
+	var usernames []string
+	if usernamesStr != "" {
+		if usernames, err = parseUsernames(usernamesStr); err != nil {
+			src.invalid("LEETCODE_USERNAMES", "%v", err)
+		}
+	} else if staticFeedsFile == "" {
+		src.invalid("LEETCODE_USERNAMES", "missing; set LEETCODE_USERNAMES, LEETCODE_USERNAME or STATIC_FEEDS_FILE")
+	}
 
-	usernames, err := parseUsernames(usernamesStr)
+	since, err := ParseSince(src.string("LEETCODE_SINCE", ""))
 	if err != nil {
-		return nil, err
+		src.invalid("LEETCODE_SINCE", "%v", err)
+	}
+
+	minUpstreamSuccess := src.float("READY_MIN_UPSTREAM_SUCCESS", 0.5)
+	if minUpstreamSuccess < 0 || minUpstreamSuccess > 1 {
+		src.invalid("READY_MIN_UPSTREAM_SUCCESS", "must be between 0 and 1")
 	}
 
-	maxArticlesPerUser := clampInt(GetEnv("LEETCODE_MAX_ARTICLES", 15).(int), 1, 50)
+	failureNotice := strings.ToLower(strings.TrimSpace(src.string("FEED_FAILURE_NOTICE", "note")))
+	switch failureNotice {
+	case "none", "note", "item":
+	default:
+		src.invalid("FEED_FAILURE_NOTICE", "invalid value %q: expected none, note or item", failureNotice)
+	}
 
 	cfg := &Config{
 		Server: ServerConfig{
-			Port:           GetEnv("PORT", 8080).(int),
-			HandlerTimeout: GetEnv("HANDLER_TIMEOUT", 10*time.Second).(time.Duration),
+			Port:           port,
+			HandlerTimeout: src.positiveDuration("HANDLER_TIMEOUT", 10*time.Second),
+			WatchInterval:  src.duration("CONFIG_WATCH_INTERVAL", 5*time.Second),
+			// below the 30s most orchestrators allow before killing the process
+			ShutdownTimeout: src.duration("SHUTDOWN_TIMEOUT", 25*time.Second),
 		},
 		LeetCode: LeetCodeConfig{
 			Usernames:          usernames,
 			MaxArticlesPerUser: maxArticlesPerUser,
-			GraphQLEndpoint:    GetEnv("LEETCODE_GRAPHQL_ENDPOINT", "https://leetcode.com/graphql/").(string),
-			Cookie:             GetEnv("LEETCODE_COOKIE", "").(string),
-			CSRF:               GetEnv("LEETCODE_CSRF", "").(string),
+			Since:              since,
+			GraphQLEndpoint:    src.string("LEETCODE_GRAPHQL_ENDPOINT", "https://leetcode.com/graphql/"),
+			Cookie:             src.string("LEETCODE_COOKIE", ""),
+			CSRF:               src.string("LEETCODE_CSRF", ""),
+			RetryMaxAttempts:   src.boundedInt("LEETCODE_RETRY_ATTEMPTS", 3, 1, 10),
+			RetryBaseDelay:     src.duration("LEETCODE_RETRY_BASE_DELAY", 500*time.Millisecond),
+			RetryMaxDelay:      src.duration("LEETCODE_RETRY_MAX_DELAY", 10*time.Second),
+			RequestsPerSecond:  src.float("LEETCODE_RPS", 2.0),
+			Burst:              src.boundedInt("LEETCODE_BURST", 5, 1, 100),
+			MaxInFlight:        src.boundedInt("LEETCODE_MAX_IN_FLIGHT", 4, 1, 64),
+			QuestionCacheTTL:   src.duration("QUESTION_CACHE_TTL", 24*time.Hour),
+			FailureNotice:      failureNotice,
 		},
 		Cache: CacheConfig{
-			TTL: GetEnv("CACHE_TTL", 5*time.Minute).(time.Duration),
+			TTL:              src.duration("CACHE_TTL", 5*time.Minute),
+			MaxEntries:       src.boundedInt("CACHE_MAX_ENTRIES", 256, 1, 10000),
+			AdHocEnabled:     src.bool("ADHOC_FEEDS_ENABLED", false),
+			AdHocMaxArticles: src.boundedInt("ADHOC_MAX_ARTICLES", 50, 1, 500),
 		},
 		Database: DatabaseConfig{
-			URL:           GetEnv("DATABASE_URL", "file:./data/leetrss.db?_journal=WAL").(string),
-			PublicBaseURL: GetEnv("PUBLIC_BASE_URL", "http://localhost:8080").(string),
-			RSSCacheTTL:   GetEnv("RSS_CACHE_TTL", 5*time.Minute).(time.Duration),
+			URL:             src.string("DATABASE_URL", "file:./data/leetrss.db?_journal=WAL"),
+			PublicBaseURL:   src.string("PUBLIC_BASE_URL", "http://localhost:8080"),
+			RSSCacheTTL:     src.positiveDuration("RSS_CACHE_TTL", 5*time.Minute),
+			ArticleCacheTTL: src.duration("ARTICLE_CACHE_TTL", 5*time.Minute),
 		},
 		Clerk: ClerkConfig{
-			SecretKey: GetEnv("CLERK_SECRET_KEY", "").(string),
+			SecretKey: src.string("CLERK_SECRET_KEY", ""),
 		},
 		Limits: LimitsConfig{
-			MaxFeedsPerUser:     clampInt(GetEnv("MAX_FEEDS_PER_USER", 3).(int), 1, 100),
-			MaxUsernamesPerFeed: clampInt(GetEnv("MAX_USERNAMES_PER_FEED", 3).(int), 1, 20),
+			MaxFeedsPerUser:           src.boundedInt("MAX_FEEDS_PER_USER", 3, 1, 100),
+			MaxUsernamesPerFeed:       src.boundedInt("MAX_USERNAMES_PER_FEED", 3, 1, 20),
+			ManualRefreshInterval:     src.duration("MANUAL_REFRESH_INTERVAL", time.Minute),
+			MaxManualRefreshesPerHour: src.boundedInt("MAX_MANUAL_REFRESHES_PER_HOUR", 20, 1, 1000),
+			MaxPreviewsPerHour:        src.boundedInt("MAX_PREVIEWS_PER_HOUR", 30, 1, 1000),
+		},
+		Refresh: RefreshConfig{
+			Enabled:     src.bool("BACKGROUND_REFRESH", true),
+			Interval:    src.duration("REFRESH_INTERVAL", 30*time.Second),
+			Lead:        src.duration("REFRESH_LEAD", time.Minute),
+			Concurrency: src.boundedInt("REFRESH_CONCURRENCY", 2, 1, 16),
+			MaxBackoff:  src.duration("REFRESH_MAX_BACKOFF", time.Hour),
+		},
+		WebSub: WebSubConfig{
+			Enabled:          src.bool("WEBSUB_ENABLED", true),
+			DefaultLease:     src.duration("WEBSUB_DEFAULT_LEASE", 10*24*time.Hour),
+			MaxLease:         src.duration("WEBSUB_MAX_LEASE", 30*24*time.Hour),
+			MaxPerTopic:      src.boundedInt("WEBSUB_MAX_SUBSCRIPTIONS_PER_TOPIC", 100, 1, 10000),
+			DeliveryAttempts: src.boundedInt("WEBSUB_DELIVERY_ATTEMPTS", 3, 1, 10),
+		},
+		Webhooks: WebhookConfig{
+			Enabled:          src.bool("WEBHOOKS_ENABLED", true),
+			MaxPerFeed:       src.boundedInt("MAX_WEBHOOKS_PER_FEED", 5, 1, 50),
+			DeliveryAttempts: src.boundedInt("WEBHOOK_DELIVERY_ATTEMPTS", 3, 1, 10),
+			Timeout:          src.duration("WEBHOOK_TIMEOUT", 10*time.Second),
+		},
+		Digest: DigestConfig{
+			SMTPHost:      src.string("SMTP_HOST", ""),
+			SMTPPort:      src.int("SMTP_PORT", 587),
+			SMTPUsername:  src.string("SMTP_USERNAME", ""),
+			SMTPPassword:  src.string("SMTP_PASSWORD", ""),
+			SMTPTimeout:   src.duration("SMTP_TIMEOUT", 30*time.Second),
+			From:          src.string("DIGEST_FROM", ""),
+			SendHour:      src.boundedInt("DIGEST_SEND_HOUR", 8, 0, 23),
+			MaxRecipients: src.boundedInt("MAX_DIGEST_RECIPIENTS", 5, 1, 50),
+			MaxItems:      src.boundedInt("DIGEST_MAX_ITEMS", 50, 1, 200),
+			Interval:      src.duration("DIGEST_INTERVAL", time.Minute),
+		},
+		Readiness: ReadinessConfig{
+			UpstreamWindow:     src.duration("READY_UPSTREAM_WINDOW", 5*time.Minute),
+			MinUpstreamSuccess: minUpstreamSuccess,
+		},
+		Metrics: MetricsConfig{
+			Enabled: src.bool("METRICS_ENABLED", true),
+			Token:   src.string("METRICS_TOKEN", ""),
 		},
+		StaticFeeds: staticFeeds,
+		File:        path,
 	}
 
+	if p := cfg.Digest.SMTPPort; p < 1 || p > 65535 {
+		src.invalid("SMTP_PORT", "must be between 1 and 65535")
+	}
+
+	src.unknownKeys()
+	if len(src.problems) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n  %s", strings.Join(src.problems, "\n  "))
+	}
+	cfg.settings = src.settings
+	cfg.staticFeedsFile = staticFeedsFile
 	return cfg, nil
 }
 
@@ -125,49 +331,26 @@ func parseUsernames(s string) ([]string, error) {
 		result = append(result, trimmed)
 	}
 	if len(invalid) > 0 {
-		return nil, fmt.Errorf("invalid username(s) in LEETCODE_USERNAMES: %s", strings.Join(invalid, ", "))
+		return nil, fmt.Errorf("invalid username(s): %s", strings.Join(invalid, ", "))
 	}
 	if len(result) == 0 {
-		return nil, fmt.Errorf("no valid usernames found in LEETCODE_USERNAMES")
+		return nil, fmt.Errorf("no valid usernames found")
 	}
 	return result, nil
 }
 
-func GetEnv(key string, defaultValue any) any {
-	value, exists := os.LookupEnv(key)
-	if !exists {
-		return defaultValue
+// ParseSince accepts an RFC3339 timestamp or a YYYY-MM-DD date. An empty
+// string yields the zero time.
+func ParseSince(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
 	}
-
-	switch def := defaultValue.(type) {
-	case string:
-		return value
-	case int:
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
-		}
-		return def
-	case bool:
-		if boolValue, err := strconv.ParseBool(value); err == nil {
-			return boolValue
-		}
-		return def
-	case time.Duration:
-		if durationValue, err := time.ParseDuration(value); err == nil {
-			return durationValue
-		}
-		return def
-	default:
-		panic(fmt.Sprintf("unsupported type %T", defaultValue))
-	}
-}
-
-func clampInt(v, min, max int) int {
-	if v < min {
-		return min
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
 	}
-	if v > max {
-		return max
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
 	}
-	return v
+	return time.Time{}, fmt.Errorf("invalid date %q: expected RFC3339 timestamp or YYYY-MM-DD", s)
 }
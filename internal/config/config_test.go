@@ -0,0 +1,43 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadReportsOutOfRangeValues(t *testing.T) {
+	tests := []struct {
+		key, value string
+		want       string // problem reported; empty means the value loads
+	}{
+		{"LEETCODE_BURST", "0", "LEETCODE_BURST: must be between 1 and 100 (env)"},
+		{"LEETCODE_BURST", "100", ""},
+		{"MAX_FEEDS_PER_USER", "0", "MAX_FEEDS_PER_USER: must be between 1 and 100 (env)"},
+		{"DIGEST_SEND_HOUR", "0", ""},
+		{"DIGEST_SEND_HOUR", "24", "DIGEST_SEND_HOUR: must be between 0 and 23 (env)"},
+		{"HANDLER_TIMEOUT", "0s", "HANDLER_TIMEOUT: must be positive (env)"},
+		{"HANDLER_TIMEOUT", "-1s", "HANDLER_TIMEOUT: must not be negative (env)"},
+		{"RSS_CACHE_TTL", "0", "RSS_CACHE_TTL: must be positive (env)"},
+		{"SMTP_PORT", "0", "SMTP_PORT: must be between 1 and 65535 (env)"},
+		{"SMTP_PORT", "65536", "SMTP_PORT: must be between 1 and 65535 (env)"},
+		{"SMTP_PORT", "465", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			t.Setenv("CONFIG_FILE", "")
+			t.Setenv("LEETCODE_USERNAMES", "alice")
+			t.Setenv(tt.key, tt.value)
+
+			_, err := Load("")
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Load error = %v, want it to report %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"text/tabwriter"
+)
+
+// reloadable lists the settings Merge applies to a running server: TTLs,
+// limits, readiness thresholds, the feeds served without a database and
+// upstream and metrics credentials.
+// Every other setting is read once at startup.
+var reloadable = map[string]bool{
+	"HANDLER_TIMEOUT":               true,
+	"LEETCODE_USERNAMES":            true,
+	"LEETCODE_USERNAME":             true,
+	"LEETCODE_MAX_ARTICLES":         true,
+	"LEETCODE_SINCE":                true,
+	"LEETCODE_COOKIE":               true,
+	"LEETCODE_CSRF":                 true,
+	"FEED_FAILURE_NOTICE":           true,
+	"STATIC_FEEDS_FILE":             true,
+	"CACHE_TTL":                     true,
+	"CACHE_MAX_ENTRIES":             true,
+	"ADHOC_FEEDS_ENABLED":           true,
+	"ADHOC_MAX_ARTICLES":            true,
+	"RSS_CACHE_TTL":                 true,
+	"ARTICLE_CACHE_TTL":             true,
+	"MAX_FEEDS_PER_USER":            true,
+	"MAX_USERNAMES_PER_FEED":        true,
+	"MANUAL_REFRESH_INTERVAL":       true,
+	"MAX_MANUAL_REFRESHES_PER_HOUR": true,
+	"MAX_PREVIEWS_PER_HOUR":         true,
+	"MAX_WEBHOOKS_PER_FEED":         true,
+	"MAX_DIGEST_RECIPIENTS":         true,
+	"REFRESH_LEAD":                  true,
+	"READY_UPSTREAM_WINDOW":         true,
+	"READY_MIN_UPSTREAM_SUCCESS":    true,
+	"METRICS_TOKEN":                 true,
+}
+
+// Merge returns current with the reloadable settings of next applied, the
+// reloadable keys whose value changed, and the changed keys that only take
+// effect after a restart.
+func Merge(current, next *Config) (merged *Config, applied, restart []string) {
+	m := *current
+	m.Server.HandlerTimeout = next.Server.HandlerTimeout
+	m.LeetCode.Usernames = next.LeetCode.Usernames
+	m.LeetCode.MaxArticlesPerUser = next.LeetCode.MaxArticlesPerUser
+	m.LeetCode.Since = next.LeetCode.Since
+	m.LeetCode.Cookie = next.LeetCode.Cookie
+	m.LeetCode.CSRF = next.LeetCode.CSRF
+	m.LeetCode.FailureNotice = next.LeetCode.FailureNotice
+	m.StaticFeeds = next.StaticFeeds
+	m.staticFeedsFile = next.staticFeedsFile
+	m.Cache = next.Cache
+	m.Database.RSSCacheTTL = next.Database.RSSCacheTTL
+	m.Database.ArticleCacheTTL = next.Database.ArticleCacheTTL
+	m.Limits = next.Limits
+	m.Webhooks.MaxPerFeed = next.Webhooks.MaxPerFeed
+	m.Digest.MaxRecipients = next.Digest.MaxRecipients
+	m.Refresh.Lead = next.Refresh.Lead
+	m.Readiness = next.Readiness
+	m.Metrics.Token = next.Metrics.Token
+
+	previous := make(map[string]Setting, len(current.settings))
+	for _, s := range current.settings {
+		previous[s.Key] = s
+	}
+	m.settings = make([]Setting, 0, len(next.settings))
+	for _, s := range next.settings {
+		old, ok := previous[s.Key]
+		if ok && old.Value != s.Value {
+			if reloadable[s.Key] {
+				applied = append(applied, s.Key)
+			} else {
+				restart = append(restart, s.Key)
+				s = old
+			}
+		}
+		m.settings = append(m.settings, s)
+	}
+	return &m, applied, restart
+}
+
+// Files returns the files the configuration was read from, which a running
+// server watches for changes.
+func (c *Config) Files() []string {
+	var files []string
+	if c.File != "" {
+		files = append(files, c.File)
+	}
+	if c.staticFeedsFile != "" {
+		files = append(files, c.staticFeedsFile)
+	}
+	return files
+}
+
+// secretKeys are the settings Print never shows.
+var secretKeys = map[string]bool{
+	"LEETCODE_COOKIE":  true,
+	"LEETCODE_CSRF":    true,
+	"CLERK_SECRET_KEY": true,
+	"SMTP_PASSWORD":    true,
+	"METRICS_TOKEN":    true,
+}
+
+// authTokenRe matches the credential a TursoDB DATABASE_URL may carry.
+var authTokenRe = regexp.MustCompile(`(?i)(authToken=)[^&]+`)
+
+// Print writes the effective settings in .env syntax with the source of
+// each one, secrets redacted.
+func (c *Config) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if c.File != "" {
+		fmt.Fprintf(tw, "# environment layered over %s\n", c.File)
+	} else {
+		fmt.Fprintln(tw, "# environment only, no config file")
+	}
+	for _, s := range c.settings {
+		fmt.Fprintf(tw, "%s=%s\t# %s\n", s.Key, redact(s.Key, s.Value), s.Source)
+	}
+	return tw.Flush()
+}
+
+func redact(key, value string) string {
+	if value == "" {
+		return value
+	}
+	if secretKeys[key] {
+		return "<redacted>"
+	}
+	if key == "DATABASE_URL" {
+		return authTokenRe.ReplaceAllString(value, "${1}<redacted>")
+	}
+	return value
+}
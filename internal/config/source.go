@@ -0,0 +1,248 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Setting sources, from highest to lowest precedence.
+const (
+	sourceEnv     = "env"
+	sourceFile    = "file"
+	sourceDefault = "default"
+)
+
+// Setting is the effective value of one configuration key.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string // env, file or default
+}
+
+// source resolves each key from the environment, then the config file,
+// then the default. Every value that does not parse is collected instead of
+// falling back to the default, so Load can report all of them at once.
+type source struct {
+	file     map[string]string // upper-case key -> raw value
+	settings []Setting
+	index    map[string]int // key -> position in settings
+	problems []string
+}
+
+// newSource reads the config file at path, if any. The file is YAML or
+// TOML by extension and holds a flat table of the environment variable
+// names, in any case, e.g. "cache_ttl: 2m". Lists are joined with commas.
+func newSource(path string) (*source, error) {
+	s := &source{file: make(map[string]string), index: make(map[string]int)}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	raw := make(map[string]any)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.NewDecoder(bytes.NewReader(data)).Decode(&raw)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported extension %q (expected .yaml, .yml or .toml)", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	for key, v := range raw {
+		key = strings.ToUpper(key)
+		value, ok := fileValue(v)
+		if !ok {
+			s.problems = append(s.problems, fmt.Sprintf("%s: must be a string, number, boolean or list of them (file)", key))
+			continue
+		}
+		s.file[key] = value
+	}
+	return s, nil
+}
+
+// fileValue formats a decoded scalar or list as the equivalent env value.
+func fileValue(v any) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", true
+	case string:
+		return v, true
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprint(v), true
+	case time.Time:
+		return v.Format(time.RFC3339), true
+	case toml.LocalDate:
+		return v.String(), true
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, e := range v {
+			s, ok := fileValue(e)
+			if !ok {
+				return "", false
+			}
+			if _, nested := e.([]any); nested {
+				return "", false
+			}
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, ","), true
+	default:
+		return "", false
+	}
+}
+
+// lookup returns the raw value of key and where it came from. Empty
+// environment variables count as unset, like the blanks in .env.example.
+func (s *source) lookup(key string) (string, string, bool) {
+	if v := os.Getenv(key); v != "" {
+		return v, sourceEnv, true
+	}
+	if v, ok := s.file[key]; ok {
+		return v, sourceFile, true
+	}
+	return "", sourceDefault, false
+}
+
+func (s *source) record(key, value, from string) {
+	if i, ok := s.index[key]; ok {
+		s.settings[i] = Setting{Key: key, Value: value, Source: from}
+		return
+	}
+	s.index[key] = len(s.settings)
+	s.settings = append(s.settings, Setting{Key: key, Value: value, Source: from})
+}
+
+// invalid reports a problem with key's value, naming where the value came
+// from unless it is the default.
+func (s *source) invalid(key, format string, args ...any) {
+	problem := key + ": " + fmt.Sprintf(format, args...)
+	if i, ok := s.index[key]; ok && s.settings[i].Source != sourceDefault {
+		problem += " (" + s.settings[i].Source + ")"
+	}
+	s.problems = append(s.problems, problem)
+}
+
+func (s *source) string(key, def string) string {
+	v, from, ok := s.lookup(key)
+	if !ok {
+		v = def
+	}
+	s.record(key, v, from)
+	return v
+}
+
+func (s *source) int(key string, def int) int {
+	raw, from, ok := s.lookup(key)
+	s.record(key, strconv.Itoa(def), from)
+	if !ok {
+		return def
+	}
+	v, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		s.invalid(key, "invalid integer %q", raw)
+		return def
+	}
+	s.record(key, strconv.Itoa(v), from)
+	return v
+}
+
+// boundedInt is int for settings limited to [min, max], as documented for
+// most limits. Values outside are reported rather than clamped, so a typo
+// such as 0 does not quietly become another limit.
+func (s *source) boundedInt(key string, def, min, max int) int {
+	v := s.int(key, def)
+	if v < min || v > max {
+		s.invalid(key, "must be between %d and %d", min, max)
+	}
+	return v
+}
+
+func (s *source) float(key string, def float64) float64 {
+	raw, from, ok := s.lookup(key)
+	s.record(key, strconv.FormatFloat(def, 'g', -1, 64), from)
+	if !ok {
+		return def
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil {
+		s.invalid(key, "invalid number %q", raw)
+		return def
+	}
+	s.record(key, strconv.FormatFloat(v, 'g', -1, 64), from)
+	return v
+}
+
+func (s *source) bool(key string, def bool) bool {
+	raw, from, ok := s.lookup(key)
+	s.record(key, strconv.FormatBool(def), from)
+	if !ok {
+		return def
+	}
+	v, err := strconv.ParseBool(strings.TrimSpace(raw))
+	if err != nil {
+		s.invalid(key, "invalid boolean %q", raw)
+		return def
+	}
+	s.record(key, strconv.FormatBool(v), from)
+	return v
+}
+
+func (s *source) duration(key string, def time.Duration) time.Duration {
+	raw, from, ok := s.lookup(key)
+	s.record(key, def.String(), from)
+	if !ok {
+		return def
+	}
+	v, err := time.ParseDuration(strings.TrimSpace(raw))
+	if err != nil {
+		s.invalid(key, "invalid duration %q", raw)
+		return def
+	}
+	if v < 0 {
+		s.invalid(key, "must not be negative")
+		return def
+	}
+	s.record(key, v.String(), from)
+	return v
+}
+
+// positiveDuration is duration for settings that cannot be zero, such as
+// timeouts and TTLs.
+func (s *source) positiveDuration(key string, def time.Duration) time.Duration {
+	v := s.duration(key, def)
+	if v == 0 {
+		s.invalid(key, "must be positive")
+	}
+	return v
+}
+
+// unknownKeys reports the file keys no setting read, which are usually
+// typos that would otherwise be silently ignored.
+func (s *source) unknownKeys() {
+	var unknown []string
+	for key := range s.file {
+		if _, ok := s.index[key]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		s.problems = append(s.problems, fmt.Sprintf("%s: unknown key (file)", key))
+	}
+}
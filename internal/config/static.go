@@ -0,0 +1,196 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+
+	"leetcode-rss/internal/leetcode"
+	"leetcode-rss/internal/rss"
+	"leetcode-rss/internal/store"
+)
+
+// StaticFeed is a named feed defined in STATIC_FEEDS_FILE and served at
+// /static/<name>.xml (and .atom, .json) without a database.
+type StaticFeed struct {
+	Name      string
+	Title     string // replaces the title built from the usernames when set
+	Usernames []string
+	Items     int        // articles per username
+	Format    rss.Format // served at /static/<name> without an extension
+	Filter    *store.FeedFilter
+}
+
+var staticFeedNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// staticFeedsFile is the layout of STATIC_FEEDS_FILE, in YAML:
+//
+//	feeds:
+//	  - name: team
+//	    title: Team solutions
+//	    usernames: [alice, bob]
+//	    items: 20
+//	    format: atom
+//	    filter:
+//	      difficulties: [Medium, Hard]
+//
+// or the equivalent TOML with a [[feeds]] table per feed.
+type staticFeedsFile struct {
+	Feeds []staticFeedEntry `yaml:"feeds" toml:"feeds"`
+}
+
+type staticFeedEntry struct {
+	Name      string             `yaml:"name" toml:"name"`
+	Title     string             `yaml:"title" toml:"title"`
+	Usernames []string           `yaml:"usernames" toml:"usernames"`
+	Items     int                `yaml:"items" toml:"items"`
+	Format    string             `yaml:"format" toml:"format"`
+	Filter    *staticFilterEntry `yaml:"filter" toml:"filter"`
+}
+
+type staticFilterEntry struct {
+	IncludeTitle    string     `yaml:"include_title" toml:"include_title"`
+	ExcludeTitle    string     `yaml:"exclude_title" toml:"exclude_title"`
+	QuestionSlugs   []string   `yaml:"question_slugs" toml:"question_slugs"`
+	MinHitCount     int        `yaml:"min_hit_count" toml:"min_hit_count"`
+	PublishedAfter  filterTime `yaml:"published_after" toml:"published_after"`
+	PublishedBefore filterTime `yaml:"published_before" toml:"published_before"`
+	Difficulties    []string   `yaml:"difficulties" toml:"difficulties"`
+}
+
+// filterTime accepts the same values as LEETCODE_SINCE. It unmarshals from
+// text so bare YAML timestamps and TOML dates need no quoting.
+type filterTime struct {
+	t *time.Time
+}
+
+func (f *filterTime) UnmarshalText(b []byte) error {
+	t, err := ParseSince(string(b))
+	if err != nil {
+		return err
+	}
+	if !t.IsZero() {
+		t = t.UTC()
+		f.t = &t
+	}
+	return nil
+}
+
+// loadStaticFeeds reads the feeds defined in path, which is YAML or TOML by
+// extension, and returns every problem found. Items defaults to
+// defaultItems. Filters are only decoded here; their patterns and values
+// are checked by the caller.
+func loadStaticFeeds(path string, defaultItems int) ([]StaticFeed, []string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, []string{err.Error()}
+	}
+
+	var file staticFeedsFile
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalWithOptions(data, &file, yaml.DisallowUnknownField())
+	case ".toml":
+		dec := toml.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&file)
+	default:
+		return nil, []string{fmt.Sprintf("unsupported extension %q (expected .yaml, .yml or .toml)", ext)}
+	}
+	if err != nil {
+		return nil, []string{fmt.Sprintf("parse %s: %v", path, err)}
+	}
+	if len(file.Feeds) == 0 {
+		return nil, []string{fmt.Sprintf("%s defines no feeds", path)}
+	}
+
+	feeds := make([]StaticFeed, 0, len(file.Feeds))
+	seen := make(map[string]struct{}, len(file.Feeds))
+	var problems []string
+	for i, entry := range file.Feeds {
+		feed, errs := entry.staticFeed(defaultItems)
+		label := fmt.Sprintf("feed %d", i+1)
+		if entry.Name != "" {
+			label = fmt.Sprintf("feed %q", entry.Name)
+		}
+		for _, e := range errs {
+			problems = append(problems, label+": "+e)
+		}
+		if _, dup := seen[feed.Name]; dup {
+			problems = append(problems, label+": duplicate name")
+		}
+		seen[feed.Name] = struct{}{}
+		feeds = append(feeds, feed)
+	}
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	return feeds, nil
+}
+
+// staticFeed converts e, returning every invalid field.
+func (e staticFeedEntry) staticFeed(defaultItems int) (StaticFeed, []string) {
+	var problems []string
+
+	feed := StaticFeed{
+		Name:   strings.TrimSpace(e.Name),
+		Title:  strings.TrimSpace(e.Title),
+		Items:  defaultItems,
+		Format: rss.FormatRSS,
+	}
+	if !staticFeedNameRe.MatchString(feed.Name) {
+		problems = append(problems, "name must be 1-64 lowercase letters, digits, '-' or '_'")
+	}
+
+	seen := make(map[string]struct{}, len(e.Usernames))
+	for _, u := range e.Usernames {
+		u = strings.TrimSpace(u)
+		if err := leetcode.ValidateUsername(u); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid username %q", u))
+			continue
+		}
+		if _, dup := seen[u]; !dup {
+			seen[u] = struct{}{}
+			feed.Usernames = append(feed.Usernames, u)
+		}
+	}
+	if len(e.Usernames) == 0 {
+		problems = append(problems, "at least one username is required")
+	}
+
+	if e.Items != 0 {
+		if e.Items < 1 || e.Items > 500 {
+			problems = append(problems, "items must be between 1 and 500")
+		}
+		feed.Items = e.Items
+	}
+
+	if e.Format != "" {
+		format := rss.Format(strings.ToLower(strings.TrimSpace(e.Format)))
+		if format != rss.FormatRSS && format != rss.FormatAtom && format != rss.FormatJSON {
+			problems = append(problems, fmt.Sprintf("unknown format %q (expected rss, atom or json)", e.Format))
+		}
+		feed.Format = format
+	}
+
+	if f := e.Filter; f != nil {
+		feed.Filter = &store.FeedFilter{
+			IncludeTitle:    f.IncludeTitle,
+			ExcludeTitle:    f.ExcludeTitle,
+			QuestionSlugs:   f.QuestionSlugs,
+			MinHitCount:     f.MinHitCount,
+			PublishedAfter:  f.PublishedAfter.t,
+			PublishedBefore: f.PublishedBefore.t,
+			Difficulties:    f.Difficulties,
+		}
+	}
+
+	return feed, problems
+}
@@ -0,0 +1,303 @@
+// Package digest emails feed owners' chosen recipients a periodic summary
+// of the items their feeds gained since the previous digest.
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"leetcode-rss/internal/rss"
+	"leetcode-rss/internal/store"
+)
+
+// Digest frequencies.
+const (
+	FrequencyDaily  = "daily"
+	FrequencyWeekly = "weekly" // sent on Mondays
+)
+
+const (
+	// dueBatchSize bounds how many due digests a single scan picks up.
+	dueBatchSize = 50
+	// retryDelay is how long a digest that could not be built or sent waits
+	// before it is tried again.
+	retryDelay = time.Hour
+)
+
+// Store is the subset of store.Store used to schedule and address digests.
+type Store interface {
+	GetFeedByID(ctx context.Context, id string) (*store.Feed, error)
+	ListDigestsDue(ctx context.Context, now time.Time, limit int) ([]store.Digest, error)
+	UpdateDigestState(ctx context.Context, digest *store.Digest) error
+	ListDigestRecipients(ctx context.Context, feedID string) ([]store.DigestRecipient, error)
+	ListSentDigestItems(ctx context.Context, feedID string) (map[string]map[string]bool, error)
+	RecordDigestItems(ctx context.Context, feedID, email string, guids []string, sentAt, pruneBefore time.Time) error
+}
+
+// BuildFunc builds the current items of a feed, newest first.
+type BuildFunc func(ctx context.Context, feed *store.Feed) ([]rss.Item, error)
+
+// Options tunes a Scheduler. Zero values fall back to the defaults noted.
+type Options struct {
+	Interval     time.Duration // how often due digests are looked for (1m)
+	SendHour     int           // hour of day, UTC, digests are sent at
+	MaxItems     int           // items listed per email (50)
+	BuildTimeout time.Duration // bound on building one feed (30s)
+	// BaseURL is the public URL of the service, used in confirmation and
+	// unsubscribe links.
+	BaseURL string
+}
+
+// Scheduler sends digests when they are due.
+type Scheduler struct {
+	store  Store
+	build  BuildFunc
+	mailer *Mailer
+	opts   Options
+}
+
+func NewScheduler(s Store, build BuildFunc, mailer *Mailer, opts Options) *Scheduler {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	if opts.SendHour < 0 || opts.SendHour > 23 {
+		opts.SendHour = 0
+	}
+	if opts.MaxItems < 1 {
+		opts.MaxItems = 50
+	}
+	if opts.BuildTimeout <= 0 {
+		opts.BuildTimeout = 30 * time.Second
+	}
+	return &Scheduler{store: s, build: build, mailer: mailer, opts: opts}
+}
+
+// NextRun returns the first send time of a digest of the given frequency
+// strictly after t.
+func (s *Scheduler) NextRun(frequency string, t time.Time) time.Time {
+	t = t.UTC()
+	next := time.Date(t.Year(), t.Month(), t.Day(), s.opts.SendHour, 0, 0, 0, time.UTC)
+	if !next.After(t) {
+		next = next.AddDate(0, 0, 1)
+	}
+	if frequency == FrequencyWeekly {
+		next = next.AddDate(0, 0, (int(time.Monday)-int(next.Weekday())+7)%7)
+	}
+	return next
+}
+
+// UnsubscribeURL is the link that opts out the recipient holding token.
+func (s *Scheduler) UnsubscribeURL(token string) string {
+	return s.opts.BaseURL + "/digest/unsubscribe?token=" + url.QueryEscape(token)
+}
+
+// ConfirmURL is the link that confirms the address of the recipient
+// holding token.
+func (s *Scheduler) ConfirmURL(token string) string {
+	return s.opts.BaseURL + "/digest/confirm?token=" + url.QueryEscape(token)
+}
+
+// SendConfirmation asks a new recipient of a feed's digest to confirm their
+// address. No digest is sent to them until they do, so feed owners cannot
+// mail arbitrary addresses through the service.
+func (s *Scheduler) SendConfirmation(feedName, frequency string, r store.DigestRecipient) error {
+	data := newConfirmData(feedName, frequency)
+	data.ConfirmURL = s.ConfirmURL(r.UnsubscribeToken)
+	data.UnsubscribeURL = s.UnsubscribeURL(r.UnsubscribeToken)
+	text, html, err := renderConfirmation(data)
+	if err != nil {
+		return err
+	}
+	return s.mailer.send(message{
+		To:          r.Email,
+		Subject:     fmt.Sprintf("Confirm your %s digest: %s", strings.ToLower(data.Period), feedName),
+		Text:        text,
+		HTML:        html,
+		Unsubscribe: data.UnsubscribeURL,
+	})
+}
+
+// Run sends due digests until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	log.Printf("email digests enabled (interval=%s send_hour=%02d:00 UTC)", s.opts.Interval, s.opts.SendHour)
+
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+
+	for ctx.Err() == nil {
+		s.scan(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) scan(ctx context.Context) {
+	digests, err := s.store.ListDigestsDue(ctx, time.Now(), dueBatchSize)
+	if err != nil {
+		log.Printf("digest: failed to list due digests: %v", err)
+		return
+	}
+	for i := range digests {
+		if ctx.Err() != nil {
+			return
+		}
+		// a digest being sent is finished and recorded even when ctx ends,
+		// so its recipients do not get it again after a restart
+		s.run(context.WithoutCancel(ctx), &digests[i])
+	}
+}
+
+// run sends one due digest and records the outcome. The items covered are
+// those published after the previous digest's cutoff, or within the last
+// period for a first digest, that no digest sent the recipient before.
+// Later digests look one more period back than the cutoff: the feed may
+// have been built from articles fetched before it, and items reaching the
+// feed late are still sent once.
+func (s *Scheduler) run(ctx context.Context, d *store.Digest) {
+	now := time.Now()
+	since := periodBefore(d.Frequency, d.NextRunAt)
+	floor := since
+	if d.CoveredUntil != nil {
+		since = *d.CoveredUntil
+		floor = periodBefore(d.Frequency, since)
+	}
+
+	sent, err := s.send(ctx, d, since, floor, now)
+	d.UpdatedAt = now
+	if err != nil {
+		log.Printf("digest: feed %s failed, retrying in %s: %v", d.FeedID, retryDelay, err)
+		msg := err.Error()
+		d.LastError = &msg
+		d.NextRunAt = now.Add(retryDelay)
+	} else {
+		d.LastError = nil
+		d.CoveredUntil = &now
+		d.NextRunAt = s.NextRun(d.Frequency, now)
+		if sent > 0 {
+			d.LastSentAt = &now
+			d.LastItemCount = sent
+		}
+	}
+	if err := s.store.UpdateDigestState(ctx, d); err != nil && !errors.Is(err, store.ErrNotFound) {
+		log.Printf("digest: failed to save state of feed %s: %v", d.FeedID, err)
+	}
+}
+
+// periodBefore returns t less one digest period.
+func periodBefore(frequency string, t time.Time) time.Time {
+	if frequency == FrequencyWeekly {
+		return t.AddDate(0, 0, -7)
+	}
+	return t.AddDate(0, 0, -1)
+}
+
+// send emails every confirmed, subscribed recipient the items published in
+// (floor, until] that no digest sent them before, as the items since
+// since, and returns the most items listed in one email. Recipients
+// without such items get nothing. Items are recorded per recipient once
+// their email is sent: a recipient whose email failed gets the items again
+// with a later digest, and nobody gets them twice. An error means no
+// recipient got the digest.
+func (s *Scheduler) send(ctx context.Context, d *store.Digest, since, floor, until time.Time) (int, error) {
+	feed, err := s.store.GetFeedByID(ctx, d.FeedID)
+	if err != nil {
+		return 0, fmt.Errorf("get feed: %w", err)
+	}
+	if !feed.Enabled {
+		return 0, nil
+	}
+
+	all, err := s.store.ListDigestRecipients(ctx, d.FeedID)
+	if err != nil {
+		return 0, fmt.Errorf("list recipients: %w", err)
+	}
+	var recipients []store.DigestRecipient
+	for _, r := range all {
+		if r.ConfirmedAt != nil && r.UnsubscribedAt == nil {
+			recipients = append(recipients, r)
+		}
+	}
+	if len(recipients) == 0 {
+		return 0, nil
+	}
+
+	buildCtx, cancel := context.WithTimeout(ctx, s.opts.BuildTimeout)
+	items, err := s.build(buildCtx, feed)
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("build feed: %w", err)
+	}
+	sentBefore, err := s.store.ListSentDigestItems(ctx, d.FeedID)
+	if err != nil {
+		return 0, fmt.Errorf("list sent items: %w", err)
+	}
+	var window []rss.Item
+	for _, it := range items {
+		if it.PubDate.After(floor) && !it.PubDate.After(until) {
+			window = append(window, it)
+		}
+	}
+
+	listed, delivered, failed := 0, 0, 0
+	var lastErr error
+	for _, r := range recipients {
+		var fresh []rss.Item
+		var guids []string
+		for _, it := range window {
+			if !sentBefore[r.Email][it.GUID] {
+				fresh = append(fresh, it)
+				guids = append(guids, it.GUID)
+			}
+		}
+		if len(fresh) == 0 {
+			continue
+		}
+		more := 0
+		if len(fresh) > s.opts.MaxItems {
+			more = len(fresh) - s.opts.MaxItems
+			fresh = fresh[:s.opts.MaxItems]
+		}
+
+		data := newTemplateData(feed.Name, d.Frequency, since, until, fresh, more)
+		data.UnsubscribeURL = s.UnsubscribeURL(r.UnsubscribeToken)
+		text, html, err := render(data)
+		if err != nil {
+			return 0, err
+		}
+		err = s.mailer.send(message{
+			To:          r.Email,
+			Subject:     fmt.Sprintf("%s digest: %s (%d new)", data.Period, feed.Name, len(fresh)+more),
+			Text:        text,
+			HTML:        html,
+			Unsubscribe: data.UnsubscribeURL,
+		})
+		if err != nil {
+			failed++
+			lastErr = err
+			log.Printf("digest: failed to send feed %s digest to %s: %v", d.FeedID, r.Email, err)
+			continue
+		}
+		delivered++
+		listed = max(listed, len(fresh))
+		// items left out of the email are covered too, as the "more in the
+		// feed" they were counted in
+		if err := s.store.RecordDigestItems(ctx, d.FeedID, r.Email, guids, until, floor); err != nil {
+			log.Printf("warning: digest: failed to record items sent to %s for feed %s: %v", r.Email, d.FeedID, err)
+		}
+	}
+	if failed > 0 && delivered == 0 {
+		return 0, fmt.Errorf("send: %w", lastErr)
+	}
+	if delivered > 0 {
+		log.Printf("digest: sent up to %d items of feed %s to %d recipients", listed, d.FeedID, delivered)
+	}
+	return listed, nil
+}
@@ -0,0 +1,315 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"leetcode-rss/internal/rss"
+	"leetcode-rss/internal/store"
+)
+
+func TestNextRun(t *testing.T) {
+	s := NewScheduler(nil, nil, nil, Options{SendHour: 8})
+	at := func(v string) time.Time {
+		t.Helper()
+		tm, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+
+	tests := []struct {
+		name, frequency, from, want string
+	}{
+		{"daily before the send hour", FrequencyDaily, "2026-10-17T07:59:00Z", "2026-10-17T08:00:00Z"},
+		{"daily at the send hour", FrequencyDaily, "2026-10-17T08:00:00Z", "2026-10-18T08:00:00Z"},
+		{"daily across the year", FrequencyDaily, "2026-12-31T23:30:00Z", "2027-01-01T08:00:00Z"},
+		{"weekly from Saturday", FrequencyWeekly, "2026-10-17T12:00:00Z", "2026-10-19T08:00:00Z"},
+		{"weekly late on Sunday", FrequencyWeekly, "2026-10-18T23:59:59Z", "2026-10-19T08:00:00Z"},
+		{"weekly on Monday before the send hour", FrequencyWeekly, "2026-10-19T07:00:00Z", "2026-10-19T08:00:00Z"},
+		{"weekly on Monday at the send hour", FrequencyWeekly, "2026-10-19T08:00:00Z", "2026-10-26T08:00:00Z"},
+		{"weekly across the year", FrequencyWeekly, "2026-12-31T09:00:00Z", "2027-01-04T08:00:00Z"},
+		{"weekly from another zone", FrequencyWeekly, "2026-10-19T07:30:00+02:00", "2026-10-19T08:00:00Z"},
+	}
+	for _, tt := range tests {
+		got := s.NextRun(tt.frequency, at(tt.from))
+		if want := at(tt.want); !got.Equal(want) {
+			t.Errorf("%s: NextRun(%s) = %s, want %s", tt.name, tt.from, got.Format(time.RFC3339), tt.want)
+		}
+	}
+}
+
+func TestUnsubscribeURLEscapesToken(t *testing.T) {
+	s := NewScheduler(nil, nil, nil, Options{BaseURL: "https://feeds.example"})
+	got := s.UnsubscribeURL("a+b/c=&d")
+	u, err := url.Parse(got)
+	if err != nil {
+		t.Fatalf("parse %q: %v", got, err)
+	}
+	if u.Path != "/digest/unsubscribe" || u.Query().Get("token") != "a+b/c=&d" {
+		t.Errorf("UnsubscribeURL = %q", got)
+	}
+}
+
+func TestSendConfirmation(t *testing.T) {
+	srv := newSMTPServer(t)
+	s := NewScheduler(nil, nil, srv.mailer(t), Options{BaseURL: "https://feeds.example"})
+	r := store.DigestRecipient{FeedID: "feed-1", Email: "new@example.com", UnsubscribeToken: "tok+en/="}
+	if err := s.SendConfirmation("Friends", FrequencyWeekly, r); err != nil {
+		t.Fatalf("SendConfirmation: %v", err)
+	}
+
+	sent := srv.sent()
+	if len(sent) != 1 || sent[0].to != "new@example.com" {
+		t.Fatalf("sent %v, want one message to new@example.com", sent)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(string(sent[0].data)))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	if got := msg.Header.Get("Subject"); got != "Confirm your weekly digest: Friends" {
+		t.Errorf("Subject = %q", got)
+	}
+	if got, want := msg.Header.Get("List-Unsubscribe"), "<"+s.UnsubscribeURL(r.UnsubscribeToken)+">"; got != want {
+		t.Errorf("List-Unsubscribe = %q, want %q", got, want)
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	if !strings.Contains(string(body), s.ConfirmURL(r.UnsubscribeToken)) {
+		t.Errorf("confirmation email lacks the confirm link:\n%s", body)
+	}
+}
+
+// memStore holds one feed's digest state in memory.
+type memStore struct {
+	mu         sync.Mutex
+	feed       store.Feed
+	recipients []store.DigestRecipient
+	sent       map[[2]string]time.Time // email and GUID
+	saved      *store.Digest
+}
+
+func (m *memStore) GetFeedByID(_ context.Context, id string) (*store.Feed, error) {
+	if id != m.feed.ID {
+		return nil, store.ErrNotFound
+	}
+	feed := m.feed
+	return &feed, nil
+}
+
+func (m *memStore) ListDigestsDue(context.Context, time.Time, int) ([]store.Digest, error) {
+	return nil, nil
+}
+
+func (m *memStore) UpdateDigestState(_ context.Context, d *store.Digest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	saved := *d
+	m.saved = &saved
+	return nil
+}
+
+func (m *memStore) ListDigestRecipients(context.Context, string) ([]store.DigestRecipient, error) {
+	return m.recipients, nil
+}
+
+func (m *memStore) ListSentDigestItems(context.Context, string) (map[string]map[string]bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sent := make(map[string]map[string]bool)
+	for key := range m.sent {
+		if sent[key[0]] == nil {
+			sent[key[0]] = make(map[string]bool)
+		}
+		sent[key[0]][key[1]] = true
+	}
+	return sent, nil
+}
+
+func (m *memStore) RecordDigestItems(_ context.Context, _, email string, guids []string, sentAt, pruneBefore time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, guid := range guids {
+		if _, ok := m.sent[[2]string{email, guid}]; !ok {
+			m.sent[[2]string{email, guid}] = sentAt
+		}
+	}
+	for key, at := range m.sent {
+		if at.Before(pruneBefore) {
+			delete(m.sent, key)
+		}
+	}
+	return nil
+}
+
+func TestRunSendsLateItemsOnce(t *testing.T) {
+	srv := newSMTPServer(t)
+	now := time.Now()
+	confirmed := now.Add(-24 * time.Hour)
+	st := &memStore{
+		feed:       store.Feed{ID: "feed-1", Name: "Friends", Enabled: true},
+		recipients: []store.DigestRecipient{{FeedID: "feed-1", Email: "reader@example.com", UnsubscribeToken: "tok", ConfirmedAt: &confirmed}},
+		sent:       make(map[[2]string]time.Time),
+	}
+	items := []rss.Item{
+		{Title: "On time", GUID: "a", PubDate: now.Add(-2 * time.Hour)},
+		{Title: "Too old", GUID: "old", PubDate: now.Add(-48 * time.Hour)},
+	}
+	var mu sync.Mutex
+	build := func(context.Context, *store.Feed) ([]rss.Item, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]rss.Item(nil), items...), nil
+	}
+	s := NewScheduler(st, build, srv.mailer(t), Options{BaseURL: "https://feeds.example"})
+
+	d := &store.Digest{FeedID: "feed-1", Frequency: FrequencyDaily, Enabled: true, NextRunAt: now}
+	s.run(context.Background(), d)
+	if d.LastError != nil {
+		t.Fatalf("first digest failed: %s", *d.LastError)
+	}
+	if d.LastItemCount != 1 {
+		t.Errorf("first digest listed %d items, want 1", d.LastItemCount)
+	}
+
+	// an item published before the first digest's cutoff only reaches the
+	// feed now, e.g. because the feed was built from cached articles
+	mu.Lock()
+	items = append([]rss.Item{{Title: "Late", GUID: "late", PubDate: now.Add(-time.Hour)}}, items...)
+	mu.Unlock()
+	d.NextRunAt = time.Now()
+	s.run(context.Background(), d)
+	if d.LastError != nil {
+		t.Fatalf("second digest failed: %s", *d.LastError)
+	}
+
+	sent := srv.sent()
+	if len(sent) != 2 {
+		t.Fatalf("server got %d messages, want 2", len(sent))
+	}
+	second := string(sent[1].data)
+	if !strings.Contains(second, "Late") || strings.Contains(second, "On time") {
+		t.Errorf("second digest should list only the late item:\n%s", second)
+	}
+
+	// nothing new: no third email
+	d.NextRunAt = time.Now()
+	s.run(context.Background(), d)
+	if n := len(srv.sent()); n != 2 {
+		t.Errorf("digest without new items sent an email (%d messages)", n)
+	}
+}
+
+func TestRunUsesEachRecipientsToken(t *testing.T) {
+	srv := newSMTPServer(t)
+	now := time.Now()
+	confirmed := now.Add(-24 * time.Hour)
+	unsubscribed := now.Add(-time.Hour)
+	st := &memStore{
+		feed: store.Feed{ID: "feed-1", Name: "Friends", Enabled: true},
+		recipients: []store.DigestRecipient{
+			{FeedID: "feed-1", Email: "a@example.com", UnsubscribeToken: "token-a", ConfirmedAt: &confirmed},
+			{FeedID: "feed-1", Email: "b@example.com", UnsubscribeToken: "token+b/=", ConfirmedAt: &confirmed},
+			{FeedID: "feed-1", Email: "gone@example.com", UnsubscribeToken: "token-c", ConfirmedAt: &confirmed, UnsubscribedAt: &unsubscribed},
+			{FeedID: "feed-1", Email: "unconfirmed@example.com", UnsubscribeToken: "token-d"},
+		},
+		sent: make(map[[2]string]time.Time),
+	}
+	build := func(context.Context, *store.Feed) ([]rss.Item, error) {
+		return []rss.Item{{Title: "Two Sum", GUID: "a", PubDate: now.Add(-time.Hour)}}, nil
+	}
+	s := NewScheduler(st, build, srv.mailer(t), Options{BaseURL: "https://feeds.example"})
+	s.run(context.Background(), &store.Digest{FeedID: "feed-1", Frequency: FrequencyDaily, Enabled: true, NextRunAt: now})
+
+	tokens := make(map[string]string)
+	for _, m := range srv.sent() {
+		msg, err := mail.ReadMessage(strings.NewReader(string(m.data)))
+		if err != nil {
+			t.Fatalf("parse message to %s: %v", m.to, err)
+		}
+		link := strings.Trim(msg.Header.Get("List-Unsubscribe"), "<>")
+		u, err := url.Parse(link)
+		if err != nil {
+			t.Fatalf("List-Unsubscribe %q: %v", link, err)
+		}
+		tokens[m.to] = u.Query().Get("token")
+	}
+	want := map[string]string{"a@example.com": "token-a", "b@example.com": "token+b/="}
+	if len(tokens) != len(want) {
+		t.Errorf("sent to %v, want only the confirmed, subscribed recipients", tokens)
+	}
+	for to, token := range want {
+		if tokens[to] != token {
+			t.Errorf("%s got unsubscribe token %q, want %q", to, tokens[to], token)
+		}
+	}
+}
+
+func TestRunRetriesOnlyFailedRecipients(t *testing.T) {
+	srv := newSMTPServer(t)
+	srv.reject("down@example.com")
+	now := time.Now()
+	confirmed := now.Add(-24 * time.Hour)
+	st := &memStore{
+		feed: store.Feed{ID: "feed-1", Name: "Friends", Enabled: true},
+		recipients: []store.DigestRecipient{
+			{FeedID: "feed-1", Email: "up@example.com", UnsubscribeToken: "token-up", ConfirmedAt: &confirmed},
+			{FeedID: "feed-1", Email: "down@example.com", UnsubscribeToken: "token-down", ConfirmedAt: &confirmed},
+		},
+		sent: make(map[[2]string]time.Time),
+	}
+	items := []rss.Item{{Title: "First", GUID: "first", PubDate: now.Add(-2 * time.Hour)}}
+	var mu sync.Mutex
+	build := func(context.Context, *store.Feed) ([]rss.Item, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]rss.Item(nil), items...), nil
+	}
+	s := NewScheduler(st, build, srv.mailer(t), Options{BaseURL: "https://feeds.example"})
+
+	d := &store.Digest{FeedID: "feed-1", Frequency: FrequencyDaily, Enabled: true, NextRunAt: now}
+	s.run(context.Background(), d)
+	if d.LastError != nil {
+		t.Fatalf("digest reaching one recipient failed: %s", *d.LastError)
+	}
+	if _, ok := st.sent[[2]string{"down@example.com", "first"}]; ok {
+		t.Error("item recorded as sent to the recipient whose email failed")
+	}
+
+	srv.accept("down@example.com")
+	mu.Lock()
+	items = append([]rss.Item{{Title: "Second", GUID: "second", PubDate: time.Now()}}, items...)
+	mu.Unlock()
+	d.NextRunAt = time.Now()
+	s.run(context.Background(), d)
+
+	got := make(map[string][]string)
+	for _, m := range srv.sent() {
+		body := string(m.data)
+		for _, title := range []string{"First", "Second"} {
+			if strings.Contains(body, title) {
+				got[m.to] = append(got[m.to], title)
+			}
+		}
+	}
+	if fmt.Sprint(got["up@example.com"]) != "[First Second]" {
+		t.Errorf("up@example.com got %v, want First then only Second", got["up@example.com"])
+	}
+	if fmt.Sprint(got["down@example.com"]) != "[First Second]" {
+		t.Errorf("down@example.com got %v, want First and Second in its first email", got["down@example.com"])
+	}
+	if n := len(srv.sent()); n != 3 {
+		t.Errorf("server got %d messages, want 3", n)
+	}
+}
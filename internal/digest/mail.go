@@ -0,0 +1,184 @@
+package digest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPOptions configures the server digests are sent through.
+type SMTPOptions struct {
+	Host     string
+	Port     int
+	Username string // empty disables authentication
+	Password string
+	From     string // "Name <address>" or a bare address
+	// Timeout bounds sending one message, connecting included (30s).
+	Timeout time.Duration
+}
+
+// Mailer sends digest emails through an SMTP server. STARTTLS is used when
+// the server offers it.
+type Mailer struct {
+	host    string
+	addr    string
+	auth    smtp.Auth
+	from    *mail.Address
+	timeout time.Duration
+}
+
+func NewMailer(opts SMTPOptions) (*Mailer, error) {
+	if opts.Host == "" {
+		return nil, fmt.Errorf("missing SMTP host")
+	}
+	from, err := mail.ParseAddress(opts.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address %q: %w", opts.From, err)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	m := &Mailer{
+		host:    opts.Host,
+		addr:    net.JoinHostPort(opts.Host, strconv.Itoa(opts.Port)),
+		from:    from,
+		timeout: opts.Timeout,
+	}
+	if opts.Username != "" {
+		m.auth = smtp.PlainAuth("", opts.Username, opts.Password, opts.Host)
+	}
+	return m, nil
+}
+
+// message is one email with a plain text and an HTML alternative.
+type message struct {
+	To          string
+	Subject     string
+	Text        string
+	HTML        string
+	Unsubscribe string // one-click unsubscribe URL (RFC 8058)
+}
+
+func (m *Mailer) send(msg message) error {
+	body, err := m.compose(msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// smtp.SendMail has no timeouts; digests are sent one after another, so
+	// a server that stops answering would hold up every later one
+	conn, err := net.DialTimeout("tcp", m.addr, m.timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(m.timeout)); err != nil {
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+	if m.auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp: server does not support AUTH")
+		}
+		if err := c.Auth(m.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(m.from.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// compose renders msg as a multipart/alternative MIME message.
+func (m *Mailer) compose(msg message, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	header := func(k, v string) { fmt.Fprintf(&buf, "%s: %s\r\n", k, v) }
+	header("From", m.from.String())
+	header("To", (&mail.Address{Address: msg.To}).String())
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", "<"+messageID()+"@"+domainOf(m.from.Address)+">")
+	header("MIME-Version", "1.0")
+	if msg.Unsubscribe != "" {
+		header("List-Unsubscribe", "<"+msg.Unsubscribe+">")
+		header("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
+	header("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func messageID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func domainOf(address string) string {
+	if i := strings.LastIndexByte(address, '@'); i >= 0 {
+		return address[i+1:]
+	}
+	return "localhost"
+}
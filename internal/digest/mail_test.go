@@ -0,0 +1,264 @@
+package digest
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpServer is a minimal SMTP server standing in for a mail relay. It
+// accepts every message to a recipient not rejected and keeps it with its
+// envelope.
+type smtpServer struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	messages []sentMessage
+	rejected map[string]bool
+}
+
+type sentMessage struct {
+	from, to string
+	data     []byte
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpServer{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	reply := func(line string) { tp.PrintfLine("%s", line) }
+
+	reply("220 localhost ESMTP")
+	var msg sentMessage
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "MAIL":
+			msg = sentMessage{from: addressOf(line)}
+			reply("250 OK")
+		case "RCPT":
+			msg.to = addressOf(line)
+			s.mu.Lock()
+			rejected := s.rejected[msg.to]
+			s.mu.Unlock()
+			if rejected {
+				reply("550 mailbox unavailable")
+				continue
+			}
+			reply("250 OK")
+		case "DATA":
+			reply("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+// addressOf extracts the address of a MAIL FROM:<...> or RCPT TO:<...> line.
+func addressOf(line string) string {
+	start, end := strings.IndexByte(line, '<'), strings.IndexByte(line, '>')
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
+
+// reject makes the server refuse mail to addr until accept is called.
+func (s *smtpServer) reject(addr string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rejected == nil {
+		s.rejected = make(map[string]bool)
+	}
+	s.rejected[addr] = true
+}
+
+func (s *smtpServer) accept(addr string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.rejected, addr)
+}
+
+func (s *smtpServer) sent() []sentMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]sentMessage(nil), s.messages...)
+}
+
+func (s *smtpServer) mailer(t *testing.T) *Mailer {
+	t.Helper()
+	host, port, _ := net.SplitHostPort(s.ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	m, err := NewMailer(SMTPOptions{Host: host, Port: p, From: "LeetCode RSS <digest@example.com>", Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMailerSendsMultipartAlternative(t *testing.T) {
+	srv := newSMTPServer(t)
+	m := srv.mailer(t)
+
+	text := "Two Sum = easy\n" + strings.Repeat("long line ", 20) + "\n"
+	html := `<p style="color: #656d76;">Two Sum</p>`
+	err := m.send(message{
+		To:          "reader@example.com",
+		Subject:     "Daily digest: café (2 new)",
+		Text:        text,
+		HTML:        html,
+		Unsubscribe: "https://feeds.example/digest/unsubscribe?token=abc",
+	})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	sent := srv.sent()
+	if len(sent) != 1 {
+		t.Fatalf("server got %d messages, want 1", len(sent))
+	}
+	if sent[0].from != "digest@example.com" || sent[0].to != "reader@example.com" {
+		t.Errorf("envelope = %s -> %s", sent[0].from, sent[0].to)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(sent[0].data)))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Daily digest: café (2 new)" {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+	for k, want := range map[string]string{
+		"From":                  `"LeetCode RSS" <digest@example.com>`,
+		"To":                    "<reader@example.com>",
+		"MIME-Version":          "1.0",
+		"List-Unsubscribe":      "<https://feeds.example/digest/unsubscribe?token=abc>",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	} {
+		if got := msg.Header.Get(k); got != want {
+			t.Errorf("%s = %q, want %q", k, got, want)
+		}
+	}
+	if id := msg.Header.Get("Message-ID"); !strings.HasSuffix(id, "@example.com>") {
+		t.Errorf("Message-ID = %q", id)
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("Date: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q (%v)", msg.Header.Get("Content-Type"), err)
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for _, want := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	} {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatalf("next part: %v", err)
+		}
+		if got := part.Header.Get("Content-Type"); got != want.contentType {
+			t.Errorf("part Content-Type = %q, want %q", got, want.contentType)
+		}
+		// the reader decodes quoted-printable parts
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+		if string(body) != want.body {
+			t.Errorf("%s part = %q, want %q", want.contentType, body, want.body)
+		}
+	}
+	if _, err := mr.NextPart(); err != io.EOF {
+		t.Errorf("extra part after the alternatives: %v", err)
+	}
+	// ReadDotBytes turned the CRLF line endings into LF
+	for _, line := range strings.Split(string(sent[0].data), "\n") {
+		if len(line) > 998 {
+			t.Errorf("line of %d bytes exceeds the SMTP limit", len(line))
+		}
+	}
+}
+
+func TestMailerTimesOutOnStalledServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			// never greet; hold the connection until the client gives up
+			go io.Copy(io.Discard, bufio.NewReader(conn))
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	m, err := NewMailer(SMTPOptions{Host: host, Port: p, From: "digest@example.com", Timeout: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	err = m.send(message{To: "reader@example.com", Subject: "s", Text: "t", HTML: "h"})
+	if err == nil {
+		t.Fatal("send to a stalled server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("send gave up after %s, want about the 200ms timeout", elapsed)
+	}
+}
@@ -0,0 +1,183 @@
+package digest
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"leetcode-rss/internal/rss"
+)
+
+// templateData is what the digest templates are executed with.
+type templateData struct {
+	FeedName       string
+	Period         string // "Daily" or "Weekly"
+	Since          string
+	Until          string
+	Items          []templateItem
+	More           int // new items left out of the email
+	UnsubscribeURL string
+}
+
+type templateItem struct {
+	Title      string
+	Link       string
+	Author     string
+	AuthorURL  string
+	Published  string
+	Categories string
+	Summary    string
+}
+
+const textTemplate = `{{.Period}} digest: {{.FeedName}}
+New solution articles from {{.Since}} to {{.Until}}
+{{range .Items}}
+* {{.Title}}
+  {{.Link}}
+  {{if .Author}}by {{.Author}}, {{end}}{{.Published}}{{if .Categories}} · {{.Categories}}{{end}}
+{{if .Summary}}  {{.Summary}}
+{{end}}{{end}}{{if .More}}
+...and {{.More}} more in the feed.
+{{end}}
+--
+You receive this digest because the owner of the feed added your address.
+Unsubscribe: {{.UnsubscribeURL}}
+`
+
+const htmlTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, Segoe UI, Helvetica, Arial, sans-serif; color: #1f2328; max-width: 640px;">
+<h2 style="margin-bottom: 4px;">{{.Period}} digest: {{.FeedName}}</h2>
+<p style="color: #656d76; margin-top: 0;">New solution articles from {{.Since}} to {{.Until}}</p>
+{{range .Items}}
+<div style="margin: 20px 0;">
+  <a href="{{.Link}}" style="font-size: 16px; font-weight: 600;">{{.Title}}</a>
+  <div style="color: #656d76; font-size: 13px;">
+    {{if .Author}}by {{if .AuthorURL}}<a href="{{.AuthorURL}}" style="color: #656d76;">{{.Author}}</a>{{else}}{{.Author}}{{end}}, {{end}}{{.Published}}{{if .Categories}} &middot; {{.Categories}}{{end}}
+  </div>
+  {{if .Summary}}<p style="margin: 6px 0 0;">{{.Summary}}</p>{{end}}
+</div>
+{{end}}
+{{if .More}}<p>&hellip;and {{.More}} more in the feed.</p>{{end}}
+<hr style="border: none; border-top: 1px solid #d0d7de;">
+<p style="color: #656d76; font-size: 12px;">
+  You receive this digest because the owner of the feed added your address.
+  <a href="{{.UnsubscribeURL}}" style="color: #656d76;">Unsubscribe</a>
+</p>
+</body>
+</html>
+`
+
+// confirmData is what the confirmation templates are executed with.
+type confirmData struct {
+	FeedName       string
+	Period         string // "Daily" or "Weekly"
+	ConfirmURL     string
+	UnsubscribeURL string
+}
+
+const confirmTextTemplate = `Confirm your {{.Period}} digest: {{.FeedName}}
+
+The owner of the feed "{{.FeedName}}" wants to email this address a summary
+of the feed's new solution articles. Nothing is sent until you confirm.
+
+Confirm: {{.ConfirmURL}}
+
+If you do not want these emails, ignore this message or decline:
+{{.UnsubscribeURL}}
+`
+
+const confirmHTMLTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, Segoe UI, Helvetica, Arial, sans-serif; color: #1f2328; max-width: 640px;">
+<h2 style="margin-bottom: 4px;">Confirm your {{.Period}} digest: {{.FeedName}}</h2>
+<p>The owner of the feed &ldquo;{{.FeedName}}&rdquo; wants to email this address a summary of the feed's new solution articles. Nothing is sent until you confirm.</p>
+<p><a href="{{.ConfirmURL}}" style="font-size: 16px; font-weight: 600;">Confirm</a></p>
+<hr style="border: none; border-top: 1px solid #d0d7de;">
+<p style="color: #656d76; font-size: 12px;">
+  If you do not want these emails, ignore this message or
+  <a href="{{.UnsubscribeURL}}" style="color: #656d76;">decline</a>.
+</p>
+</body>
+</html>
+`
+
+var (
+	textTmpl        = texttemplate.Must(texttemplate.New("digest.txt").Parse(textTemplate))
+	htmlTmpl        = htmltemplate.Must(htmltemplate.New("digest.html").Parse(htmlTemplate))
+	confirmTextTmpl = texttemplate.Must(texttemplate.New("confirm.txt").Parse(confirmTextTemplate))
+	confirmHTMLTmpl = htmltemplate.Must(htmltemplate.New("confirm.html").Parse(confirmHTMLTemplate))
+)
+
+// maxSummaryRunes bounds item summaries in emails; feeds carry longer ones.
+const maxSummaryRunes = 300
+
+func newTemplateData(feedName, frequency string, since, until time.Time, items []rss.Item, more int) templateData {
+	data := templateData{
+		FeedName: feedName,
+		Period:   period(frequency),
+		Since:    since.UTC().Format("Jan 2, 15:04 MST"),
+		Until:    until.UTC().Format("Jan 2, 15:04 MST"),
+		More:     more,
+	}
+	for _, it := range items {
+		data.Items = append(data.Items, templateItem{
+			Title:      it.Title,
+			Link:       it.Link,
+			Author:     it.Author,
+			AuthorURL:  it.AuthorURI,
+			Published:  it.PubDate.UTC().Format("Jan 2, 15:04 MST"),
+			Categories: strings.Join(it.Categories, ", "),
+			Summary:    truncate(it.Summary, maxSummaryRunes),
+		})
+	}
+	return data
+}
+
+func newConfirmData(feedName, frequency string) confirmData {
+	return confirmData{FeedName: feedName, Period: period(frequency)}
+}
+
+// period names a digest frequency in email headings.
+func period(frequency string) string {
+	if frequency == FrequencyWeekly {
+		return "Weekly"
+	}
+	return "Daily"
+}
+
+// render executes both templates for one recipient.
+func render(data templateData) (text, html string, err error) {
+	var tb, hb bytes.Buffer
+	if err := textTmpl.Execute(&tb, data); err != nil {
+		return "", "", fmt.Errorf("render text digest: %w", err)
+	}
+	if err := htmlTmpl.Execute(&hb, data); err != nil {
+		return "", "", fmt.Errorf("render html digest: %w", err)
+	}
+	return tb.String(), hb.String(), nil
+}
+
+// renderConfirmation executes both confirmation templates.
+func renderConfirmation(data confirmData) (text, html string, err error) {
+	var tb, hb bytes.Buffer
+	if err := confirmTextTmpl.Execute(&tb, data); err != nil {
+		return "", "", fmt.Errorf("render text confirmation: %w", err)
+	}
+	if err := confirmHTMLTmpl.Execute(&hb, data); err != nil {
+		return "", "", fmt.Errorf("render html confirmation: %w", err)
+	}
+	return tb.String(), hb.String(), nil
+}
+
+// truncate shortens s to at most limit runes, marking the cut with "…".
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-1]) + "…"
+}
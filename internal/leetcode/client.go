@@ -4,16 +4,70 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
+	"sync"
+	"time"
 )
 
+// RetryPolicy controls how Client.Do retries rate-limited, 5xx and
+// transport failures. Delays grow exponentially from BaseDelay with full
+// jitter and are capped at MaxDelay. A Retry-After hint from LeetCode is
+// honoured as long as it does not exceed MaxDelay.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+	}
+}
+
+// backoff returns the delay before retry number attempt (starting at 1).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+	return rand.N(d) + 1
+}
+
+// Observer receives the duration of every request sent to LeetCode,
+// retries included, and the kind of every error, including GraphQL errors
+// in otherwise successful responses.
+type Observer interface {
+	ObserveRequest(d time.Duration)
+	ObserveError(kind string)
+}
+
 type Client struct {
 	Endpoint string
-	Cookie   string
-	CSRF     string
-	Client   *http.Client
+	// Cookie and CSRF authenticate requests. Once the client is in use,
+	// change them with SetCredentials.
+	Cookie string
+	CSRF   string
+	Client *http.Client
+	Retry  RetryPolicy
+	// Limiter, when set, gates every attempt sent to LeetCode, retries included.
+	Limiter *Limiter
+	// Outcomes, when set, records whether each Do call succeeded once
+	// retries were exhausted. Calls cut short by their context are skipped.
+	Outcomes *Outcomes
+	// Observer, when set, is told about every request and error.
+	Observer Observer
+
+	credMu sync.RWMutex
 }
 
 func New(endpoint, cookie, csrf string) *Client {
@@ -22,20 +76,68 @@ func New(endpoint, cookie, csrf string) *Client {
 		Cookie:   cookie,
 		CSRF:     csrf,
 		Client:   http.DefaultClient,
+		Retry:    DefaultRetryPolicy(),
 	}
 }
 
-func (c *Client) Do(ctx context.Context, body any, out any) error {
+// SetCredentials replaces the cookie and CSRF token sent with requests
+// that start after it returns.
+func (c *Client) SetCredentials(cookie, csrf string) {
+	c.credMu.Lock()
+	defer c.credMu.Unlock()
+	c.Cookie = cookie
+	c.CSRF = csrf
+}
+
+func (c *Client) Do(ctx context.Context, body any, out any) (err error) {
 	b, err := json.Marshal(body)
 	if err != nil {
 		return fmt.Errorf("marshal request body: %w", err)
 	}
+	if c.Outcomes != nil {
+		defer func() {
+			if ctx.Err() == nil {
+				c.Outcomes.record(err == nil)
+			}
+		}()
+	}
 
+	attempts := c.Retry.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = c.do(ctx, b, out)
+		if err == nil || attempt >= attempts || !isRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		delay := c.Retry.backoff(attempt)
+		var rateErr *RateLimitError
+		if errors.As(err, &rateErr) && rateErr.RetryAfter > 0 {
+			if c.Retry.MaxDelay > 0 && rateErr.RetryAfter > c.Retry.MaxDelay {
+				return err
+			}
+			delay = rateErr.RetryAfter
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) do(ctx context.Context, body []byte, out any) (err error) {
 	req, err := http.NewRequestWithContext(
 		ctx,
 		"POST",
 		c.Endpoint,
-		bytes.NewReader(b),
+		bytes.NewReader(body),
 	)
 	if err != nil {
 		return err
@@ -45,26 +147,59 @@ func (c *Client) Do(ctx context.Context, body any, out any) error {
 	req.Header.Set("Referer", "https://leetcode.com/")
 	req.Header.Set("User-Agent", "leetcode-rss/1.0")
 
-	if c.Cookie != "" {
-		req.Header.Set("Cookie", c.Cookie)
+	c.credMu.RLock()
+	cookie, csrf := c.Cookie, c.CSRF
+	c.credMu.RUnlock()
+	if cookie != "" {
+		req.Header.Set("Cookie", cookie)
 	}
-	if c.CSRF != "" {
-		req.Header.Set("x-csrftoken", c.CSRF)
+	if csrf != "" {
+		req.Header.Set("x-csrftoken", csrf)
 		req.Header.Set("x-requested-with", "XMLHttpRequest")
 	}
 
+	if c.Limiter != nil {
+		release, err := c.Limiter.Acquire(ctx)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	if c.Observer != nil {
+		start := time.Now()
+		defer func() {
+			c.Observer.ObserveRequest(time.Since(start))
+			if err != nil {
+				c.Observer.ObserveError(errorKind(err))
+			}
+		}()
+	}
+
 	resp, err := c.Client.Do(req)
 	if err != nil {
-		return err
+		return &TransportError{Err: err}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
 		raw, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("leetcode http %d: %s", resp.StatusCode, raw)
+		return errorFromResponse(resp, raw)
 	}
 
-	return json.NewDecoder(resp.Body).Decode(out)
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode leetcode response: %w", err)
+	}
+	return nil
+}
+
+// responseError reports err, found in the body of a successful response,
+// to the Observer and returns it.
+func (c *Client) responseError(err error) error {
+	if c.Observer != nil {
+		c.Observer.ObserveError(errorKind(err))
+	}
+	return err
 }
 
 func (c *Client) PostJSON(ctx context.Context, body any, out any) error {
@@ -0,0 +1,214 @@
+package leetcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type stubResponse struct {
+	status     int
+	retryAfter string
+	body       string
+}
+
+// sequenceServer answers each request with the next of responses,
+// repeating the last one once they run out.
+func sequenceServer(t *testing.T, responses []stubResponse) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1)) - 1
+		if n >= len(responses) {
+			n = len(responses) - 1
+		}
+		resp := responses[n]
+		if resp.retryAfter != "" {
+			w.Header().Set("Retry-After", resp.retryAfter)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.status)
+		fmt.Fprint(w, resp.body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+const okBody = `{"data":{"ugcArticleUserSolutionArticles":{"totalNum":0,"pageInfo":{"hasNextPage":false},"edges":[]}}}`
+
+func TestDoRetries(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second}
+	tests := []struct {
+		name      string
+		responses []stubResponse
+		wantCalls int32
+		// check inspects the error; nil means success is expected.
+		check     func(t *testing.T, err error)
+		minElapse time.Duration
+	}{
+		{
+			name:      "success first try",
+			responses: []stubResponse{{status: 200, body: okBody}},
+			wantCalls: 1,
+		},
+		{
+			name:      "retries 5xx then succeeds",
+			responses: []stubResponse{{status: 502}, {status: 200, body: okBody}},
+			wantCalls: 2,
+		},
+		{
+			name:      "gives up after max attempts",
+			responses: []stubResponse{{status: 503, body: "down"}},
+			wantCalls: 3,
+			check: func(t *testing.T, err error) {
+				var httpErr *HTTPError
+				if !errors.As(err, &httpErr) || httpErr.StatusCode != 503 || httpErr.Body != "down" {
+					t.Fatalf("err = %v, want HTTPError 503", err)
+				}
+			},
+		},
+		{
+			name:      "honours retry-after",
+			responses: []stubResponse{{status: 429, retryAfter: "1"}, {status: 200, body: okBody}},
+			wantCalls: 2,
+			minElapse: time.Second,
+		},
+		{
+			name:      "retry-after beyond max delay returns early",
+			responses: []stubResponse{{status: 429, retryAfter: "60"}, {status: 200, body: okBody}},
+			wantCalls: 1,
+			check: func(t *testing.T, err error) {
+				var rateErr *RateLimitError
+				if !errors.As(err, &rateErr) || rateErr.RetryAfter != time.Minute {
+					t.Fatalf("err = %v, want RateLimitError retrying after 1m", err)
+				}
+			},
+		},
+		{
+			name:      "rate limited without hint uses backoff",
+			responses: []stubResponse{{status: 429}},
+			wantCalls: 3,
+			check: func(t *testing.T, err error) {
+				var rateErr *RateLimitError
+				if !errors.As(err, &rateErr) || rateErr.RetryAfter != 0 {
+					t.Fatalf("err = %v, want RateLimitError", err)
+				}
+			},
+		},
+		{
+			name:      "auth errors are not retried",
+			responses: []stubResponse{{status: 403}},
+			wantCalls: 1,
+			check: func(t *testing.T, err error) {
+				var authErr *AuthRequiredError
+				if !errors.As(err, &authErr) || authErr.StatusCode != 403 {
+					t.Fatalf("err = %v, want AuthRequiredError 403", err)
+				}
+			},
+		},
+		{
+			name:      "4xx is not retried",
+			responses: []stubResponse{{status: 400, body: "bad"}},
+			wantCalls: 1,
+			check: func(t *testing.T, err error) {
+				var httpErr *HTTPError
+				if !errors.As(err, &httpErr) || httpErr.StatusCode != 400 {
+					t.Fatalf("err = %v, want HTTPError 400", err)
+				}
+			},
+		},
+		{
+			name:      "unknown user",
+			responses: []stubResponse{{status: 200, body: `{"errors":[{"message":"User matching query does not exist."}]}`}},
+			wantCalls: 1,
+			check: func(t *testing.T, err error) {
+				var notFound *UserNotFoundError
+				if !errors.As(err, &notFound) || notFound.Username != "alice" {
+					t.Fatalf("err = %v, want UserNotFoundError for alice", err)
+				}
+			},
+		},
+		{
+			name:      "other graphql errors",
+			responses: []stubResponse{{status: 200, body: `{"errors":[{"message":"boom"}]}`}},
+			wantCalls: 1,
+			check: func(t *testing.T, err error) {
+				var gqlErr *GraphQLError
+				if !errors.As(err, &gqlErr) || len(gqlErr.Errors) != 1 || gqlErr.Errors[0].Message != "boom" {
+					t.Fatalf("err = %v, want GraphQLError boom", err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := sequenceServer(t, tt.responses)
+			c := New(srv.URL, "", "")
+			c.Retry = policy
+
+			start := time.Now()
+			_, err := FetchUserSolutionArticlesPage(context.Background(), c, "alice", 0, 10)
+			elapsed := time.Since(start)
+
+			if tt.check == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			} else {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				tt.check(t, err)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("upstream called %d times, want %d", got, tt.wantCalls)
+			}
+			if elapsed < tt.minElapse {
+				t.Errorf("returned after %s, want at least %s", elapsed, tt.minElapse)
+			}
+		})
+	}
+}
+
+func TestDoStopsWhenContextDone(t *testing.T) {
+	srv, calls := sequenceServer(t, []stubResponse{{status: 429, retryAfter: "1"}})
+	c := New(srv.URL, "", "")
+	c.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := FetchUserSolutionArticlesPage(ctx, c, "alice", 0, 10)
+	var rateErr *RateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Fatalf("err = %v, want the last RateLimitError", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("upstream called %d times, want 1", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 7, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{" 5 ", 5 * time.Second},
+		{"-3", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.in, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package leetcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitError is returned when LeetCode answers 429. RetryAfter is the
+// delay requested by the Retry-After header, or zero when none was sent.
+type RateLimitError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("leetcode rate limited (retry after %s)", e.RetryAfter)
+	}
+	return "leetcode rate limited"
+}
+
+// UserNotFoundError is returned when the requested LeetCode user does not exist.
+type UserNotFoundError struct {
+	Username string
+}
+
+func (e *UserNotFoundError) Error() string {
+	return fmt.Sprintf("leetcode user %q not found", e.Username)
+}
+
+// AuthRequiredError is returned when LeetCode rejects the request as
+// unauthenticated or forbidden, usually because of a missing or expired cookie.
+type AuthRequiredError struct {
+	StatusCode int
+}
+
+func (e *AuthRequiredError) Error() string {
+	return fmt.Sprintf("leetcode authentication required (http %d)", e.StatusCode)
+}
+
+type GraphQLMessage struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// GraphQLError carries the errors array of a GraphQL response that
+// otherwise returned 200.
+type GraphQLError struct {
+	Errors []GraphQLMessage
+}
+
+func (e *GraphQLError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, m := range e.Errors {
+		msgs = append(msgs, m.Message)
+	}
+	return "graphql error: " + strings.Join(msgs, "; ")
+}
+
+// HTTPError is returned for non-2xx responses not covered by a more
+// specific error type.
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("leetcode http %d: %s", e.StatusCode, e.Body)
+}
+
+// TransportError wraps failures to reach LeetCode or to decode its response.
+type TransportError struct {
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("leetcode transport: %v", e.Err)
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// maxErrorBody bounds how much of an error response body is kept in HTTPError.
+const maxErrorBody = 512
+
+func errorFromResponse(resp *http.Response, body []byte) error {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &RateLimitError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+		return &AuthRequiredError{StatusCode: resp.StatusCode}
+	default:
+		if len(body) > maxErrorBody {
+			body = body[:maxErrorBody]
+		}
+		return &HTTPError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+}
+
+// parseRetryAfter accepts both the delay-seconds and HTTP-date forms.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// graphQLErrorFor converts a GraphQL errors array into a typed error,
+// recognising LeetCode's "user does not exist" responses.
+func graphQLErrorFor(username string, msgs []GraphQLMessage) error {
+	for _, m := range msgs {
+		lower := strings.ToLower(m.Message)
+		if strings.Contains(lower, "does not exist") || strings.Contains(lower, "user not found") {
+			return &UserNotFoundError{Username: username}
+		}
+	}
+	return &GraphQLError{Errors: msgs}
+}
+
+// errorKind names the kind of err for metrics.
+func errorKind(err error) string {
+	var (
+		rateErr      *RateLimitError
+		authErr      *AuthRequiredError
+		notFoundErr  *UserNotFoundError
+		graphQLErr   *GraphQLError
+		httpErr      *HTTPError
+		transportErr *TransportError
+	)
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	case errors.As(err, &rateErr):
+		return "rate_limited"
+	case errors.As(err, &authErr):
+		return "auth_required"
+	case errors.As(err, &notFoundErr):
+		return "user_not_found"
+	case errors.As(err, &graphQLErr):
+		return "graphql"
+	case errors.As(err, &httpErr):
+		return "http"
+	case errors.As(err, &transportErr):
+		return "transport"
+	default:
+		return "decode"
+	}
+}
+
+// isRetryable reports whether a request that failed with err may succeed
+// if sent again.
+func isRetryable(err error) bool {
+	var rateErr *RateLimitError
+	if errors.As(err, &rateErr) {
+		return true
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500
+	}
+	var transportErr *TransportError
+	return errors.As(err, &transportErr)
+}
@@ -0,0 +1,153 @@
+package leetcode
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Limiter throttles every request a Client sends to LeetCode. It combines a
+// token bucket (requests per second with a burst allowance) with a cap on
+// concurrent in-flight requests, so all feed builds in the process share a
+// single upstream budget.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	slots chan struct{}
+
+	requests   atomic.Int64
+	queued     atomic.Int64
+	inFlight   atomic.Int64
+	totalQueue atomic.Int64
+	maxQueue   atomic.Int64
+}
+
+// LimiterStats is a snapshot of Limiter activity since process start.
+type LimiterStats struct {
+	Requests       int64
+	Queued         int64 // requests that waited at least a millisecond
+	InFlight       int64
+	TotalQueueTime time.Duration
+	MaxQueueTime   time.Duration
+}
+
+// NewLimiter allows rps requests per second with bursts of up to burst and at
+// most maxInFlight concurrent requests. A non-positive rps disables rate
+// limiting and a non-positive maxInFlight disables the concurrency cap.
+func NewLimiter(rps float64, burst, maxInFlight int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	l := &Limiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+	if maxInFlight > 0 {
+		l.slots = make(chan struct{}, maxInFlight)
+	}
+	return l
+}
+
+// Acquire blocks until a request may be sent. The returned release func must
+// be called once the request has completed.
+func (l *Limiter) Acquire(ctx context.Context) (func(), error) {
+	start := time.Now()
+	l.requests.Add(1)
+
+	if wait := l.reserve(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			l.cancelReservation()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			l.cancelReservation()
+			return nil, ctx.Err()
+		}
+	}
+
+	l.recordQueueTime(time.Since(start))
+	l.inFlight.Add(1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.inFlight.Add(-1)
+			if l.slots != nil {
+				<-l.slots
+			}
+		})
+	}, nil
+}
+
+// reserve takes a token and returns how long the caller must wait before
+// the token becomes valid.
+func (l *Limiter) reserve(now time.Time) time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elapsed := now.Sub(l.last).Seconds()
+	l.last = now
+	l.tokens += elapsed * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancelReservation refunds the token taken by reserve when the caller
+// gives up before sending its request.
+func (l *Limiter) cancelReservation() {
+	if l.rate <= 0 {
+		return
+	}
+	l.mu.Lock()
+	l.tokens = min(l.tokens+1, l.burst)
+	l.mu.Unlock()
+}
+
+func (l *Limiter) recordQueueTime(d time.Duration) {
+	if d < time.Millisecond {
+		return
+	}
+	l.queued.Add(1)
+	l.totalQueue.Add(int64(d))
+	for {
+		cur := l.maxQueue.Load()
+		if int64(d) <= cur || l.maxQueue.CompareAndSwap(cur, int64(d)) {
+			return
+		}
+	}
+}
+
+func (l *Limiter) Stats() LimiterStats {
+	return LimiterStats{
+		Requests:       l.requests.Load(),
+		Queued:         l.queued.Load(),
+		InFlight:       l.inFlight.Load(),
+		TotalQueueTime: time.Duration(l.totalQueue.Load()),
+		MaxQueueTime:   time.Duration(l.maxQueue.Load()),
+	}
+}
@@ -0,0 +1,53 @@
+package leetcode
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiterRefundsTokenWhenSlotWaitCancelled(t *testing.T) {
+	// Two tokens that practically never refill and a single slot.
+	l := NewLimiter(0.001, 2, 1)
+
+	release, err := l.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("first Acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx); err == nil {
+		t.Fatal("Acquire succeeded while the only slot was held")
+	}
+	release()
+
+	// The cancelled caller's token must have been refunded.
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	release, err = l.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("Acquire after refund: %v", err)
+	}
+	release()
+}
+
+func TestLimiterRefundsTokenWhenRateWaitCancelled(t *testing.T) {
+	l := NewLimiter(0.001, 1, 0)
+
+	if _, err := l.Acquire(context.Background()); err != nil {
+		t.Fatalf("first Acquire: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx); err == nil {
+		t.Fatal("Acquire succeeded with an empty bucket")
+	}
+
+	l.mu.Lock()
+	tokens := l.tokens
+	l.mu.Unlock()
+	if tokens < -0.01 {
+		t.Fatalf("tokens = %f after cancelled wait, want the reservation refunded", tokens)
+	}
+}
@@ -1,5 +1,7 @@
 package leetcode
 
+import "time"
+
 type UGCArticlesResponse struct {
 	Data struct {
 		UgcArticleUserSolutionArticles struct {
@@ -22,9 +24,7 @@ type UGCArticlesEnvelope struct {
 			} `json:"edges"`
 		} `json:"ugcArticleUserSolutionArticles"`
 	} `json:"data"`
-	Errors []struct {
-		Message string `json:"message"`
-	} `json:"errors"`
+	Errors []GraphQLMessage `json:"errors"`
 }
 
 type Article struct {
@@ -37,3 +37,48 @@ type Article struct {
 	QuestionSlug  string `json:"questionSlug"`
 	QuestionTitle string `json:"questionTitle"`
 }
+
+// CreatedTime parses CreatedAt, which looks like 2026-01-07T03:52:30.464981+00:00.
+func (a Article) CreatedTime() (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339Nano, a.CreatedAt)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+type UGCSolutionArticleEnvelope struct {
+	Data struct {
+		UgcArticleSolutionArticle *ArticleContent `json:"ugcArticleSolutionArticle"`
+	} `json:"data"`
+	Errors []GraphQLMessage `json:"errors"`
+}
+
+// ArticleContent is the body of a solution article. Content is Markdown.
+type ArticleContent struct {
+	UUID      string `json:"uuid"`
+	Content   string `json:"content"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+type QuestionEnvelope struct {
+	Data struct {
+		Question *Question `json:"question"`
+	} `json:"data"`
+	Errors []GraphQLMessage `json:"errors"`
+}
+
+// Question is the metadata of a LeetCode problem.
+type Question struct {
+	FrontendID string     `json:"questionFrontendId"`
+	Title      string     `json:"title"`
+	TitleSlug  string     `json:"titleSlug"`
+	Difficulty string     `json:"difficulty"` // "Easy", "Medium" or "Hard"
+	ACRate     float64    `json:"acRate"`     // acceptance rate in percent
+	TopicTags  []TopicTag `json:"topicTags"`
+}
+
+type TopicTag struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
@@ -0,0 +1,54 @@
+package leetcode
+
+import (
+	"sync"
+	"time"
+)
+
+// outcomeHistory bounds how many request outcomes Outcomes keeps.
+const outcomeHistory = 512
+
+// Outcomes records whether recent requests to LeetCode succeeded, so the
+// readiness check can report the upstream success rate. The zero value is
+// ready to use.
+type Outcomes struct {
+	mu   sync.Mutex
+	ring [outcomeHistory]outcome
+	next int
+}
+
+type outcome struct {
+	at time.Time
+	ok bool
+}
+
+func (o *Outcomes) record(ok bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.ring[o.next] = outcome{at: time.Now(), ok: ok}
+	o.next = (o.next + 1) % outcomeHistory
+}
+
+// Rate returns the share of requests completed within window that
+// succeeded, and how many requests that was. The rate is 1 when there were
+// none.
+func (o *Outcomes) Rate(window time.Duration) (float64, int) {
+	since := time.Now().Add(-window)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var total, ok int
+	for _, r := range o.ring {
+		if r.at.IsZero() || r.at.Before(since) {
+			continue
+		}
+		total++
+		if r.ok {
+			ok++
+		}
+	}
+	if total == 0 {
+		return 1, 0
+	}
+	return float64(ok) / float64(total), total
+}
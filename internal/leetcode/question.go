@@ -0,0 +1,118 @@
+package leetcode
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const queryQuestionMetadata = `
+query questionMetadata($titleSlug: String!) {
+  question(titleSlug: $titleSlug) {
+    questionFrontendId
+    title
+    titleSlug
+    difficulty
+    acRate
+    topicTags { name slug }
+  }
+}
+`
+
+// FetchQuestion fetches metadata for the problem identified by titleSlug.
+func FetchQuestion(ctx context.Context, c *Client, titleSlug string) (*Question, error) {
+	req := ugcReq{
+		Query:         queryQuestionMetadata,
+		OperationName: "questionMetadata",
+		Variables: map[string]interface{}{
+			"titleSlug": titleSlug,
+		},
+	}
+
+	var env QuestionEnvelope
+	if err := c.PostJSON(ctx, req, &env); err != nil {
+		return nil, err
+	}
+	if len(env.Errors) > 0 {
+		return nil, c.responseError(&GraphQLError{Errors: env.Errors})
+	}
+	if env.Data.Question == nil {
+		return nil, fmt.Errorf("question %q not found", titleSlug)
+	}
+	return env.Data.Question, nil
+}
+
+// questionFetchTimeout bounds a shared question fetch, which outlives the
+// request that started it.
+const questionFetchTimeout = 30 * time.Second
+
+// QuestionCache memoizes question metadata in memory. Problems change
+// rarely, so entries live for a long TTL and concurrent lookups of the same
+// slug share a single upstream request.
+type QuestionCache struct {
+	client *Client
+	ttl    time.Duration
+
+	mu      sync.RWMutex
+	entries map[string]questionEntry
+	group   singleflight.Group
+}
+
+type questionEntry struct {
+	question  *Question
+	expiresAt time.Time
+}
+
+func NewQuestionCache(c *Client, ttl time.Duration) *QuestionCache {
+	return &QuestionCache{
+		client:  c,
+		ttl:     ttl,
+		entries: make(map[string]questionEntry),
+	}
+}
+
+// Lookup returns the cached metadata for titleSlug without going upstream.
+func (qc *QuestionCache) Lookup(titleSlug string) (*Question, bool) {
+	qc.mu.RLock()
+	e, ok := qc.entries[titleSlug]
+	qc.mu.RUnlock()
+	if !ok || time.Now().After(e.expiresAt) {
+		return nil, false
+	}
+	return e.question, true
+}
+
+// Get returns the metadata for titleSlug, fetching it on a cache miss.
+func (qc *QuestionCache) Get(ctx context.Context, titleSlug string) (*Question, error) {
+	if q, ok := qc.Lookup(titleSlug); ok {
+		return q, nil
+	}
+
+	// the fetch is shared by every caller waiting on the slug, so it must
+	// not end when the first one gives up; each caller still returns as
+	// soon as its own context is done
+	ch := qc.group.DoChan(titleSlug, func() (interface{}, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), questionFetchTimeout)
+		defer cancel()
+		q, err := FetchQuestion(fetchCtx, qc.client, titleSlug)
+		if err != nil {
+			return nil, err
+		}
+		qc.mu.Lock()
+		qc.entries[titleSlug] = questionEntry{question: q, expiresAt: time.Now().Add(qc.ttl)}
+		qc.mu.Unlock()
+		return q, nil
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*Question), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
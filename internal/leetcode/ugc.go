@@ -3,8 +3,16 @@ package leetcode
 import (
 	"context"
 	"fmt"
+	"time"
 )
 
+// MaxPageSize is the largest page ugcArticleUserSolutionArticles will return.
+const MaxPageSize = 50
+
+// defaultMaxPages bounds a paginated fetch so a misbehaving upstream that
+// always reports hasNextPage cannot keep us looping forever.
+const defaultMaxPages = 100
+
 type ugcReq struct {
 	Query         string                 `json:"query"`
 	Variables     map[string]interface{} `json:"variables"`
@@ -49,14 +57,41 @@ query ugcArticleUserSolutionArticles(
 }
 `
 
+// ArticlePage is a single page of a user's solution articles.
+type ArticlePage struct {
+	Articles    []Article
+	TotalNum    int
+	HasNextPage bool
+}
+
+// FetchOptions controls how far back FetchAllUserSolutionArticles walks.
+//
+// Limit caps the number of articles returned (0 means no cap). Since stops
+// pagination once articles older than it are reached (zero means no cutoff).
+// When both are zero the user's full history is fetched.
+type FetchOptions struct {
+	Limit    int
+	Since    time.Time
+	PageSize int
+	MaxPages int
+}
+
 func FetchUserSolutionArticles(ctx context.Context, c *Client, username string, first int) ([]Article, error) {
+	page, err := FetchUserSolutionArticlesPage(ctx, c, username, 0, first)
+	if err != nil {
+		return nil, err
+	}
+	return page.Articles, nil
+}
+
+func FetchUserSolutionArticlesPage(ctx context.Context, c *Client, username string, skip, first int) (*ArticlePage, error) {
 	req := ugcReq{
 		Query:         queryUGCUserSolutions,
 		OperationName: "ugcArticleUserSolutionArticles",
 		Variables: map[string]interface{}{
 			"username": username,
 			"orderBy":  "MOST_RECENT",
-			"skip":     0,
+			"skip":     skip,
 			"first":    first,
 		},
 	}
@@ -66,13 +101,101 @@ func FetchUserSolutionArticles(ctx context.Context, c *Client, username string,
 		return nil, err
 	}
 	if len(env.Errors) > 0 {
-		return nil, fmt.Errorf("graphql error: %s", env.Errors[0].Message)
+		return nil, c.responseError(graphQLErrorFor(username, env.Errors))
 	}
 
-	edges := env.Data.UgcArticleUserSolutionArticles.Edges
-	out := make([]Article, 0, len(edges))
-	for _, e := range edges {
+	conn := env.Data.UgcArticleUserSolutionArticles
+	out := make([]Article, 0, len(conn.Edges))
+	for _, e := range conn.Edges {
 		out = append(out, e.Node)
 	}
+	return &ArticlePage{
+		Articles:    out,
+		TotalNum:    conn.TotalNum,
+		HasNextPage: conn.PageInfo.HasNextPage,
+	}, nil
+}
+
+// FetchAllUserSolutionArticles walks pageInfo.hasNextPage using skip offsets
+// until opts.Limit articles have been collected, an article older than
+// opts.Since is seen, or the user's history is exhausted. Articles are
+// returned most recent first.
+func FetchAllUserSolutionArticles(ctx context.Context, c *Client, username string, opts FetchOptions) ([]Article, error) {
+	pageSize := opts.PageSize
+	if pageSize <= 0 || pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+	if opts.Limit > 0 && opts.Limit < pageSize {
+		pageSize = opts.Limit
+	}
+	maxPages := opts.MaxPages
+	if maxPages <= 0 {
+		maxPages = defaultMaxPages
+	}
+
+	out := make([]Article, 0, pageSize)
+	skip := 0
+	for pageNum := 0; pageNum < maxPages; pageNum++ {
+		first := pageSize
+		if opts.Limit > 0 && opts.Limit-len(out) < first {
+			first = opts.Limit - len(out)
+		}
+
+		page, err := FetchUserSolutionArticlesPage(ctx, c, username, skip, first)
+		if err != nil {
+			return nil, fmt.Errorf("fetch page at skip %d: %w", skip, err)
+		}
+
+		for _, a := range page.Articles {
+			if !opts.Since.IsZero() {
+				if t, ok := a.CreatedTime(); ok && t.Before(opts.Since) {
+					return out, nil
+				}
+			}
+			out = append(out, a)
+			if opts.Limit > 0 && len(out) >= opts.Limit {
+				return out, nil
+			}
+		}
+
+		if !page.HasNextPage || len(page.Articles) == 0 {
+			return out, nil
+		}
+		skip += len(page.Articles)
+	}
 	return out, nil
 }
+
+const queryUGCSolutionArticle = `
+query ugcArticleSolutionArticle($topicId: ID) {
+  ugcArticleSolutionArticle(topicId: $topicId) {
+    uuid
+    content
+    updatedAt
+  }
+}
+`
+
+// FetchSolutionArticleContent fetches the Markdown body of a single solution
+// article.
+func FetchSolutionArticleContent(ctx context.Context, c *Client, topicID int) (*ArticleContent, error) {
+	req := ugcReq{
+		Query:         queryUGCSolutionArticle,
+		OperationName: "ugcArticleSolutionArticle",
+		Variables: map[string]interface{}{
+			"topicId": topicID,
+		},
+	}
+
+	var env UGCSolutionArticleEnvelope
+	if err := c.PostJSON(ctx, req, &env); err != nil {
+		return nil, err
+	}
+	if len(env.Errors) > 0 {
+		return nil, c.responseError(&GraphQLError{Errors: env.Errors})
+	}
+	if env.Data.UgcArticleSolutionArticle == nil {
+		return nil, fmt.Errorf("solution article %d not found", topicID)
+	}
+	return env.Data.UgcArticleSolutionArticle, nil
+}
@@ -0,0 +1,193 @@
+package leetcode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+var articleEpoch = time.Date(2026, 1, 7, 12, 0, 0, 0, time.UTC)
+
+// stubArticle returns the i-th most recent article of the stub history;
+// articles are an hour apart.
+func stubArticle(i int) Article {
+	return Article{
+		TopicID:   i + 1,
+		Title:     fmt.Sprintf("article %d", i),
+		CreatedAt: articleEpoch.Add(-time.Duration(i) * time.Hour).Format(time.RFC3339Nano),
+	}
+}
+
+type pageRequest struct {
+	Skip, First int
+}
+
+// articleServer serves the first total stub articles through the
+// ugcArticleUserSolutionArticles query. With endless set every page claims
+// another one follows. The skip/first of every request is recorded.
+type articleServer struct {
+	total   int
+	endless bool
+
+	mu       sync.Mutex
+	requests []pageRequest
+}
+
+func (s *articleServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Variables struct {
+			Skip  int `json:"skip"`
+			First int `json:"first"`
+		} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	skip, first := req.Variables.Skip, req.Variables.First
+	s.mu.Lock()
+	s.requests = append(s.requests, pageRequest{skip, first})
+	s.mu.Unlock()
+
+	type edge struct {
+		Node Article `json:"node"`
+	}
+	edges := []edge{}
+	for i := skip; i < skip+first && (s.endless || i < s.total); i++ {
+		edges = append(edges, edge{stubArticle(i)})
+	}
+	var env struct {
+		Data struct {
+			Conn struct {
+				TotalNum int `json:"totalNum"`
+				PageInfo struct {
+					HasNextPage bool `json:"hasNextPage"`
+				} `json:"pageInfo"`
+				Edges []edge `json:"edges"`
+			} `json:"ugcArticleUserSolutionArticles"`
+		} `json:"data"`
+	}
+	env.Data.Conn.TotalNum = s.total
+	env.Data.Conn.PageInfo.HasNextPage = s.endless || skip+len(edges) < s.total
+	env.Data.Conn.Edges = edges
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(env)
+}
+
+func TestFetchAllUserSolutionArticles(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int
+		endless   bool
+		opts      FetchOptions
+		wantCount int
+		wantReqs  []pageRequest
+	}{
+		{
+			name:      "limit below page size",
+			total:     10,
+			opts:      FetchOptions{Limit: 3},
+			wantCount: 3,
+			wantReqs:  []pageRequest{{0, 3}},
+		},
+		{
+			name:      "limit spans pages",
+			total:     10,
+			opts:      FetchOptions{Limit: 7, PageSize: 3},
+			wantCount: 7,
+			wantReqs:  []pageRequest{{0, 3}, {3, 3}, {6, 1}},
+		},
+		{
+			name:      "since stops at older article",
+			total:     10,
+			opts:      FetchOptions{Since: articleEpoch.Add(-4*time.Hour - time.Minute), PageSize: 3},
+			wantCount: 5,
+			wantReqs:  []pageRequest{{0, 3}, {3, 3}},
+		},
+		{
+			name:      "full history",
+			total:     5,
+			opts:      FetchOptions{PageSize: 2},
+			wantCount: 5,
+			wantReqs:  []pageRequest{{0, 2}, {2, 2}, {4, 2}},
+		},
+		{
+			name:      "page size capped",
+			total:     60,
+			opts:      FetchOptions{PageSize: 100},
+			wantCount: 60,
+			wantReqs:  []pageRequest{{0, MaxPageSize}, {MaxPageSize, MaxPageSize}},
+		},
+		{
+			name:      "max pages caps endless upstream",
+			endless:   true,
+			opts:      FetchOptions{PageSize: 2, MaxPages: 3},
+			wantCount: 6,
+			wantReqs:  []pageRequest{{0, 2}, {2, 2}, {4, 2}},
+		},
+		{
+			name:      "history ends on page boundary",
+			total:     4,
+			opts:      FetchOptions{PageSize: 2},
+			wantCount: 4,
+			wantReqs:  []pageRequest{{0, 2}, {2, 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &articleServer{total: tt.total, endless: tt.endless}
+			srv := httptest.NewServer(stub)
+			defer srv.Close()
+
+			c := New(srv.URL, "", "")
+			c.Retry = RetryPolicy{MaxAttempts: 1}
+			got, err := FetchAllUserSolutionArticles(context.Background(), c, "alice", tt.opts)
+			if err != nil {
+				t.Fatalf("FetchAllUserSolutionArticles: %v", err)
+			}
+			if len(got) != tt.wantCount {
+				t.Fatalf("got %d articles, want %d", len(got), tt.wantCount)
+			}
+			for i, a := range got {
+				if a.TopicID != i+1 {
+					t.Fatalf("article %d has topic %d, want %d", i, a.TopicID, i+1)
+				}
+			}
+			if !reflect.DeepEqual(stub.requests, tt.wantReqs) {
+				t.Errorf("requests = %v, want %v", stub.requests, tt.wantReqs)
+			}
+		})
+	}
+}
+
+func TestFetchAllUserSolutionArticlesEmptyPageWithNext(t *testing.T) {
+	// An upstream that claims another page but returns nothing must not
+	// be polled again.
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		edges := ""
+		if calls == 1 {
+			edges = `{"node":{"topicId":1}},{"node":{"topicId":2}}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"data":{"ugcArticleUserSolutionArticles":{"totalNum":9,"pageInfo":{"hasNextPage":true},"edges":[%s]}}}`, edges)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "", "")
+	c.Retry = RetryPolicy{MaxAttempts: 1}
+	got, err := FetchAllUserSolutionArticles(context.Background(), c, "alice", FetchOptions{})
+	if err != nil {
+		t.Fatalf("FetchAllUserSolutionArticles: %v", err)
+	}
+	if len(got) != 2 || calls != 2 {
+		t.Fatalf("got %d articles after %d calls, want 2 after 2", len(got), calls)
+	}
+}
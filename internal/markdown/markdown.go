@@ -0,0 +1,354 @@
+// Package markdown converts the Markdown used in LeetCode solution articles
+// to HTML that is safe to embed in feeds.
+//
+// Only a conservative subset is recognised: headings, paragraphs, fenced and
+// indented code, block quotes, lists, rules, emphasis, inline code, links and
+// images. Raw HTML in the source is always escaped and only http(s) and
+// site-relative URLs are emitted, so the output needs no further sanitizing.
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// baseURL resolves site-relative links and images in article bodies.
+const baseURL = "https://leetcode.com"
+
+var (
+	headingRe   = regexp.MustCompile(`^(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+	ruleRe      = regexp.MustCompile(`^(\*\s*){3,}$|^(-\s*){3,}$|^(_\s*){3,}$`)
+	unorderedRe = regexp.MustCompile(`^\s{0,3}[-*+]\s+(.*)$`)
+	orderedRe   = regexp.MustCompile(`^\s{0,3}\d{1,9}[.)]\s+(.*)$`)
+	langRe      = regexp.MustCompile(`^[A-Za-z0-9_+#-]+$`)
+)
+
+// ToHTML renders src as sanitized HTML.
+func ToHTML(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	lines := strings.Split(src, "\n")
+
+	var b strings.Builder
+	renderBlocks(&b, lines)
+	return strings.TrimSpace(b.String())
+}
+
+func renderBlocks(b *strings.Builder, lines []string) {
+	var para []string
+	flush := func() {
+		if len(para) == 0 {
+			return
+		}
+		b.WriteString("<p>")
+		b.WriteString(renderInline(strings.Join(para, "\n")))
+		b.WriteString("</p>\n")
+		para = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flush()
+			fence := trimmed[:3]
+			lang := codeLanguage(strings.TrimSpace(trimmed[3:]))
+			var code []string
+			i++
+			for ; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			writeCode(b, lang, code)
+
+		case strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t"):
+			if len(para) > 0 {
+				// indented continuation of a paragraph, not code
+				para = append(para, trimmed)
+				continue
+			}
+			var code []string
+			for ; i < len(lines); i++ {
+				l := lines[i]
+				if strings.HasPrefix(l, "    ") {
+					code = append(code, l[4:])
+				} else if strings.HasPrefix(l, "\t") {
+					code = append(code, l[1:])
+				} else if strings.TrimSpace(l) == "" {
+					code = append(code, "")
+				} else {
+					break
+				}
+			}
+			i--
+			for len(code) > 0 && code[len(code)-1] == "" {
+				code = code[:len(code)-1]
+			}
+			writeCode(b, "", code)
+
+		case headingRe.MatchString(trimmed):
+			flush()
+			m := headingRe.FindStringSubmatch(trimmed)
+			level := string(rune('0' + len(m[1])))
+			b.WriteString("<h" + level + ">")
+			b.WriteString(renderInline(m[2]))
+			b.WriteString("</h" + level + ">\n")
+
+		case ruleRe.MatchString(trimmed):
+			flush()
+			b.WriteString("<hr>\n")
+
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			var quoted []string
+			for ; i < len(lines); i++ {
+				t := strings.TrimSpace(lines[i])
+				if !strings.HasPrefix(t, ">") {
+					break
+				}
+				t = strings.TrimPrefix(t, ">")
+				quoted = append(quoted, strings.TrimPrefix(t, " "))
+			}
+			i--
+			b.WriteString("<blockquote>\n")
+			renderBlocks(b, quoted)
+			b.WriteString("</blockquote>\n")
+
+		case unorderedRe.MatchString(line):
+			flush()
+			i = writeList(b, lines, i, unorderedRe, "ul") - 1
+
+		case orderedRe.MatchString(line):
+			flush()
+			i = writeList(b, lines, i, orderedRe, "ol") - 1
+
+		default:
+			para = append(para, trimmed)
+		}
+	}
+	flush()
+}
+
+// writeList renders consecutive items matching re starting at lines[i] and
+// returns the index of the first line after the list. Lines indented under
+// an item are folded into its text.
+func writeList(b *strings.Builder, lines []string, i int, re *regexp.Regexp, tag string) int {
+	b.WriteString("<" + tag + ">\n")
+	for i < len(lines) {
+		m := re.FindStringSubmatch(lines[i])
+		if m == nil {
+			break
+		}
+		text := []string{m[1]}
+		i++
+		for i < len(lines) {
+			l := lines[i]
+			if strings.TrimSpace(l) == "" || re.MatchString(l) {
+				break
+			}
+			if !strings.HasPrefix(l, "  ") && !strings.HasPrefix(l, "\t") {
+				break
+			}
+			text = append(text, strings.TrimSpace(l))
+			i++
+		}
+		b.WriteString("<li>")
+		b.WriteString(renderInline(strings.Join(text, "\n")))
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// codeLanguage extracts the language from a fence info string. LeetCode
+// writes fences like "```Python3 []" for its tabbed code blocks.
+func codeLanguage(info string) string {
+	if f := strings.Fields(info); len(f) > 0 && langRe.MatchString(f[0]) {
+		return strings.ToLower(f[0])
+	}
+	return ""
+}
+
+func writeCode(b *strings.Builder, lang string, code []string) {
+	b.WriteString("<pre><code")
+	if lang != "" {
+		b.WriteString(` class="language-` + html.EscapeString(lang) + `"`)
+	}
+	b.WriteString(">")
+	b.WriteString(html.EscapeString(strings.Join(code, "\n")))
+	b.WriteString("</code></pre>\n")
+}
+
+// renderInline renders code spans, images, links and emphasis in s. All other
+// text is escaped.
+func renderInline(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte("\\`*_[]()#+-.!<>", s[i+1]) >= 0:
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '`':
+			n := runLength(s[i:], '`')
+			delim := s[i : i+n]
+			if end := strings.Index(s[i+n:], delim); end >= 0 {
+				code := strings.TrimSpace(s[i+n : i+n+end])
+				b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i += n + end + n
+				continue
+			}
+
+		case c == '!' && strings.HasPrefix(s[i:], "!["):
+			if text, dest, n, ok := parseLink(s[i+1:]); ok {
+				if u := safeURL(dest); u != "" {
+					b.WriteString(`<img src="` + html.EscapeString(u) + `" alt="` + html.EscapeString(text) + `">`)
+				} else {
+					b.WriteString(html.EscapeString(text))
+				}
+				i += 1 + n
+				continue
+			}
+
+		case c == '[':
+			if text, dest, n, ok := parseLink(s[i:]); ok {
+				if u := safeURL(dest); u != "" {
+					b.WriteString(`<a href="` + html.EscapeString(u) + `">` + renderInline(text) + `</a>`)
+				} else {
+					b.WriteString(renderInline(text))
+				}
+				i += n
+				continue
+			}
+
+		case c == '*' || c == '_':
+			n := runLength(s[i:], c)
+			if n > 2 {
+				n = 2
+			}
+			delim := s[i : i+n]
+			// intraword underscores (snake_case) are not emphasis
+			if c == '_' && i > 0 && isWordByte(s[i-1]) {
+				break
+			}
+			rest := s[i+n:]
+			if end := strings.Index(rest, delim); end > 0 && rest[0] != ' ' && rest[end-1] != ' ' {
+				if c == '_' && end+n < len(rest) && isWordByte(rest[end+n]) {
+					break
+				}
+				tag := "em"
+				if n == 2 {
+					tag = "strong"
+				}
+				b.WriteString("<" + tag + ">" + renderInline(rest[:end]) + "</" + tag + ">")
+				i += n + end + n
+				continue
+			}
+
+		case c == '\n':
+			b.WriteString("\n")
+			i++
+			continue
+		}
+
+		b.WriteString(html.EscapeString(s[i : i+1]))
+		i++
+	}
+	return b.String()
+}
+
+// parseLink parses "[text](dest)" at the start of s and returns the number
+// of bytes consumed.
+func parseLink(s string) (text, dest string, n int, ok bool) {
+	if !strings.HasPrefix(s, "[") {
+		return "", "", 0, false
+	}
+	depth := 0
+	closeText := -1
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closeText = i
+			}
+		}
+		if closeText >= 0 {
+			break
+		}
+	}
+	if closeText < 0 || closeText+1 >= len(s) || s[closeText+1] != '(' {
+		return "", "", 0, false
+	}
+	end := closingParen(s[closeText+2:])
+	if end < 0 {
+		return "", "", 0, false
+	}
+	dest = strings.TrimSpace(s[closeText+2 : closeText+2+end])
+	// drop an optional title: [text](url "title")
+	if j := strings.IndexAny(dest, " \t"); j >= 0 {
+		dest = dest[:j]
+	}
+	dest = strings.TrimSuffix(strings.TrimPrefix(dest, "<"), ">")
+	return s[1:closeText], dest, closeText + 2 + end + 1, true
+}
+
+// closingParen returns the index of the ")" that closes a link destination,
+// allowing balanced parentheses inside it, or -1.
+func closingParen(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case '\n':
+			return -1
+		}
+	}
+	return -1
+}
+
+// safeURL returns u if it is an http(s) URL, resolves site-relative paths
+// against leetcode.com, and returns "" for anything else (javascript:,
+// data:, ...).
+func safeURL(u string) string {
+	lower := strings.ToLower(u)
+	switch {
+	case strings.HasPrefix(lower, "https://"), strings.HasPrefix(lower, "http://"):
+		return u
+	case strings.HasPrefix(u, "/") && !strings.HasPrefix(u, "//"):
+		return baseURL + u
+	default:
+		return ""
+	}
+}
+
+func runLength(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,274 @@
+// Package metrics keeps counters, histograms and gauges in memory and
+// writes them in the Prometheus text exposition format (version 0.0.4).
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the output of Registry.Write.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are histogram upper bounds, in seconds, suited to request
+// latencies.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry holds the metrics of a process, written in registration order.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+type metric interface {
+	write(ctx context.Context, w *bufio.Writer) error
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+}
+
+// Write writes every metric to w. A gauge whose function fails is left out
+// and its error returned once the others are written.
+func (r *Registry) Write(ctx context.Context, w io.Writer) error {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	var errs []error
+	for _, m := range metrics {
+		if err := m.write(ctx, bw); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// desc is the name, help and label names shared by every kind of metric.
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (d *desc) header(w *bufio.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, kind)
+}
+
+func (d *desc) check(values []string) {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+}
+
+// series formats name{label="value",...}, with the histogram bucket bound
+// le as the last label when set.
+func (d *desc) series(name string, values []string, le string) string {
+	if len(values) == 0 && le == "" {
+		return name
+	}
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, d.labels[i], labelEscaper.Replace(v))
+	}
+	if le != "" {
+		if len(values) > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `le="%s"`, le)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// Counter is a monotonically increasing value per label combination.
+type Counter struct {
+	desc
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labels []string
+	v      float64
+}
+
+// Counter registers a counter with the given label names.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	c := &Counter{desc: desc{name, help, labels}, values: make(map[string]*counterValue)}
+	r.register(c)
+	return c
+}
+
+// Inc adds one to the series of labelValues, given in the order of the
+// registered label names.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *Counter) Add(v float64, labelValues ...string) {
+	c.check(labelValues)
+	key := strings.Join(labelValues, "\xff")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cv, ok := c.values[key]
+	if !ok {
+		cv = &counterValue{labels: append([]string(nil), labelValues...)}
+		c.values[key] = cv
+	}
+	cv.v += v
+}
+
+func (c *Counter) write(_ context.Context, w *bufio.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w, "counter")
+	for _, key := range sortedKeys(c.values) {
+		cv := c.values[key]
+		fmt.Fprintf(w, "%s %s\n", c.series(c.name, cv.labels, ""), formatFloat(cv.v))
+	}
+	return nil
+}
+
+// Histogram counts observations into cumulative buckets per label
+// combination.
+type Histogram struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labels []string
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// Histogram registers a histogram with the given upper bounds, which must
+// be sorted, and label names.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{desc: desc{name, help, labels}, buckets: buckets, values: make(map[string]*histogramValue)}
+	r.register(h)
+	return h
+}
+
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.check(labelValues)
+	key := strings.Join(labelValues, "\xff")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hv, ok := h.values[key]
+	if !ok {
+		hv = &histogramValue{labels: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = hv
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		hv.counts[i]++
+	}
+	hv.count++
+	hv.sum += v
+}
+
+func (h *Histogram) write(_ context.Context, w *bufio.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w, "histogram")
+	for _, key := range sortedKeys(h.values) {
+		hv := h.values[key]
+		var cumulative uint64
+		for i, le := range h.buckets {
+			cumulative += hv.counts[i]
+			fmt.Fprintf(w, "%s %d\n", h.series(h.name+"_bucket", hv.labels, formatFloat(le)), cumulative)
+		}
+		fmt.Fprintf(w, "%s %d\n", h.series(h.name+"_bucket", hv.labels, "+Inf"), hv.count)
+		fmt.Fprintf(w, "%s %s\n", h.series(h.name+"_sum", hv.labels, ""), formatFloat(hv.sum))
+		fmt.Fprintf(w, "%s %d\n", h.series(h.name+"_count", hv.labels, ""), hv.count)
+	}
+	return nil
+}
+
+// gaugeFunc reads its values when the registry is written.
+type gaugeFunc struct {
+	desc
+	kind string // gauge or counter
+	fn   func(ctx context.Context, set func(v float64, labelValues ...string)) error
+}
+
+// GaugeFunc registers a gauge whose values fn reports on every write by
+// calling set once per label combination.
+func (r *Registry) GaugeFunc(name, help string, fn func(ctx context.Context, set func(v float64, labelValues ...string)) error, labels ...string) {
+	r.register(&gaugeFunc{desc: desc{name, help, labels}, kind: "gauge", fn: fn})
+}
+
+// CounterFunc is GaugeFunc for values that only increase and are kept
+// elsewhere, such as totals since process start.
+func (r *Registry) CounterFunc(name, help string, fn func(ctx context.Context, set func(v float64, labelValues ...string)) error, labels ...string) {
+	r.register(&gaugeFunc{desc: desc{name, help, labels}, kind: "counter", fn: fn})
+}
+
+func (g *gaugeFunc) write(ctx context.Context, w *bufio.Writer) error {
+	var lines []string
+	err := g.fn(ctx, func(v float64, labelValues ...string) {
+		g.check(labelValues)
+		lines = append(lines, fmt.Sprintf("%s %s\n", g.series(g.name, labelValues, ""), formatFloat(v)))
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", g.name, err)
+	}
+	g.header(w, g.kind)
+	for _, line := range lines {
+		w.WriteString(line)
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,137 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/xml"
+	"time"
+)
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+type atomFeedXML struct {
+	XMLName  xml.Name       `xml:"feed"`
+	NS       string         `xml:"xmlns,attr"`
+	ID       string         `xml:"id"`
+	Title    string         `xml:"title"`
+	Subtitle string         `xml:"subtitle,omitempty"`
+	Updated  string         `xml:"updated"`
+	Links    []atomLinkXML  `xml:"link"`
+	Author   *atomPersonXML `xml:"author,omitempty"`
+	Entries  []atomEntryXML `xml:"entry"`
+}
+
+type atomEntryXML struct {
+	ID         string            `xml:"id"`
+	Title      string            `xml:"title"`
+	Links      []atomLinkXML     `xml:"link"`
+	Published  string            `xml:"published"`
+	Updated    string            `xml:"updated"`
+	Author     *atomPersonXML    `xml:"author,omitempty"`
+	Categories []atomCategoryXML `xml:"category"`
+	Summary    *atomTextXML      `xml:"summary,omitempty"`
+	Content    *atomTextXML      `xml:"content,omitempty"`
+}
+
+type atomCategoryXML struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomPersonXML struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomTextXML struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// RenderAtom renders feed as an Atom 1.0 document.
+func RenderAtom(feed Feed) ([]byte, error) {
+	updated := feed.Updated
+	entries := make([]atomEntryXML, 0, len(feed.Items))
+	missingAuthor := false
+	for _, it := range feed.Items {
+		itemUpdated := it.Updated
+		if itemUpdated.IsZero() {
+			itemUpdated = it.PubDate
+		}
+		if itemUpdated.After(updated) {
+			updated = itemUpdated
+		}
+
+		entry := atomEntryXML{
+			ID:        atomEntryID(it),
+			Title:     it.Title,
+			Published: it.PubDate.UTC().Format(time.RFC3339),
+			Updated:   itemUpdated.UTC().Format(time.RFC3339),
+		}
+		if it.Link != "" {
+			entry.Links = []atomLinkXML{{Href: it.Link, Rel: "alternate", Type: "text/html"}}
+		}
+		if it.Author != "" {
+			entry.Author = &atomPersonXML{Name: it.Author, URI: it.AuthorURI}
+		} else {
+			missingAuthor = true
+		}
+		for _, c := range it.Categories {
+			entry.Categories = append(entry.Categories, atomCategoryXML{Term: c})
+		}
+		if it.Summary != "" {
+			entry.Summary = &atomTextXML{Type: "text", Value: it.Summary}
+		}
+		if it.ContentHTML != "" {
+			entry.Content = &atomTextXML{Type: "html", Value: it.ContentHTML}
+		}
+		entries = append(entries, entry)
+	}
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+
+	links := make([]atomLinkXML, 0, 3)
+	if feed.SelfLink != "" {
+		links = append(links, atomLinkXML{Href: feed.SelfLink, Rel: "self", Type: "application/atom+xml"})
+	}
+	if feed.HubLink != "" {
+		links = append(links, atomLinkXML{Href: feed.HubLink, Rel: "hub"})
+	}
+	if feed.Link != "" {
+		links = append(links, atomLinkXML{Href: feed.Link, Rel: "alternate", Type: "text/html"})
+	}
+
+	id := feed.SelfLink
+	if id == "" {
+		id = feed.Link
+	}
+
+	out := atomFeedXML{
+		NS:       atomNamespace,
+		ID:       id,
+		Title:    feed.Title,
+		Subtitle: feed.Description,
+		Updated:  updated.UTC().Format(time.RFC3339),
+		Links:    links,
+		Entries:  entries,
+	}
+	// Atom requires an author on every entry; entries without one inherit
+	// the feed's.
+	if missingAuthor {
+		out.Author = &atomPersonXML{Name: feed.Title}
+	}
+
+	raw, err := xml.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Join([][]byte{[]byte(xml.Header), raw}, nil), nil
+}
+
+// atomEntryID prefers the item's permalink, which is a stable IRI, and falls
+// back to a URN built from its GUID.
+func atomEntryID(it Item) string {
+	if it.Link != "" {
+		return it.Link
+	}
+	return "urn:leetcode-rss:" + it.GUID
+}
@@ -3,17 +3,29 @@ package rss
 import "time"
 
 type Feed struct {
-	Title       string
-	Link        string
-	SelfLink    string
+	Title    string
+	Link     string
+	SelfLink string
+	// HubLink is the WebSub hub that pushes updates of SelfLink; empty when
+	// the feed is not published through a hub.
+	HubLink     string
 	Description string
+	Updated     time.Time
 	Items       []Item
 }
 
 type Item struct {
-	Title   string
-	Link    string
-	GUID    string
-	PubDate time.Time
-	Summary string
+	Title     string
+	Link      string
+	GUID      string
+	PubDate   time.Time
+	Updated   time.Time
+	Author    string
+	AuthorURI string
+	Summary   string
+	// Categories label the item, e.g. problem difficulty and topics.
+	Categories []string
+	// ContentHTML is the full item body as sanitized HTML; empty when the
+	// feed only carries summaries.
+	ContentHTML string
 }
@@ -0,0 +1,61 @@
+package rss
+
+import "fmt"
+
+// Format identifies a feed serialization.
+type Format string
+
+const (
+	FormatRSS  Format = "rss"
+	FormatAtom Format = "atom"
+	FormatJSON Format = "json"
+)
+
+// Formats lists every supported format in the order feeds are rendered.
+var Formats = []Format{FormatRSS, FormatAtom, FormatJSON}
+
+// FormatFromExt maps a URL extension such as ".atom" to its format.
+func FormatFromExt(ext string) (Format, bool) {
+	for _, f := range Formats {
+		if f.Ext() == ext {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+func (f Format) Ext() string {
+	switch f {
+	case FormatAtom:
+		return ".atom"
+	case FormatJSON:
+		return ".json"
+	default:
+		return ".xml"
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatAtom:
+		return "application/atom+xml"
+	case FormatJSON:
+		return "application/feed+json"
+	default:
+		return "application/rss+xml"
+	}
+}
+
+// RenderFormat renders feed in the given format.
+func RenderFormat(feed Feed, f Format) ([]byte, error) {
+	switch f {
+	case FormatRSS:
+		return Render(feed)
+	case FormatAtom:
+		return RenderAtom(feed)
+	case FormatJSON:
+		return RenderJSONFeed(feed)
+	default:
+		return nil, fmt.Errorf("unsupported feed format %q", f)
+	}
+}
@@ -0,0 +1,91 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+const jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+
+type jsonFeed struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url,omitempty"`
+	FeedURL     string           `json:"feed_url,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Items       []jsonFeedItem   `json:"items"`
+	Authors     []jsonFeedAuthor `json:"authors,omitempty"`
+	Hubs        []jsonFeedHub    `json:"hubs,omitempty"`
+}
+
+type jsonFeedHub struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url,omitempty"`
+	Title         string           `json:"title,omitempty"`
+	Summary       string           `json:"summary,omitempty"`
+	ContentHTML   string           `json:"content_html,omitempty"`
+	ContentText   string           `json:"content_text,omitempty"`
+	DatePublished string           `json:"date_published,omitempty"`
+	DateModified  string           `json:"date_modified,omitempty"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+// RenderJSONFeed renders feed as a JSON Feed 1.1 document.
+func RenderJSONFeed(feed Feed) ([]byte, error) {
+	items := make([]jsonFeedItem, 0, len(feed.Items))
+	for _, it := range feed.Items {
+		item := jsonFeedItem{
+			ID:            it.GUID,
+			URL:           it.Link,
+			Title:         it.Title,
+			Summary:       it.Summary,
+			ContentHTML:   it.ContentHTML,
+			Tags:          it.Categories,
+			DatePublished: it.PubDate.UTC().Format(time.RFC3339),
+		}
+		// JSON Feed requires at least one of content_html and content_text
+		if item.ContentHTML == "" {
+			item.ContentText = it.Summary
+		}
+		if !it.Updated.IsZero() {
+			item.DateModified = it.Updated.UTC().Format(time.RFC3339)
+		}
+		if it.Author != "" {
+			item.Authors = []jsonFeedAuthor{{Name: it.Author, URL: it.AuthorURI}}
+		}
+		items = append(items, item)
+	}
+
+	out := jsonFeed{
+		Version:     jsonFeedVersion,
+		Title:       feed.Title,
+		HomePageURL: feed.Link,
+		FeedURL:     feed.SelfLink,
+		Description: feed.Description,
+		Items:       items,
+	}
+	if feed.HubLink != "" {
+		out.Hubs = []jsonFeedHub{{Type: "WebSub", URL: feed.HubLink}}
+	}
+	// content_html is meant to be read as HTML, so leave <, > and & as is
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
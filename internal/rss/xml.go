@@ -7,26 +7,33 @@ import (
 )
 
 type rssXML struct {
-	XMLName xml.Name   `xml:"rss"`
-	Version string     `xml:"version,attr"`
-	AtomNS  string     `xml:"xmlns:atom,attr,omitempty"`
-	Channel channelXML `xml:"channel"`
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	AtomNS    string     `xml:"xmlns:atom,attr,omitempty"`
+	ContentNS string     `xml:"xmlns:content,attr,omitempty"`
+	Channel   channelXML `xml:"channel"`
 }
 
 type channelXML struct {
-	AtomLink    *atomLinkXML `xml:"atom:link,omitempty"`
-	Title       string       `xml:"title"`
-	Link        string       `xml:"link"`
-	Description string       `xml:"description"`
-	Items       []itemXML    `xml:"item"`
+	AtomLinks   []atomLinkXML `xml:"atom:link"`
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	Description string        `xml:"description"`
+	Items       []itemXML     `xml:"item"`
 }
 
 type itemXML struct {
-	Title       string  `xml:"title"`
-	Link        string  `xml:"link"`
-	GUID        guidXML `xml:"guid"`
-	PubDate     string  `xml:"pubDate"`
-	Description string  `xml:"description,omitempty"`
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	GUID        guidXML   `xml:"guid"`
+	PubDate     string    `xml:"pubDate"`
+	Description string    `xml:"description,omitempty"`
+	Categories  []string  `xml:"category"`
+	Content     *cdataXML `xml:"content:encoded,omitempty"`
+}
+
+type cdataXML struct {
+	Value string `xml:",cdata"`
 }
 
 type atomLinkXML struct {
@@ -42,7 +49,13 @@ type guidXML struct {
 
 func Render(feed Feed) ([]byte, error) {
 	items := make([]itemXML, 0, len(feed.Items))
+	var contentNS string
 	for _, it := range feed.Items {
+		var content *cdataXML
+		if it.ContentHTML != "" {
+			contentNS = "http://purl.org/rss/1.0/modules/content/"
+			content = &cdataXML{Value: it.ContentHTML}
+		}
 		items = append(items, itemXML{
 			Title: it.Title,
 			Link:  it.Link,
@@ -52,25 +65,33 @@ func Render(feed Feed) ([]byte, error) {
 			},
 			PubDate:     it.PubDate.UTC().Format(time.RFC1123Z),
 			Description: it.Summary,
+			Categories:  it.Categories,
+			Content:     content,
 		})
 	}
 
-	var atomNS string
-	var atomLink *atomLinkXML
+	var atomLinks []atomLinkXML
 	if feed.SelfLink != "" {
-		atomNS = "http://www.w3.org/2005/Atom"
-		atomLink = &atomLinkXML{
+		atomLinks = append(atomLinks, atomLinkXML{
 			Href: feed.SelfLink,
 			Rel:  "self",
 			Type: "application/rss+xml",
-		}
+		})
+	}
+	if feed.HubLink != "" {
+		atomLinks = append(atomLinks, atomLinkXML{Href: feed.HubLink, Rel: "hub"})
+	}
+	var atomNS string
+	if len(atomLinks) > 0 {
+		atomNS = atomNamespace
 	}
 
 	out := rssXML{
-		Version: "2.0",
-		AtomNS:  atomNS,
+		Version:   "2.0",
+		AtomNS:    atomNS,
+		ContentNS: contentNS,
 		Channel: channelXML{
-			AtomLink:    atomLink,
+			AtomLinks:   atomLinks,
 			Title:       feed.Title,
 			Link:        feed.Link,
 			Description: feed.Description,
@@ -0,0 +1,301 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"leetcode-rss/internal/leetcode"
+)
+
+// Observer receives the duration and error of a Store call by method name.
+type Observer func(method string, d time.Duration, err error)
+
+// Instrument returns s with every call but Close reported to observe.
+func Instrument(s Store, observe Observer) Store {
+	return &instrumented{next: s, observe: observe}
+}
+
+type instrumented struct {
+	next    Store
+	observe Observer
+}
+
+var _ Store = (*instrumented)(nil)
+
+func (s *instrumented) done(method string, start time.Time, err *error) {
+	s.observe(method, time.Since(start), *err)
+}
+
+func (s *instrumented) CreateUser(ctx context.Context, user *User) (err error) {
+	defer s.done("CreateUser", time.Now(), &err)
+	return s.next.CreateUser(ctx, user)
+}
+
+func (s *instrumented) GetUserByEmail(ctx context.Context, email string) (_ *User, err error) {
+	defer s.done("GetUserByEmail", time.Now(), &err)
+	return s.next.GetUserByEmail(ctx, email)
+}
+
+func (s *instrumented) GetUserByID(ctx context.Context, id string) (_ *User, err error) {
+	defer s.done("GetUserByID", time.Now(), &err)
+	return s.next.GetUserByID(ctx, id)
+}
+
+func (s *instrumented) GetUserByProvider(ctx context.Context, provider, subject string) (_ *User, err error) {
+	defer s.done("GetUserByProvider", time.Now(), &err)
+	return s.next.GetUserByProvider(ctx, provider, subject)
+}
+
+func (s *instrumented) CreateFeed(ctx context.Context, feed *Feed) (err error) {
+	defer s.done("CreateFeed", time.Now(), &err)
+	return s.next.CreateFeed(ctx, feed)
+}
+
+func (s *instrumented) GetFeedByID(ctx context.Context, id string) (_ *Feed, err error) {
+	defer s.done("GetFeedByID", time.Now(), &err)
+	return s.next.GetFeedByID(ctx, id)
+}
+
+func (s *instrumented) GetFeedByIDAndSecret(ctx context.Context, id, secret string) (_ *Feed, err error) {
+	defer s.done("GetFeedByIDAndSecret", time.Now(), &err)
+	return s.next.GetFeedByIDAndSecret(ctx, id, secret)
+}
+
+func (s *instrumented) UpdateFeed(ctx context.Context, feed *Feed) (err error) {
+	defer s.done("UpdateFeed", time.Now(), &err)
+	return s.next.UpdateFeed(ctx, feed)
+}
+
+func (s *instrumented) DeleteFeed(ctx context.Context, id string) (err error) {
+	defer s.done("DeleteFeed", time.Now(), &err)
+	return s.next.DeleteFeed(ctx, id)
+}
+
+func (s *instrumented) ListFeedsByUserID(ctx context.Context, userID string) (_ []Feed, err error) {
+	defer s.done("ListFeedsByUserID", time.Now(), &err)
+	return s.next.ListFeedsByUserID(ctx, userID)
+}
+
+func (s *instrumented) CountFeedsByUserID(ctx context.Context, userID string) (_ int, err error) {
+	defer s.done("CountFeedsByUserID", time.Now(), &err)
+	return s.next.CountFeedsByUserID(ctx, userID)
+}
+
+func (s *instrumented) GetFeedCache(ctx context.Context, feedID, format string) (_ *FeedCache, err error) {
+	defer s.done("GetFeedCache", time.Now(), &err)
+	return s.next.GetFeedCache(ctx, feedID, format)
+}
+
+func (s *instrumented) GetFeedCacheStatus(ctx context.Context, feedID string) (_ *FeedCache, err error) {
+	defer s.done("GetFeedCacheStatus", time.Now(), &err)
+	return s.next.GetFeedCacheStatus(ctx, feedID)
+}
+
+func (s *instrumented) ListFeedCacheStatusByUserID(ctx context.Context, userID string) (_ map[string]*FeedCache, err error) {
+	defer s.done("ListFeedCacheStatusByUserID", time.Now(), &err)
+	return s.next.ListFeedCacheStatusByUserID(ctx, userID)
+}
+
+func (s *instrumented) SetFeedCache(ctx context.Context, cache *FeedCache) (err error) {
+	defer s.done("SetFeedCache", time.Now(), &err)
+	return s.next.SetFeedCache(ctx, cache)
+}
+
+func (s *instrumented) SetFeedCacheError(ctx context.Context, feedID, lastError string, userStatus []UsernameStatus) (err error) {
+	defer s.done("SetFeedCacheError", time.Now(), &err)
+	return s.next.SetFeedCacheError(ctx, feedID, lastError, userStatus)
+}
+
+func (s *instrumented) ListFeedsDueForRefresh(ctx context.Context, expiringBefore, now time.Time, limit int) (_ []Feed, err error) {
+	defer s.done("ListFeedsDueForRefresh", time.Now(), &err)
+	return s.next.ListFeedsDueForRefresh(ctx, expiringBefore, now, limit)
+}
+
+func (s *instrumented) DeferFeedRefresh(ctx context.Context, feedID string, until time.Time) (err error) {
+	defer s.done("DeferFeedRefresh", time.Now(), &err)
+	return s.next.DeferFeedRefresh(ctx, feedID, until)
+}
+
+func (s *instrumented) InvalidateFeedCache(ctx context.Context, feedID string) (err error) {
+	defer s.done("InvalidateFeedCache", time.Now(), &err)
+	return s.next.InvalidateFeedCache(ctx, feedID)
+}
+
+func (s *instrumented) GetFeedCacheETags(ctx context.Context, feedID string) (_ map[string]string, err error) {
+	defer s.done("GetFeedCacheETags", time.Now(), &err)
+	return s.next.GetFeedCacheETags(ctx, feedID)
+}
+
+func (s *instrumented) GetUserArticleCache(ctx context.Context, username string) (_ *UserArticleCache, err error) {
+	defer s.done("GetUserArticleCache", time.Now(), &err)
+	return s.next.GetUserArticleCache(ctx, username)
+}
+
+func (s *instrumented) SetUserArticleCache(ctx context.Context, cache *UserArticleCache) (err error) {
+	defer s.done("SetUserArticleCache", time.Now(), &err)
+	return s.next.SetUserArticleCache(ctx, cache)
+}
+
+func (s *instrumented) UpsertArticles(ctx context.Context, username string, articles []leetcode.Article, seenAt time.Time) (_ []string, err error) {
+	defer s.done("UpsertArticles", time.Now(), &err)
+	return s.next.UpsertArticles(ctx, username, articles, seenAt)
+}
+
+func (s *instrumented) ListArticlesByUsername(ctx context.Context, username string, limit int, since *time.Time) (_ []Article, err error) {
+	defer s.done("ListArticlesByUsername", time.Now(), &err)
+	return s.next.ListArticlesByUsername(ctx, username, limit, since)
+}
+
+func (s *instrumented) GetArticleBodies(ctx context.Context, uuids []string) (_ map[string]*ArticleBody, err error) {
+	defer s.done("GetArticleBodies", time.Now(), &err)
+	return s.next.GetArticleBodies(ctx, uuids)
+}
+
+func (s *instrumented) SetArticleBody(ctx context.Context, body *ArticleBody) (err error) {
+	defer s.done("SetArticleBody", time.Now(), &err)
+	return s.next.SetArticleBody(ctx, body)
+}
+
+func (s *instrumented) UpsertWebSubSubscription(ctx context.Context, sub *WebSubSubscription) (err error) {
+	defer s.done("UpsertWebSubSubscription", time.Now(), &err)
+	return s.next.UpsertWebSubSubscription(ctx, sub)
+}
+
+func (s *instrumented) DeleteWebSubSubscription(ctx context.Context, topic, callback string) (err error) {
+	defer s.done("DeleteWebSubSubscription", time.Now(), &err)
+	return s.next.DeleteWebSubSubscription(ctx, topic, callback)
+}
+
+func (s *instrumented) DeleteWebSubSubscriptionsByFeed(ctx context.Context, feedID string) (err error) {
+	defer s.done("DeleteWebSubSubscriptionsByFeed", time.Now(), &err)
+	return s.next.DeleteWebSubSubscriptionsByFeed(ctx, feedID)
+}
+
+func (s *instrumented) ListWebSubSubscriptions(ctx context.Context, feedID, format string, now time.Time) (_ []WebSubSubscription, err error) {
+	defer s.done("ListWebSubSubscriptions", time.Now(), &err)
+	return s.next.ListWebSubSubscriptions(ctx, feedID, format, now)
+}
+
+func (s *instrumented) CountWebSubSubscriptions(ctx context.Context, feedID, format, exceptTopic, exceptCallback string, now time.Time) (_ int, err error) {
+	defer s.done("CountWebSubSubscriptions", time.Now(), &err)
+	return s.next.CountWebSubSubscriptions(ctx, feedID, format, exceptTopic, exceptCallback, now)
+}
+
+func (s *instrumented) CreateWebhook(ctx context.Context, hook *Webhook) (err error) {
+	defer s.done("CreateWebhook", time.Now(), &err)
+	return s.next.CreateWebhook(ctx, hook)
+}
+
+func (s *instrumented) GetWebhookByID(ctx context.Context, id string) (_ *Webhook, err error) {
+	defer s.done("GetWebhookByID", time.Now(), &err)
+	return s.next.GetWebhookByID(ctx, id)
+}
+
+func (s *instrumented) UpdateWebhook(ctx context.Context, hook *Webhook) (err error) {
+	defer s.done("UpdateWebhook", time.Now(), &err)
+	return s.next.UpdateWebhook(ctx, hook)
+}
+
+func (s *instrumented) DeleteWebhook(ctx context.Context, id string) (err error) {
+	defer s.done("DeleteWebhook", time.Now(), &err)
+	return s.next.DeleteWebhook(ctx, id)
+}
+
+func (s *instrumented) ListWebhooksByFeedID(ctx context.Context, feedID string) (_ []Webhook, err error) {
+	defer s.done("ListWebhooksByFeedID", time.Now(), &err)
+	return s.next.ListWebhooksByFeedID(ctx, feedID)
+}
+
+func (s *instrumented) RecordFeedItems(ctx context.Context, feedID string, guids []string, seenAt time.Time) (newGUIDs []string, baseline bool, err error) {
+	defer s.done("RecordFeedItems", time.Now(), &err)
+	return s.next.RecordFeedItems(ctx, feedID, guids, seenAt)
+}
+
+func (s *instrumented) ResetFeedItems(ctx context.Context, feedID string) (err error) {
+	defer s.done("ResetFeedItems", time.Now(), &err)
+	return s.next.ResetFeedItems(ctx, feedID)
+}
+
+func (s *instrumented) AddWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) (err error) {
+	defer s.done("AddWebhookDelivery", time.Now(), &err)
+	return s.next.AddWebhookDelivery(ctx, delivery)
+}
+
+func (s *instrumented) ListWebhookDeliveries(ctx context.Context, webhookID string, limit int) (_ []WebhookDelivery, err error) {
+	defer s.done("ListWebhookDeliveries", time.Now(), &err)
+	return s.next.ListWebhookDeliveries(ctx, webhookID, limit)
+}
+
+func (s *instrumented) GetDigest(ctx context.Context, feedID string) (_ *Digest, err error) {
+	defer s.done("GetDigest", time.Now(), &err)
+	return s.next.GetDigest(ctx, feedID)
+}
+
+func (s *instrumented) UpsertDigest(ctx context.Context, digest *Digest) (err error) {
+	defer s.done("UpsertDigest", time.Now(), &err)
+	return s.next.UpsertDigest(ctx, digest)
+}
+
+func (s *instrumented) UpdateDigestState(ctx context.Context, digest *Digest) (err error) {
+	defer s.done("UpdateDigestState", time.Now(), &err)
+	return s.next.UpdateDigestState(ctx, digest)
+}
+
+func (s *instrumented) DeleteDigest(ctx context.Context, feedID string) (err error) {
+	defer s.done("DeleteDigest", time.Now(), &err)
+	return s.next.DeleteDigest(ctx, feedID)
+}
+
+func (s *instrumented) ListDigestsDue(ctx context.Context, now time.Time, limit int) (_ []Digest, err error) {
+	defer s.done("ListDigestsDue", time.Now(), &err)
+	return s.next.ListDigestsDue(ctx, now, limit)
+}
+
+func (s *instrumented) ListDigestRecipients(ctx context.Context, feedID string) (_ []DigestRecipient, err error) {
+	defer s.done("ListDigestRecipients", time.Now(), &err)
+	return s.next.ListDigestRecipients(ctx, feedID)
+}
+
+func (s *instrumented) SetDigestRecipients(ctx context.Context, feedID string, recipients []DigestRecipient) (err error) {
+	defer s.done("SetDigestRecipients", time.Now(), &err)
+	return s.next.SetDigestRecipients(ctx, feedID, recipients)
+}
+
+func (s *instrumented) ConfirmDigestRecipient(ctx context.Context, token string, at time.Time) (_ *DigestRecipient, err error) {
+	defer s.done("ConfirmDigestRecipient", time.Now(), &err)
+	return s.next.ConfirmDigestRecipient(ctx, token, at)
+}
+
+func (s *instrumented) UnsubscribeDigestRecipient(ctx context.Context, token string, at time.Time) (_ *DigestRecipient, err error) {
+	defer s.done("UnsubscribeDigestRecipient", time.Now(), &err)
+	return s.next.UnsubscribeDigestRecipient(ctx, token, at)
+}
+
+func (s *instrumented) ListSentDigestItems(ctx context.Context, feedID string) (_ map[string]map[string]bool, err error) {
+	defer s.done("ListSentDigestItems", time.Now(), &err)
+	return s.next.ListSentDigestItems(ctx, feedID)
+}
+
+func (s *instrumented) RecordDigestItems(ctx context.Context, feedID, email string, guids []string, sentAt, pruneBefore time.Time) (err error) {
+	defer s.done("RecordDigestItems", time.Now(), &err)
+	return s.next.RecordDigestItems(ctx, feedID, email, guids, sentAt, pruneBefore)
+}
+
+func (s *instrumented) CountStats(ctx context.Context) (_ *Stats, err error) {
+	defer s.done("CountStats", time.Now(), &err)
+	return s.next.CountStats(ctx)
+}
+
+func (s *instrumented) Ping(ctx context.Context) (err error) {
+	defer s.done("Ping", time.Now(), &err)
+	return s.next.Ping(ctx)
+}
+
+func (s *instrumented) MigrationVersion(ctx context.Context) (_ int64, err error) {
+	defer s.done("MigrationVersion", time.Now(), &err)
+	return s.next.MigrationVersion(ctx)
+}
+
+func (s *instrumented) Close() error {
+	return s.next.Close()
+}
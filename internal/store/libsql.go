@@ -8,6 +8,8 @@ import (
 	"strings"
 	"time"
 
+	"leetcode-rss/internal/leetcode"
+
 	_ "github.com/tursodatabase/go-libsql"
 )
 
@@ -23,6 +25,13 @@ func NewSQLStore(dsn string) (*SQLStore, error) {
 		return nil, fmt.Errorf("open database: %w", err)
 	}
 
+	// a local SQLite file allows a single writer; sharing one connection
+	// serializes concurrent feed builds instead of failing with "database is
+	// locked", and keeps per-connection PRAGMAs in effect
+	if strings.HasPrefix(dsn, "file:") {
+		db.SetMaxOpenConns(1)
+	}
+
 	// enable foreign keys for SQLite
 	if _, err := db.Exec("PRAGMA foreign_keys=ON"); err != nil {
 		// Ignore error for remote TursoDB (may not support PRAGMA)
@@ -41,6 +50,35 @@ func (s *SQLStore) Close() error {
 	return s.db.Close()
 }
 
+func (s *SQLStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *SQLStore) CountStats(ctx context.Context) (*Stats, error) {
+	var stats Stats
+	err := s.db.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM users),
+			(SELECT COUNT(*) FROM feeds),
+			(SELECT COUNT(*) FROM feeds WHERE enabled = 1)
+	`).Scan(&stats.Users, &stats.Feeds, &stats.EnabledFeeds)
+	if err != nil {
+		return nil, fmt.Errorf("count users and feeds: %w", err)
+	}
+	return &stats, nil
+}
+
+// MigrationVersion returns the latest migration goose has applied. goose
+// deletes the row of a migration it rolls back.
+func (s *SQLStore) MigrationVersion(ctx context.Context) (int64, error) {
+	var version int64
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("read migration version: %w", err)
+	}
+	return version, nil
+}
+
 // returns the database connection for migrations and tests
 func (s *SQLStore) DB() *sql.DB {
 	return s.db
@@ -116,15 +154,26 @@ func (s *SQLStore) scanUser(row *sql.Row) (*User, error) {
 
 // --- Feed operations ---
 
+const feedColumns = `id, user_id, name, secret, usernames, first_per_user, since, include_content, filter, group_by, timezone, enabled, created_at, updated_at`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
 func (s *SQLStore) CreateFeed(ctx context.Context, feed *Feed) error {
 	usernamesJSON, err := json.Marshal(feed.Usernames)
 	if err != nil {
 		return fmt.Errorf("marshal usernames: %w", err)
 	}
+	filterJSON, err := marshalFeedFilter(feed.Filter)
+	if err != nil {
+		return err
+	}
 
 	query := `
-		INSERT INTO feeds (id, user_id, name, secret, usernames, first_per_user, enabled, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO feeds (` + feedColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	_, err = s.db.ExecContext(ctx, query,
 		feed.ID,
@@ -133,6 +182,11 @@ func (s *SQLStore) CreateFeed(ctx context.Context, feed *Feed) error {
 		feed.Secret,
 		string(usernamesJSON),
 		feed.FirstPerUser,
+		formatNullTime(feed.Since),
+		boolToInt(feed.IncludeContent),
+		filterJSON,
+		feed.GroupBy,
+		feed.Timezone,
 		boolToInt(feed.Enabled),
 		feed.CreatedAt.Format(time.RFC3339),
 		feed.UpdatedAt.Format(time.RFC3339),
@@ -148,7 +202,7 @@ func (s *SQLStore) CreateFeed(ctx context.Context, feed *Feed) error {
 
 func (s *SQLStore) GetFeedByID(ctx context.Context, id string) (*Feed, error) {
 	query := `
-		SELECT id, user_id, name, secret, usernames, first_per_user, enabled, created_at, updated_at
+		SELECT ` + feedColumns + `
 		FROM feeds WHERE id = ?
 	`
 	return s.scanFeed(s.db.QueryRowContext(ctx, query, id))
@@ -156,7 +210,7 @@ func (s *SQLStore) GetFeedByID(ctx context.Context, id string) (*Feed, error) {
 
 func (s *SQLStore) GetFeedByIDAndSecret(ctx context.Context, id, secret string) (*Feed, error) {
 	query := `
-		SELECT id, user_id, name, secret, usernames, first_per_user, enabled, created_at, updated_at
+		SELECT ` + feedColumns + `
 		FROM feeds WHERE id = ? AND secret = ?
 	`
 	return s.scanFeed(s.db.QueryRowContext(ctx, query, id, secret))
@@ -167,10 +221,14 @@ func (s *SQLStore) UpdateFeed(ctx context.Context, feed *Feed) error {
 	if err != nil {
 		return fmt.Errorf("marshal usernames: %w", err)
 	}
+	filterJSON, err := marshalFeedFilter(feed.Filter)
+	if err != nil {
+		return err
+	}
 
 	query := `
 		UPDATE feeds 
-		SET name = ?, secret = ?, usernames = ?, first_per_user = ?, enabled = ?, updated_at = ?
+		SET name = ?, secret = ?, usernames = ?, first_per_user = ?, since = ?, include_content = ?, filter = ?, group_by = ?, timezone = ?, enabled = ?, updated_at = ?
 		WHERE id = ?
 	`
 	result, err := s.db.ExecContext(ctx, query,
@@ -178,6 +236,11 @@ func (s *SQLStore) UpdateFeed(ctx context.Context, feed *Feed) error {
 		feed.Secret,
 		string(usernamesJSON),
 		feed.FirstPerUser,
+		formatNullTime(feed.Since),
+		boolToInt(feed.IncludeContent),
+		filterJSON,
+		feed.GroupBy,
+		feed.Timezone,
 		boolToInt(feed.Enabled),
 		feed.UpdatedAt.Format(time.RFC3339),
 		feed.ID,
@@ -207,7 +270,7 @@ func (s *SQLStore) DeleteFeed(ctx context.Context, id string) error {
 
 func (s *SQLStore) ListFeedsByUserID(ctx context.Context, userID string) ([]Feed, error) {
 	query := `
-		SELECT id, user_id, name, secret, usernames, first_per_user, enabled, created_at, updated_at
+		SELECT ` + feedColumns + `
 		FROM feeds WHERE user_id = ? ORDER BY created_at DESC
 	`
 	rows, err := s.db.QueryContext(ctx, query, userID)
@@ -238,9 +301,23 @@ func (s *SQLStore) CountFeedsByUserID(ctx context.Context, userID string) (int,
 }
 
 func (s *SQLStore) scanFeed(row *sql.Row) (*Feed, error) {
+	feed, err := scanFeedColumns(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return feed, err
+}
+
+func (s *SQLStore) scanFeedFromRows(rows *sql.Rows) (*Feed, error) {
+	return scanFeedColumns(rows)
+}
+
+func scanFeedColumns(row rowScanner) (*Feed, error) {
 	var feed Feed
 	var usernamesJSON string
-	var enabled int
+	var since sql.NullString
+	var filterJSON sql.NullString
+	var includeContent, enabled int
 	var createdAt, updatedAt string
 
 	err := row.Scan(
@@ -250,12 +327,17 @@ func (s *SQLStore) scanFeed(row *sql.Row) (*Feed, error) {
 		&feed.Secret,
 		&usernamesJSON,
 		&feed.FirstPerUser,
+		&since,
+		&includeContent,
+		&filterJSON,
+		&feed.GroupBy,
+		&feed.Timezone,
 		&enabled,
 		&createdAt,
 		&updatedAt,
 	)
 	if err == sql.ErrNoRows {
-		return nil, ErrNotFound
+		return nil, err
 	}
 	if err != nil {
 		return nil, fmt.Errorf("scan feed: %w", err)
@@ -264,94 +346,176 @@ func (s *SQLStore) scanFeed(row *sql.Row) (*Feed, error) {
 	if err := json.Unmarshal([]byte(usernamesJSON), &feed.Usernames); err != nil {
 		return nil, fmt.Errorf("unmarshal usernames: %w", err)
 	}
+	feed.Since = parseNullTime(since)
+	feed.IncludeContent = includeContent == 1
+	if filterJSON.Valid {
+		var filter FeedFilter
+		if err := json.Unmarshal([]byte(filterJSON.String), &filter); err != nil {
+			return nil, fmt.Errorf("unmarshal feed filter: %w", err)
+		}
+		feed.Filter = &filter
+	}
 	feed.Enabled = enabled == 1
 	feed.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
 	feed.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
 	return &feed, nil
 }
 
-func (s *SQLStore) scanFeedFromRows(rows *sql.Rows) (*Feed, error) {
-	var feed Feed
-	var usernamesJSON string
-	var enabled int
-	var createdAt, updatedAt string
-
-	err := rows.Scan(
-		&feed.ID,
-		&feed.UserID,
-		&feed.Name,
-		&feed.Secret,
-		&usernamesJSON,
-		&feed.FirstPerUser,
-		&enabled,
-		&createdAt,
-		&updatedAt,
-	)
+// marshalFeedFilter encodes a feed filter column; filters that filter
+// nothing are stored as NULL.
+func marshalFeedFilter(f *FeedFilter) (sql.NullString, error) {
+	if f.IsZero() {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(f)
 	if err != nil {
-		return nil, fmt.Errorf("scan feed: %w", err)
+		return sql.NullString{}, fmt.Errorf("marshal feed filter: %w", err)
 	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
 
-	if err := json.Unmarshal([]byte(usernamesJSON), &feed.Usernames); err != nil {
-		return nil, fmt.Errorf("unmarshal usernames: %w", err)
+// --- Feed cache operations ---
+
+// feedCacheStatusColumns are the feed_cache columns other than the document.
+const feedCacheStatusColumns = `feed_id, format, etag, last_built_at, expires_at, last_error, user_status, item_count, build_duration_ms, consecutive_failures, next_attempt_at`
+
+func (s *SQLStore) GetFeedCache(ctx context.Context, feedID, format string) (*FeedCache, error) {
+	query := `
+		SELECT ` + feedCacheStatusColumns + `, xml
+		FROM feed_cache WHERE feed_id = ? AND format = ?
+	`
+	var xml []byte
+	cache, err := scanFeedCacheColumns(s.db.QueryRowContext(ctx, query, feedID, format), &xml)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
 	}
-	feed.Enabled = enabled == 1
-	feed.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
-	feed.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
-	return &feed, nil
+	if err != nil {
+		return nil, err
+	}
+	cache.XML = xml
+	return cache, nil
 }
 
-// --- Feed cache operations ---
+// GetFeedCacheStatus returns the build status of a feed without its
+// documents. Every format is built together, so the RSS row stands for all.
+func (s *SQLStore) GetFeedCacheStatus(ctx context.Context, feedID string) (*FeedCache, error) {
+	query := `
+		SELECT ` + feedCacheStatusColumns + `
+		FROM feed_cache WHERE feed_id = ? AND format = 'rss'
+	`
+	cache, err := scanFeedCacheColumns(s.db.QueryRowContext(ctx, query, feedID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return cache, err
+}
 
-func (s *SQLStore) GetFeedCache(ctx context.Context, feedID string) (*FeedCache, error) {
+// ListFeedCacheStatusByUserID returns the build status of every built feed
+// owned by userID, keyed by feed ID. Feeds never built are absent.
+func (s *SQLStore) ListFeedCacheStatusByUserID(ctx context.Context, userID string) (map[string]*FeedCache, error) {
 	query := `
-		SELECT feed_id, xml, etag, last_built_at, expires_at, last_error
-		FROM feed_cache WHERE feed_id = ?
+		SELECT ` + feedCacheStatusColumns + `
+		FROM feed_cache
+		WHERE format = 'rss' AND feed_id IN (SELECT id FROM feeds WHERE user_id = ?)
 	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query feed cache status: %w", err)
+	}
+	defer rows.Close()
+
+	statuses := make(map[string]*FeedCache)
+	for rows.Next() {
+		cache, err := scanFeedCacheColumns(rows)
+		if err != nil {
+			return nil, err
+		}
+		statuses[cache.FeedID] = cache
+	}
+	return statuses, rows.Err()
+}
+
+// scanFeedCacheColumns scans feedCacheStatusColumns followed by extra.
+func scanFeedCacheColumns(row rowScanner, extra ...any) (*FeedCache, error) {
 	var cache FeedCache
-	var lastBuiltAt, expiresAt sql.NullString
+	var etag, lastBuiltAt, expiresAt, userStatus, nextAttemptAt sql.NullString
+	var itemCount, buildDurationMS sql.NullInt64
 
-	err := s.db.QueryRowContext(ctx, query, feedID).Scan(
+	dest := []any{
 		&cache.FeedID,
-		&cache.XML,
-		&cache.ETag,
+		&cache.Format,
+		&etag,
 		&lastBuiltAt,
 		&expiresAt,
 		&cache.LastError,
-	)
+		&userStatus,
+		&itemCount,
+		&buildDurationMS,
+		&cache.Failures,
+		&nextAttemptAt,
+	}
+	err := row.Scan(append(dest, extra...)...)
 	if err == sql.ErrNoRows {
-		return nil, ErrNotFound
+		return nil, err
 	}
 	if err != nil {
 		return nil, fmt.Errorf("scan feed cache: %w", err)
 	}
 
+	cache.ETag = etag.String
 	if lastBuiltAt.Valid {
 		cache.LastBuiltAt, _ = time.Parse(time.RFC3339, lastBuiltAt.String)
 	}
 	if expiresAt.Valid {
 		cache.ExpiresAt, _ = time.Parse(time.RFC3339, expiresAt.String)
 	}
+	if userStatus.Valid {
+		if err := json.Unmarshal([]byte(userStatus.String), &cache.UserStatus); err != nil {
+			return nil, fmt.Errorf("unmarshal feed cache user status: %w", err)
+		}
+	}
+	cache.ItemCount = int(itemCount.Int64)
+	cache.BuildTime = time.Duration(buildDurationMS.Int64) * time.Millisecond
+	if nextAttemptAt.Valid {
+		if t, err := time.Parse(time.RFC3339, nextAttemptAt.String); err == nil {
+			cache.NextAttemptAt = &t
+		}
+	}
 	return &cache, nil
 }
 
 func (s *SQLStore) SetFeedCache(ctx context.Context, cache *FeedCache) error {
+	userStatus, err := marshalUserStatus(cache.UserStatus)
+	if err != nil {
+		return err
+	}
+
 	query := `
-		INSERT INTO feed_cache (feed_id, xml, etag, last_built_at, expires_at, last_error)
-		VALUES (?, ?, ?, ?, ?, ?)
-		ON CONFLICT(feed_id) DO UPDATE SET
+		INSERT INTO feed_cache (feed_id, format, xml, etag, last_built_at, expires_at, last_error, user_status, item_count, build_duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(feed_id, format) DO UPDATE SET
 			xml = excluded.xml,
 			etag = excluded.etag,
 			last_built_at = excluded.last_built_at,
 			expires_at = excluded.expires_at,
-			last_error = excluded.last_error
+			last_error = excluded.last_error,
+			user_status = excluded.user_status,
+			item_count = excluded.item_count,
+			build_duration_ms = excluded.build_duration_ms,
+			consecutive_failures = 0,
+			next_attempt_at = NULL
 	`
-	_, err := s.db.ExecContext(ctx, query,
+	_, err = s.db.ExecContext(ctx, query,
 		cache.FeedID,
+		cache.Format,
 		cache.XML,
 		cache.ETag,
-		cache.LastBuiltAt.Format(time.RFC3339),
-		cache.ExpiresAt.Format(time.RFC3339),
+		cache.LastBuiltAt.UTC().Format(time.RFC3339),
+		cache.ExpiresAt.UTC().Format(time.RFC3339),
 		cache.LastError,
+		userStatus,
+		cache.ItemCount,
+		cache.BuildTime.Milliseconds(),
 	)
 	if err != nil {
 		return fmt.Errorf("upsert feed cache: %w", err)
@@ -359,6 +523,99 @@ func (s *SQLStore) SetFeedCache(ctx context.Context, cache *FeedCache) error {
 	return nil
 }
 
+// SetFeedCacheError records a failed build on every cached format without
+// discarding the last good documents, so readers can keep being served
+// stale content. A placeholder row is created for a feed that was never built.
+// Consecutive failures are counted until the next successful build.
+func (s *SQLStore) SetFeedCacheError(ctx context.Context, feedID, lastError string, userStatus []UsernameStatus) error {
+	status, err := marshalUserStatus(userStatus)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE feed_cache SET last_error = ?, user_status = ?, consecutive_failures = consecutive_failures + 1 WHERE feed_id = ?`
+	result, err := s.db.ExecContext(ctx, query, lastError, status, feedID)
+	if err != nil {
+		return fmt.Errorf("set feed cache error: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows > 0 {
+		return nil
+	}
+
+	query = `
+		INSERT INTO feed_cache (feed_id, last_error, user_status, consecutive_failures) VALUES (?, ?, ?, 1)
+		ON CONFLICT(feed_id, format) DO UPDATE SET
+			last_error = excluded.last_error,
+			user_status = excluded.user_status,
+			consecutive_failures = consecutive_failures + 1
+	`
+	if _, err := s.db.ExecContext(ctx, query, feedID, lastError, status); err != nil {
+		return fmt.Errorf("set feed cache error: %w", err)
+	}
+	return nil
+}
+
+// marshalUserStatus encodes the user_status column; no statuses is NULL.
+func marshalUserStatus(statuses []UsernameStatus) (sql.NullString, error) {
+	if len(statuses) == 0 {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(statuses)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("marshal feed cache user status: %w", err)
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+// ListFeedsDueForRefresh returns enabled feeds whose earliest cached format
+// expires at or before expiringBefore, or that have never been built.
+// Feeds backing off after failed builds are left out until their next
+// attempt; feeds without failures come first, then the soonest to expire.
+func (s *SQLStore) ListFeedsDueForRefresh(ctx context.Context, expiringBefore, now time.Time, limit int) ([]Feed, error) {
+	query := `
+		SELECT ` + feedColumns + `
+		FROM feeds
+		LEFT JOIN (
+			SELECT feed_id, MIN(expires_at) AS expires_at, MAX(next_attempt_at) AS next_attempt_at, MAX(consecutive_failures) AS failures
+			FROM feed_cache GROUP BY feed_id
+		) c ON c.feed_id = feeds.id
+		WHERE enabled = 1
+			AND (c.expires_at IS NULL OR c.expires_at <= ?)
+			AND (c.next_attempt_at IS NULL OR c.next_attempt_at <= ?)
+		ORDER BY COALESCE(c.failures, 0) > 0, c.expires_at IS NOT NULL, c.expires_at
+		LIMIT ?
+	`
+	rows, err := s.db.QueryContext(ctx, query,
+		expiringBefore.UTC().Format(time.RFC3339),
+		now.UTC().Format(time.RFC3339),
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query feeds due for refresh: %w", err)
+	}
+	defer rows.Close()
+
+	var feeds []Feed
+	for rows.Next() {
+		feed, err := s.scanFeedFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		feeds = append(feeds, *feed)
+	}
+	return feeds, rows.Err()
+}
+
+// DeferFeedRefresh keeps the background refresh away from a feed until the
+// given time. A successful build clears it.
+func (s *SQLStore) DeferFeedRefresh(ctx context.Context, feedID string, until time.Time) error {
+	query := `UPDATE feed_cache SET next_attempt_at = ? WHERE feed_id = ?`
+	if _, err := s.db.ExecContext(ctx, query, until.UTC().Format(time.RFC3339), feedID); err != nil {
+		return fmt.Errorf("defer feed refresh: %w", err)
+	}
+	return nil
+}
+
 func (s *SQLStore) InvalidateFeedCache(ctx context.Context, feedID string) error {
 	query := `DELETE FROM feed_cache WHERE feed_id = ?`
 	_, err := s.db.ExecContext(ctx, query, feedID)
@@ -368,14 +625,1035 @@ func (s *SQLStore) InvalidateFeedCache(ctx context.Context, feedID string) error
 	return nil
 }
 
-// isUniqueConstraintError checks if the error is a unique constraint violation.
-func isUniqueConstraintError(err error) bool {
-	if err == nil {
-		return false
+// GetFeedCacheETags returns the ETag of every cached format of a feed,
+// keyed by format.
+func (s *SQLStore) GetFeedCacheETags(ctx context.Context, feedID string) (map[string]string, error) {
+	query := `SELECT format, etag FROM feed_cache WHERE feed_id = ?`
+	rows, err := s.db.QueryContext(ctx, query, feedID)
+	if err != nil {
+		return nil, fmt.Errorf("query feed cache etags: %w", err)
 	}
-	msg := err.Error()
-	return strings.Contains(msg, "UNIQUE constraint failed") ||
-		strings.Contains(msg, "constraint failed")
+	defer rows.Close()
+
+	etags := make(map[string]string)
+	for rows.Next() {
+		var format string
+		var etag sql.NullString
+		if err := rows.Scan(&format, &etag); err != nil {
+			return nil, fmt.Errorf("scan feed cache etag: %w", err)
+		}
+		etags[format] = etag.String
+	}
+	return etags, rows.Err()
+}
+
+// --- User article cache operations ---
+
+func (s *SQLStore) GetUserArticleCache(ctx context.Context, username string) (*UserArticleCache, error) {
+	query := `
+		SELECT username, fetch_limit, since, articles, fetched_at, expires_at
+		FROM user_article_cache WHERE username = ?
+	`
+	var cache UserArticleCache
+	var since sql.NullString
+	var articlesJSON, fetchedAt, expiresAt string
+
+	err := s.db.QueryRowContext(ctx, query, username).Scan(
+		&cache.Username,
+		&cache.FetchLimit,
+		&since,
+		&articlesJSON,
+		&fetchedAt,
+		&expiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan user article cache: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(articlesJSON), &cache.Articles); err != nil {
+		return nil, fmt.Errorf("unmarshal cached articles: %w", err)
+	}
+	cache.Since = parseNullTime(since)
+	cache.FetchedAt, _ = time.Parse(time.RFC3339, fetchedAt)
+	cache.ExpiresAt, _ = time.Parse(time.RFC3339, expiresAt)
+	return &cache, nil
+}
+
+func (s *SQLStore) SetUserArticleCache(ctx context.Context, cache *UserArticleCache) error {
+	articlesJSON, err := json.Marshal(cache.Articles)
+	if err != nil {
+		return fmt.Errorf("marshal cached articles: %w", err)
+	}
+
+	query := `
+		INSERT INTO user_article_cache (username, fetch_limit, since, articles, fetched_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(username) DO UPDATE SET
+			fetch_limit = excluded.fetch_limit,
+			since = excluded.since,
+			articles = excluded.articles,
+			fetched_at = excluded.fetched_at,
+			expires_at = excluded.expires_at
+	`
+	_, err = s.db.ExecContext(ctx, query,
+		cache.Username,
+		cache.FetchLimit,
+		formatNullTime(cache.Since),
+		string(articlesJSON),
+		cache.FetchedAt.Format(time.RFC3339),
+		cache.ExpiresAt.Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("upsert user article cache: %w", err)
+	}
+	return nil
+}
+
+// --- Article history operations ---
+
+// publishedAtLayout is fixed-width UTC so published_at sorts lexically.
+const publishedAtLayout = "2006-01-02T15:04:05.000000Z"
+
+// UpsertArticles records articles fetched for username. Articles already in
+// history have their mutable fields and last_seen_at refreshed. It returns
+// the UUIDs of articles that were not seen before.
+func (s *SQLStore) UpsertArticles(ctx context.Context, username string, articles []leetcode.Article, seenAt time.Time) ([]string, error) {
+	if len(articles) == 0 {
+		return nil, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin article upsert: %w", err)
+	}
+	defer tx.Rollback()
+
+	insertQuery := `
+		INSERT INTO articles (uuid, topic_id, username, title, slug, created_at, published_at, hit_count, question_slug, question_title, first_seen_at, last_seen_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(uuid) DO NOTHING
+	`
+	updateQuery := `
+		UPDATE articles
+		SET title = ?, slug = ?, hit_count = ?, question_slug = ?, question_title = ?, last_seen_at = ?
+		WHERE uuid = ?
+	`
+	seen := seenAt.UTC().Format(time.RFC3339)
+
+	var inserted []string
+	for _, a := range articles {
+		if a.UUID == "" {
+			continue
+		}
+		var publishedAt sql.NullString
+		if t, ok := a.CreatedTime(); ok {
+			publishedAt = sql.NullString{String: t.UTC().Format(publishedAtLayout), Valid: true}
+		}
+
+		result, err := tx.ExecContext(ctx, insertQuery,
+			a.UUID,
+			a.TopicID,
+			username,
+			a.Title,
+			a.Slug,
+			a.CreatedAt,
+			publishedAt,
+			a.HitCount,
+			a.QuestionSlug,
+			a.QuestionTitle,
+			seen,
+			seen,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("insert article %s: %w", a.UUID, err)
+		}
+		if rows, _ := result.RowsAffected(); rows > 0 {
+			inserted = append(inserted, a.UUID)
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, updateQuery,
+			a.Title,
+			a.Slug,
+			a.HitCount,
+			a.QuestionSlug,
+			a.QuestionTitle,
+			seen,
+			a.UUID,
+		); err != nil {
+			return nil, fmt.Errorf("update article %s: %w", a.UUID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit article upsert: %w", err)
+	}
+	return inserted, nil
+}
+
+// ListArticlesByUsername returns up to limit of username's most recent
+// articles published at or after since. A limit of 0 means no limit and a
+// nil since means no cutoff.
+func (s *SQLStore) ListArticlesByUsername(ctx context.Context, username string, limit int, since *time.Time) ([]Article, error) {
+	query := `
+		SELECT uuid, topic_id, username, title, slug, created_at, hit_count, question_slug, question_title, first_seen_at, last_seen_at
+		FROM articles
+		WHERE username = ? AND (? IS NULL OR published_at >= ?)
+		ORDER BY published_at IS NULL, published_at DESC, topic_id DESC
+		LIMIT ?
+	`
+	var sinceArg sql.NullString
+	if since != nil {
+		sinceArg = sql.NullString{String: since.UTC().Format(publishedAtLayout), Valid: true}
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, username, sinceArg, sinceArg, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query articles: %w", err)
+	}
+	defer rows.Close()
+
+	var articles []Article
+	for rows.Next() {
+		var a Article
+		var firstSeenAt, lastSeenAt string
+		if err := rows.Scan(
+			&a.UUID,
+			&a.TopicID,
+			&a.Username,
+			&a.Title,
+			&a.Slug,
+			&a.CreatedAt,
+			&a.HitCount,
+			&a.QuestionSlug,
+			&a.QuestionTitle,
+			&firstSeenAt,
+			&lastSeenAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan article: %w", err)
+		}
+		a.FirstSeenAt, _ = time.Parse(time.RFC3339, firstSeenAt)
+		a.LastSeenAt, _ = time.Parse(time.RFC3339, lastSeenAt)
+		articles = append(articles, a)
+	}
+	return articles, rows.Err()
+}
+
+// --- Article body operations ---
+
+// articleBodyBatch bounds the number of UUIDs bound into one IN (...) query.
+const articleBodyBatch = 500
+
+// GetArticleBodies returns the cached bodies for uuids, keyed by UUID.
+// UUIDs without a cached body are absent from the map.
+func (s *SQLStore) GetArticleBodies(ctx context.Context, uuids []string) (map[string]*ArticleBody, error) {
+	bodies := make(map[string]*ArticleBody, len(uuids))
+	for start := 0; start < len(uuids); start += articleBodyBatch {
+		end := min(start+articleBodyBatch, len(uuids))
+		if err := s.getArticleBodies(ctx, uuids[start:end], bodies); err != nil {
+			return nil, err
+		}
+	}
+	return bodies, nil
+}
+
+func (s *SQLStore) getArticleBodies(ctx context.Context, uuids []string, into map[string]*ArticleBody) error {
+	args := make([]any, 0, len(uuids))
+	for _, id := range uuids {
+		args = append(args, id)
+	}
+	query := `
+		SELECT uuid, topic_id, content, updated_at, fetched_at
+		FROM article_bodies WHERE uuid IN (?` + strings.Repeat(", ?", len(uuids)-1) + `)
+	`
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("query article bodies: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var body ArticleBody
+		var updatedAt sql.NullString
+		var fetchedAt string
+		if err := rows.Scan(&body.UUID, &body.TopicID, &body.Content, &updatedAt, &fetchedAt); err != nil {
+			return fmt.Errorf("scan article body: %w", err)
+		}
+		body.UpdatedAt = updatedAt.String
+		body.FetchedAt, _ = time.Parse(time.RFC3339, fetchedAt)
+		into[body.UUID] = &body
+	}
+	return rows.Err()
+}
+
+func (s *SQLStore) SetArticleBody(ctx context.Context, body *ArticleBody) error {
+	query := `
+		INSERT INTO article_bodies (uuid, topic_id, content, updated_at, fetched_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(uuid) DO UPDATE SET
+			topic_id = excluded.topic_id,
+			content = excluded.content,
+			updated_at = excluded.updated_at,
+			fetched_at = excluded.fetched_at
+	`
+	var updatedAt sql.NullString
+	if body.UpdatedAt != "" {
+		updatedAt = sql.NullString{String: body.UpdatedAt, Valid: true}
+	}
+	_, err := s.db.ExecContext(ctx, query,
+		body.UUID,
+		body.TopicID,
+		body.Content,
+		updatedAt,
+		body.FetchedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("upsert article body: %w", err)
+	}
+	return nil
+}
+
+// --- WebSub subscription operations ---
+
+// UpsertWebSubSubscription stores a verified subscription, renewing the
+// lease and secret of an existing (topic, callback) pair.
+func (s *SQLStore) UpsertWebSubSubscription(ctx context.Context, sub *WebSubSubscription) error {
+	query := `
+		INSERT INTO websub_subscriptions (topic, callback, feed_id, format, secret, expires_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(topic, callback) DO UPDATE SET
+			feed_id = excluded.feed_id,
+			format = excluded.format,
+			secret = excluded.secret,
+			expires_at = excluded.expires_at,
+			updated_at = excluded.updated_at
+	`
+	var secret sql.NullString
+	if sub.Secret != "" {
+		secret = sql.NullString{String: sub.Secret, Valid: true}
+	}
+	_, err := s.db.ExecContext(ctx, query,
+		sub.Topic,
+		sub.Callback,
+		sub.FeedID,
+		sub.Format,
+		secret,
+		sub.ExpiresAt.UTC().Format(time.RFC3339),
+		sub.CreatedAt.UTC().Format(time.RFC3339),
+		sub.UpdatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("upsert websub subscription: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) DeleteWebSubSubscription(ctx context.Context, topic, callback string) error {
+	query := `DELETE FROM websub_subscriptions WHERE topic = ? AND callback = ?`
+	result, err := s.db.ExecContext(ctx, query, topic, callback)
+	if err != nil {
+		return fmt.Errorf("delete websub subscription: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteWebSubSubscriptionsByFeed drops every subscription to a feed, e.g.
+// after its secret is rotated and the subscribed topic URLs stop working.
+func (s *SQLStore) DeleteWebSubSubscriptionsByFeed(ctx context.Context, feedID string) error {
+	query := `DELETE FROM websub_subscriptions WHERE feed_id = ?`
+	if _, err := s.db.ExecContext(ctx, query, feedID); err != nil {
+		return fmt.Errorf("delete websub subscriptions: %w", err)
+	}
+	return nil
+}
+
+// CountWebSubSubscriptions counts the unexpired subscriptions to one format
+// of a feed, whatever URL they spell the topic with, other than the
+// (exceptTopic, exceptCallback) one, so a renewal does not count against
+// itself.
+func (s *SQLStore) CountWebSubSubscriptions(ctx context.Context, feedID, format, exceptTopic, exceptCallback string, now time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM websub_subscriptions
+		WHERE feed_id = ? AND format = ? AND NOT (topic = ? AND callback = ?) AND expires_at > ?
+	`
+	var count int
+	err := s.db.QueryRowContext(ctx, query, feedID, format, exceptTopic, exceptCallback, now.UTC().Format(time.RFC3339)).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count websub subscriptions: %w", err)
+	}
+	return count, nil
+}
+
+// ListWebSubSubscriptions returns the subscriptions to one format of a feed
+// whose lease has not expired at now. Expired subscriptions are deleted.
+func (s *SQLStore) ListWebSubSubscriptions(ctx context.Context, feedID, format string, now time.Time) ([]WebSubSubscription, error) {
+	nowStr := now.UTC().Format(time.RFC3339)
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM websub_subscriptions WHERE feed_id = ? AND expires_at <= ?`, feedID, nowStr); err != nil {
+		return nil, fmt.Errorf("delete expired websub subscriptions: %w", err)
+	}
+
+	query := `
+		SELECT topic, callback, feed_id, format, secret, expires_at, created_at, updated_at
+		FROM websub_subscriptions
+		WHERE feed_id = ? AND format = ?
+		ORDER BY created_at
+	`
+	rows, err := s.db.QueryContext(ctx, query, feedID, format)
+	if err != nil {
+		return nil, fmt.Errorf("query websub subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []WebSubSubscription
+	for rows.Next() {
+		var sub WebSubSubscription
+		var secret sql.NullString
+		var expiresAt, createdAt, updatedAt string
+		if err := rows.Scan(&sub.Topic, &sub.Callback, &sub.FeedID, &sub.Format, &secret, &expiresAt, &createdAt, &updatedAt); err != nil {
+			return nil, fmt.Errorf("scan websub subscription: %w", err)
+		}
+		sub.Secret = secret.String
+		sub.ExpiresAt, _ = time.Parse(time.RFC3339, expiresAt)
+		sub.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		sub.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// --- Webhook operations ---
+
+const webhookColumns = `id, feed_id, url, secret, payload_format, enabled, created_at, updated_at`
+
+func (s *SQLStore) CreateWebhook(ctx context.Context, hook *Webhook) error {
+	query := `
+		INSERT INTO feed_webhooks (` + webhookColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := s.db.ExecContext(ctx, query,
+		hook.ID,
+		hook.FeedID,
+		hook.URL,
+		hook.Secret,
+		hook.PayloadFormat,
+		boolToInt(hook.Enabled),
+		hook.CreatedAt.UTC().Format(time.RFC3339),
+		hook.UpdatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		if isUniqueConstraintError(err) {
+			return ErrAlreadyExists
+		}
+		return fmt.Errorf("insert webhook: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) GetWebhookByID(ctx context.Context, id string) (*Webhook, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM feed_webhooks WHERE id = ?
+	`
+	hook, err := scanWebhookColumns(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return hook, err
+}
+
+func (s *SQLStore) UpdateWebhook(ctx context.Context, hook *Webhook) error {
+	query := `
+		UPDATE feed_webhooks
+		SET url = ?, secret = ?, payload_format = ?, enabled = ?, updated_at = ?
+		WHERE id = ?
+	`
+	result, err := s.db.ExecContext(ctx, query,
+		hook.URL,
+		hook.Secret,
+		hook.PayloadFormat,
+		boolToInt(hook.Enabled),
+		hook.UpdatedAt.UTC().Format(time.RFC3339),
+		hook.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("update webhook: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) DeleteWebhook(ctx context.Context, id string) error {
+	query := `DELETE FROM feed_webhooks WHERE id = ?`
+	result, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) ListWebhooksByFeedID(ctx context.Context, feedID string) ([]Webhook, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM feed_webhooks WHERE feed_id = ?
+		ORDER BY created_at
+	`
+	rows, err := s.db.QueryContext(ctx, query, feedID)
+	if err != nil {
+		return nil, fmt.Errorf("query webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var hooks []Webhook
+	for rows.Next() {
+		hook, err := scanWebhookColumns(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, *hook)
+	}
+	return hooks, rows.Err()
+}
+
+func scanWebhookColumns(row rowScanner) (*Webhook, error) {
+	var hook Webhook
+	var enabled int
+	var createdAt, updatedAt string
+	err := row.Scan(
+		&hook.ID,
+		&hook.FeedID,
+		&hook.URL,
+		&hook.Secret,
+		&hook.PayloadFormat,
+		&enabled,
+		&createdAt,
+		&updatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan webhook: %w", err)
+	}
+	hook.Enabled = enabled == 1
+	hook.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	hook.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	return &hook, nil
+}
+
+// RecordFeedItems marks guids as produced by a feed and returns the ones it
+// had not produced before, in the given order. When the feed has no
+// recorded items yet, every guid is recorded as a baseline: newGUIDs is
+// empty and baseline is true.
+func (s *SQLStore) RecordFeedItems(ctx context.Context, feedID string, guids []string, seenAt time.Time) ([]string, bool, error) {
+	if len(guids) == 0 {
+		return nil, false, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("begin feed item record: %w", err)
+	}
+	defer tx.Rollback()
+
+	var known int
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM feed_items WHERE feed_id = ?)`, feedID).Scan(&known); err != nil {
+		return nil, false, fmt.Errorf("query feed items: %w", err)
+	}
+	baseline := known == 0
+
+	query := `
+		INSERT INTO feed_items (feed_id, guid, first_seen_at) VALUES (?, ?, ?)
+		ON CONFLICT(feed_id, guid) DO NOTHING
+	`
+	seen := seenAt.UTC().Format(time.RFC3339)
+	var inserted []string
+	for _, guid := range guids {
+		result, err := tx.ExecContext(ctx, query, feedID, guid, seen)
+		if err != nil {
+			return nil, false, fmt.Errorf("insert feed item %s: %w", guid, err)
+		}
+		if rows, _ := result.RowsAffected(); rows > 0 && !baseline {
+			inserted = append(inserted, guid)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("commit feed item record: %w", err)
+	}
+	return inserted, baseline, nil
+}
+
+// ResetFeedItems forgets the items a feed has produced, so its next
+// recorded build becomes a new baseline.
+func (s *SQLStore) ResetFeedItems(ctx context.Context, feedID string) error {
+	query := `DELETE FROM feed_items WHERE feed_id = ?`
+	if _, err := s.db.ExecContext(ctx, query, feedID); err != nil {
+		return fmt.Errorf("delete feed items: %w", err)
+	}
+	return nil
+}
+
+// webhookDeliveriesKept is how many deliveries are logged per webhook.
+const webhookDeliveriesKept = 100
+
+// AddWebhookDelivery logs a delivery and trims the webhook's log to the
+// most recent webhookDeliveriesKept entries.
+func (s *SQLStore) AddWebhookDelivery(ctx context.Context, d *WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, item_guid, success, attempts, response_status, error, duration_ms, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	var status sql.NullInt64
+	if d.ResponseStatus != 0 {
+		status = sql.NullInt64{Int64: int64(d.ResponseStatus), Valid: true}
+	}
+	var errMsg sql.NullString
+	if d.Error != "" {
+		errMsg = sql.NullString{String: d.Error, Valid: true}
+	}
+	result, err := s.db.ExecContext(ctx, query,
+		d.WebhookID,
+		d.ItemGUID,
+		boolToInt(d.Success),
+		d.Attempts,
+		status,
+		errMsg,
+		d.Duration.Milliseconds(),
+		d.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("insert webhook delivery: %w", err)
+	}
+	d.ID, _ = result.LastInsertId()
+
+	trim := `
+		DELETE FROM webhook_deliveries
+		WHERE webhook_id = ? AND id NOT IN (
+			SELECT id FROM webhook_deliveries WHERE webhook_id = ? ORDER BY id DESC LIMIT ?
+		)
+	`
+	if _, err := s.db.ExecContext(ctx, trim, d.WebhookID, d.WebhookID, webhookDeliveriesKept); err != nil {
+		return fmt.Errorf("trim webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries returns up to limit of a webhook's most recent
+// deliveries, newest first.
+func (s *SQLStore) ListWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]WebhookDelivery, error) {
+	query := `
+		SELECT id, webhook_id, item_guid, success, attempts, response_status, error, duration_ms, created_at
+		FROM webhook_deliveries WHERE webhook_id = ?
+		ORDER BY id DESC
+		LIMIT ?
+	`
+	rows, err := s.db.QueryContext(ctx, query, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []WebhookDelivery
+	for rows.Next() {
+		var d WebhookDelivery
+		var success int
+		var status sql.NullInt64
+		var errMsg sql.NullString
+		var durationMS int64
+		var createdAt string
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.ItemGUID, &success, &d.Attempts, &status, &errMsg, &durationMS, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan webhook delivery: %w", err)
+		}
+		d.Success = success == 1
+		d.ResponseStatus = int(status.Int64)
+		d.Error = errMsg.String
+		d.Duration = time.Duration(durationMS) * time.Millisecond
+		d.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// --- Digest operations ---
+
+const digestColumns = `feed_id, frequency, enabled, next_run_at, covered_until, last_sent_at, last_item_count, last_error, created_at, updated_at`
+
+func (s *SQLStore) GetDigest(ctx context.Context, feedID string) (*Digest, error) {
+	query := `
+		SELECT ` + digestColumns + `
+		FROM feed_digests WHERE feed_id = ?
+	`
+	digest, err := scanDigestColumns(s.db.QueryRowContext(ctx, query, feedID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return digest, err
+}
+
+// UpsertDigest saves a feed's digest settings. The scheduling state of an
+// existing digest is kept, except for NextRunAt.
+func (s *SQLStore) UpsertDigest(ctx context.Context, d *Digest) error {
+	query := `
+		INSERT INTO feed_digests (feed_id, frequency, enabled, next_run_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(feed_id) DO UPDATE SET
+			frequency = excluded.frequency,
+			enabled = excluded.enabled,
+			next_run_at = excluded.next_run_at,
+			updated_at = excluded.updated_at
+	`
+	_, err := s.db.ExecContext(ctx, query,
+		d.FeedID,
+		d.Frequency,
+		boolToInt(d.Enabled),
+		d.NextRunAt.UTC().Format(time.RFC3339),
+		d.CreatedAt.UTC().Format(time.RFC3339),
+		d.UpdatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("upsert digest: %w", err)
+	}
+	return nil
+}
+
+// UpdateDigestState saves the outcome of a digest run.
+func (s *SQLStore) UpdateDigestState(ctx context.Context, d *Digest) error {
+	query := `
+		UPDATE feed_digests
+		SET next_run_at = ?, covered_until = ?, last_sent_at = ?, last_item_count = ?, last_error = ?, updated_at = ?
+		WHERE feed_id = ?
+	`
+	result, err := s.db.ExecContext(ctx, query,
+		d.NextRunAt.UTC().Format(time.RFC3339),
+		formatNullTime(d.CoveredUntil),
+		formatNullTime(d.LastSentAt),
+		d.LastItemCount,
+		d.LastError,
+		d.UpdatedAt.UTC().Format(time.RFC3339),
+		d.FeedID,
+	)
+	if err != nil {
+		return fmt.Errorf("update digest state: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) DeleteDigest(ctx context.Context, feedID string) error {
+	query := `DELETE FROM feed_digests WHERE feed_id = ?`
+	result, err := s.db.ExecContext(ctx, query, feedID)
+	if err != nil {
+		return fmt.Errorf("delete digest: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListDigestsDue returns enabled digests whose next run is at or before now,
+// most overdue first.
+func (s *SQLStore) ListDigestsDue(ctx context.Context, now time.Time, limit int) ([]Digest, error) {
+	query := `
+		SELECT ` + digestColumns + `
+		FROM feed_digests
+		WHERE enabled = 1 AND next_run_at <= ?
+		ORDER BY next_run_at
+		LIMIT ?
+	`
+	rows, err := s.db.QueryContext(ctx, query, now.UTC().Format(time.RFC3339), limit)
+	if err != nil {
+		return nil, fmt.Errorf("query due digests: %w", err)
+	}
+	defer rows.Close()
+
+	var digests []Digest
+	for rows.Next() {
+		digest, err := scanDigestColumns(rows)
+		if err != nil {
+			return nil, err
+		}
+		digests = append(digests, *digest)
+	}
+	return digests, rows.Err()
+}
+
+func scanDigestColumns(row rowScanner) (*Digest, error) {
+	var d Digest
+	var enabled int
+	var nextRunAt, createdAt, updatedAt string
+	var coveredUntil, lastSentAt, lastError sql.NullString
+	err := row.Scan(
+		&d.FeedID,
+		&d.Frequency,
+		&enabled,
+		&nextRunAt,
+		&coveredUntil,
+		&lastSentAt,
+		&d.LastItemCount,
+		&lastError,
+		&createdAt,
+		&updatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan digest: %w", err)
+	}
+	d.Enabled = enabled == 1
+	d.NextRunAt, _ = time.Parse(time.RFC3339, nextRunAt)
+	d.CoveredUntil = parseNullTime(coveredUntil)
+	d.LastSentAt = parseNullTime(lastSentAt)
+	if lastError.Valid {
+		d.LastError = &lastError.String
+	}
+	d.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	d.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	return &d, nil
+}
+
+const digestRecipientColumns = `feed_id, email, unsubscribe_token, confirmed_at, unsubscribed_at, created_at`
+
+// ListDigestRecipients returns every recipient of a feed's digest, including
+// those who unsubscribed.
+func (s *SQLStore) ListDigestRecipients(ctx context.Context, feedID string) ([]DigestRecipient, error) {
+	query := `
+		SELECT ` + digestRecipientColumns + `
+		FROM digest_recipients WHERE feed_id = ?
+		ORDER BY created_at, email
+	`
+	rows, err := s.db.QueryContext(ctx, query, feedID)
+	if err != nil {
+		return nil, fmt.Errorf("query digest recipients: %w", err)
+	}
+	defer rows.Close()
+
+	var recipients []DigestRecipient
+	for rows.Next() {
+		r, err := scanDigestRecipientColumns(rows)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, *r)
+	}
+	return recipients, rows.Err()
+}
+
+// SetDigestRecipients makes recipients the digest's recipient list. New
+// recipients start unconfirmed. Existing recipients keep their unsubscribe
+// token and confirmation, and recipients who unsubscribed are kept (still
+// unsubscribed) even when left out, so they cannot be re-added.
+func (s *SQLStore) SetDigestRecipients(ctx context.Context, feedID string, recipients []DigestRecipient) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin digest recipients update: %w", err)
+	}
+	defer tx.Rollback()
+
+	emails := make([]any, 0, len(recipients)+1)
+	emails = append(emails, feedID)
+	for _, r := range recipients {
+		emails = append(emails, r.Email)
+	}
+	remove := `DELETE FROM digest_recipients WHERE feed_id = ? AND unsubscribed_at IS NULL`
+	if len(recipients) > 0 {
+		remove += ` AND email NOT IN (?` + strings.Repeat(", ?", len(recipients)-1) + `)`
+	}
+	if _, err := tx.ExecContext(ctx, remove, emails...); err != nil {
+		return fmt.Errorf("delete digest recipients: %w", err)
+	}
+
+	insert := `
+		INSERT INTO digest_recipients (` + digestRecipientColumns + `)
+		VALUES (?, ?, ?, NULL, NULL, ?)
+		ON CONFLICT(feed_id, email) DO NOTHING
+	`
+	for _, r := range recipients {
+		if _, err := tx.ExecContext(ctx, insert, feedID, r.Email, r.UnsubscribeToken, r.CreatedAt.UTC().Format(time.RFC3339)); err != nil {
+			return fmt.Errorf("insert digest recipient: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit digest recipients update: %w", err)
+	}
+	return nil
+}
+
+// ConfirmDigestRecipient confirms the address of the recipient holding
+// token. Repeated calls keep the original confirmation time; a recipient
+// who unsubscribed stays unsubscribed.
+func (s *SQLStore) ConfirmDigestRecipient(ctx context.Context, token string, at time.Time) (*DigestRecipient, error) {
+	query := `
+		UPDATE digest_recipients
+		SET confirmed_at = COALESCE(confirmed_at, ?)
+		WHERE unsubscribe_token = ?
+	`
+	result, err := s.db.ExecContext(ctx, query, at.UTC().Format(time.RFC3339), token)
+	if err != nil {
+		return nil, fmt.Errorf("confirm digest recipient: %w", err)
+	}
+	return s.digestRecipientByToken(ctx, result, token)
+}
+
+// UnsubscribeDigestRecipient opts out the recipient holding token. Repeated
+// calls keep the original opt-out time.
+func (s *SQLStore) UnsubscribeDigestRecipient(ctx context.Context, token string, at time.Time) (*DigestRecipient, error) {
+	query := `
+		UPDATE digest_recipients
+		SET unsubscribed_at = COALESCE(unsubscribed_at, ?)
+		WHERE unsubscribe_token = ?
+	`
+	result, err := s.db.ExecContext(ctx, query, at.UTC().Format(time.RFC3339), token)
+	if err != nil {
+		return nil, fmt.Errorf("unsubscribe digest recipient: %w", err)
+	}
+	return s.digestRecipientByToken(ctx, result, token)
+}
+
+// digestRecipientByToken returns the recipient holding token after result
+// updated it, or ErrNotFound when no recipient holds it.
+func (s *SQLStore) digestRecipientByToken(ctx context.Context, result sql.Result, token string) (*DigestRecipient, error) {
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return nil, ErrNotFound
+	}
+
+	query := `
+		SELECT ` + digestRecipientColumns + `
+		FROM digest_recipients WHERE unsubscribe_token = ?
+	`
+	r, err := scanDigestRecipientColumns(s.db.QueryRowContext(ctx, query, token))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return r, err
+}
+
+// ListSentDigestItems returns, by recipient email, the GUIDs of the items
+// a feed's digest has sent and still remembers.
+func (s *SQLStore) ListSentDigestItems(ctx context.Context, feedID string) (map[string]map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT email, guid FROM digest_recipient_items WHERE feed_id = ?`, feedID)
+	if err != nil {
+		return nil, fmt.Errorf("query digest items: %w", err)
+	}
+	defer rows.Close()
+
+	sent := make(map[string]map[string]bool)
+	for rows.Next() {
+		var email, guid string
+		if err := rows.Scan(&email, &guid); err != nil {
+			return nil, fmt.Errorf("scan digest item: %w", err)
+		}
+		if sent[email] == nil {
+			sent[email] = make(map[string]bool)
+		}
+		sent[email][guid] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate digest items: %w", err)
+	}
+	return sent, nil
+}
+
+// RecordDigestItems marks guids as sent to one recipient of a feed's digest
+// and forgets the items sent before pruneBefore, which later digests no
+// longer look at.
+func (s *SQLStore) RecordDigestItems(ctx context.Context, feedID, email string, guids []string, sentAt, pruneBefore time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin digest item record: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO digest_recipient_items (feed_id, email, guid, sent_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(feed_id, email, guid) DO NOTHING
+	`
+	sent := sentAt.UTC().Format(time.RFC3339)
+	for _, guid := range guids {
+		if _, err := tx.ExecContext(ctx, query, feedID, email, guid, sent); err != nil {
+			return fmt.Errorf("insert digest item %s: %w", guid, err)
+		}
+	}
+	prune := `DELETE FROM digest_recipient_items WHERE feed_id = ? AND sent_at < ?`
+	if _, err := tx.ExecContext(ctx, prune, feedID, pruneBefore.UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("prune digest items: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit digest item record: %w", err)
+	}
+	return nil
+}
+
+func scanDigestRecipientColumns(row rowScanner) (*DigestRecipient, error) {
+	var r DigestRecipient
+	var confirmedAt, unsubscribedAt sql.NullString
+	var createdAt string
+	err := row.Scan(&r.FeedID, &r.Email, &r.UnsubscribeToken, &confirmedAt, &unsubscribedAt, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan digest recipient: %w", err)
+	}
+	r.ConfirmedAt = parseNullTime(confirmedAt)
+	r.UnsubscribedAt = parseNullTime(unsubscribedAt)
+	r.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	return &r, nil
+}
+
+// isUniqueConstraintError checks if the error is a unique constraint violation.
+func isUniqueConstraintError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "UNIQUE constraint failed") ||
+		strings.Contains(msg, "constraint failed")
+}
+
+// formatNullTime converts an optional time to a nullable RFC3339 column value.
+func formatNullTime(t *time.Time) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: t.UTC().Format(time.RFC3339), Valid: true}
+}
+
+// parseNullTime is the inverse of formatNullTime.
+func parseNullTime(v sql.NullString) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, v.String)
+	if err != nil {
+		return nil
+	}
+	return &t
 }
 
 // boolToInt converts a boolean to SQLite integer (0 or 1).
@@ -1,6 +1,10 @@
 package store
 
-import "time"
+import (
+	"time"
+
+	"leetcode-rss/internal/leetcode"
+)
 
 type User struct {
 	ID              string
@@ -12,22 +16,189 @@ type User struct {
 }
 
 type Feed struct {
-	ID           string
-	UserID       string
-	Name         string
-	Secret       string
-	Usernames    []string
-	FirstPerUser int
-	Enabled      bool
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ID             string
+	UserID         string
+	Name           string
+	Secret         string
+	Usernames      []string
+	FirstPerUser   int
+	Since          *time.Time  // only articles published at or after Since are included
+	IncludeContent bool        // embed each article's full body in its item
+	Filter         *FeedFilter // nil means every fetched article is included
+	GroupBy        string      // "day" or "week" for one item per period; "" for one per article
+	Timezone       string      // IANA zone period boundaries are computed in
+	Enabled        bool
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// FeedFilter narrows the fetched articles down to those included in a feed.
+// Zero-valued fields do not filter. Difficulties need problem metadata:
+// articles whose metadata could not be fetched pass them, and with metadata
+// disabled they match nothing.
+type FeedFilter struct {
+	IncludeTitle    string     `json:"include_title,omitempty"` // regexp the title must match
+	ExcludeTitle    string     `json:"exclude_title,omitempty"` // regexp the title must not match
+	QuestionSlugs   []string   `json:"question_slugs,omitempty"`
+	MinHitCount     int        `json:"min_hit_count,omitempty"`
+	PublishedAfter  *time.Time `json:"published_after,omitempty"`
+	PublishedBefore *time.Time `json:"published_before,omitempty"`
+	Difficulties    []string   `json:"difficulties,omitempty"` // "Easy", "Medium", "Hard"
+}
+
+// IsZero reports whether f filters nothing.
+func (f *FeedFilter) IsZero() bool {
+	return f == nil || (f.IncludeTitle == "" && f.ExcludeTitle == "" && len(f.QuestionSlugs) == 0 &&
+		f.MinHitCount <= 0 && f.PublishedAfter == nil && f.PublishedBefore == nil && len(f.Difficulties) == 0)
 }
 
 type FeedCache struct {
 	FeedID      string
-	XML         []byte
+	Format      string // rss.Format the document was rendered as
+	XML         []byte // rendered document; JSON for the json format
 	ETag        string
 	LastBuiltAt time.Time
 	ExpiresAt   time.Time
 	LastError   *string
+	UserStatus  []UsernameStatus // per-username outcome of the last build attempt
+	ItemCount   int              // items in the last successful build
+	BuildTime   time.Duration    // duration of the last successful build
+	// Failures counts the builds that failed since the last success, and
+	// NextAttemptAt, when set, is when the background refresh retries.
+	Failures      int
+	NextAttemptAt *time.Time
+}
+
+// UsernameStatus is the outcome of fetching one username during a build.
+type UsernameStatus struct {
+	Username string `json:"username"`
+	OK       bool   `json:"ok"`
+	Error    string `json:"error,omitempty"`
+	Articles int    `json:"articles"`
+	// DurationMS is how long loading the username's articles took.
+	DurationMS int64 `json:"duration_ms"`
+	// FromHistory is set when LeetCode failed (see Error) and the articles
+	// were served from our article history instead.
+	FromHistory bool `json:"from_history,omitempty"`
+}
+
+// UserArticleCache holds the articles fetched for a single LeetCode username.
+// FetchLimit and Since record the window the articles were fetched with so
+// callers can tell whether the entry covers a (possibly wider) request.
+type UserArticleCache struct {
+	Username   string
+	FetchLimit int        // 0 means the fetch was not limited by count
+	Since      *time.Time // nil means the fetch had no date cutoff
+	Articles   []leetcode.Article
+	FetchedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+// Covers reports whether the cached articles include every one of the most
+// recent limit articles published at or after since (limit 0 and nil since
+// mean unbounded), so the request can be answered without refetching.
+func (c *UserArticleCache) Covers(limit int, since *time.Time) bool {
+	n := len(c.Articles)
+	stoppedByCount := c.FetchLimit > 0 && n >= c.FetchLimit
+	if c.Since == nil && !stoppedByCount {
+		// the fetch ran out of history, so it holds everything
+		return true
+	}
+	if limit > 0 && limit <= n {
+		return true
+	}
+	if since == nil {
+		return false
+	}
+	if n > 0 {
+		if oldest, ok := c.Articles[n-1].CreatedTime(); ok && oldest.Before(*since) {
+			return true
+		}
+	}
+	return c.Since != nil && !stoppedByCount && !since.Before(*c.Since)
+}
+
+// Article is a LeetCode solution article recorded in our history.
+type Article struct {
+	leetcode.Article
+	Username    string
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+}
+
+// ArticleBody is the Markdown body of a solution article, cached by UUID.
+type ArticleBody struct {
+	UUID      string
+	TopicID   int
+	Content   string
+	UpdatedAt string // as returned by LeetCode; empty if unknown
+	FetchedAt time.Time
+}
+
+// WebSubSubscription is a verified WebSub subscriber of one feed format.
+type WebSubSubscription struct {
+	Topic     string
+	Callback  string
+	FeedID    string
+	Format    string
+	Secret    string // HMAC key for signing deliveries; empty when none was given
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Webhook is an owner-registered URL notified of new items in a feed.
+type Webhook struct {
+	ID            string
+	FeedID        string
+	URL           string
+	Secret        string // HMAC key for signing payloads
+	PayloadFormat string // "json", "slack" or "discord"
+	Enabled       bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// WebhookDelivery records the outcome of notifying a webhook of one item.
+type WebhookDelivery struct {
+	ID             int64
+	WebhookID      string
+	ItemGUID       string
+	Success        bool
+	Attempts       int
+	ResponseStatus int // last HTTP status; 0 when no response was received
+	Error          string
+	Duration       time.Duration
+	CreatedAt      time.Time
+}
+
+// Digest is the email digest configuration and scheduling state of a feed.
+type Digest struct {
+	FeedID        string
+	Frequency     string // "daily" or "weekly"
+	Enabled       bool
+	NextRunAt     time.Time  // when the next digest is due
+	CoveredUntil  *time.Time // items published up to here were covered; nil before the first run
+	LastSentAt    *time.Time
+	LastItemCount int
+	LastError     *string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// DigestRecipient is an email address a feed's digest is sent to.
+type DigestRecipient struct {
+	FeedID           string
+	Email            string
+	UnsubscribeToken string
+	ConfirmedAt      *time.Time // set once the recipient confirms the address
+	UnsubscribedAt   *time.Time // set once the recipient opts out
+	CreatedAt        time.Time
+}
+
+// Stats counts the users and feeds in the database.
+type Stats struct {
+	Users        int
+	Feeds        int
+	EnabledFeeds int
 }
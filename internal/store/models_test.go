@@ -0,0 +1,48 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"leetcode-rss/internal/leetcode"
+)
+
+func TestUserArticleCacheCovers(t *testing.T) {
+	base := time.Date(2026, 1, 7, 12, 0, 0, 0, time.UTC)
+	// articles returns n articles a day apart, the newest at base.
+	articles := func(n int) []leetcode.Article {
+		out := make([]leetcode.Article, n)
+		for i := range out {
+			out[i].CreatedAt = base.AddDate(0, 0, -i).Format(time.RFC3339Nano)
+		}
+		return out
+	}
+	day := func(offset int) *time.Time {
+		t := base.AddDate(0, 0, offset)
+		return &t
+	}
+
+	tests := []struct {
+		name  string
+		cache UserArticleCache
+		limit int
+		since *time.Time
+		want  bool
+	}{
+		{"history exhausted covers any count", UserArticleCache{FetchLimit: 10, Articles: articles(4)}, 50, nil, true},
+		{"unlimited fetch covers any window", UserArticleCache{Articles: articles(4)}, 0, day(-30), true},
+		{"count within fetched count", UserArticleCache{FetchLimit: 5, Articles: articles(5)}, 3, nil, true},
+		{"count beyond fetched count", UserArticleCache{FetchLimit: 5, Articles: articles(5)}, 8, nil, false},
+		{"unbounded request on count-limited entry", UserArticleCache{FetchLimit: 5, Articles: articles(5)}, 0, nil, false},
+		{"since reached by count-limited entry", UserArticleCache{FetchLimit: 5, Articles: articles(5)}, 0, day(-2), true},
+		{"since beyond count-limited entry", UserArticleCache{FetchLimit: 5, Articles: articles(5)}, 0, day(-10), false},
+		{"narrower since than fetched", UserArticleCache{Since: day(-7), Articles: articles(3)}, 0, day(-5), true},
+		{"wider since than fetched", UserArticleCache{Since: day(-7), Articles: articles(3)}, 0, day(-9), false},
+		{"since entry asked for count", UserArticleCache{Since: day(-7), Articles: articles(3)}, 10, nil, false},
+	}
+	for _, tt := range tests {
+		if got := tt.cache.Covers(tt.limit, tt.since); got != tt.want {
+			t.Errorf("%s: Covers(%d, %v) = %v, want %v", tt.name, tt.limit, tt.since, got, tt.want)
+		}
+	}
+}
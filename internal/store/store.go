@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
+
+	"leetcode-rss/internal/leetcode"
 )
 
 var (
@@ -12,6 +15,10 @@ var (
 	ErrAlreadyExists = errors.New("already exists")
 )
 
+// SchemaVersion is the latest migration in migrations/ this build expects
+// to have been applied. Bump it with every new migration.
+const SchemaVersion = 17
+
 type Store interface {
 	CreateUser(ctx context.Context, user *User) error
 	GetUserByEmail(ctx context.Context, email string) (*User, error)
@@ -26,10 +33,57 @@ type Store interface {
 	ListFeedsByUserID(ctx context.Context, userID string) ([]Feed, error)
 	CountFeedsByUserID(ctx context.Context, userID string) (int, error)
 
-	GetFeedCache(ctx context.Context, feedID string) (*FeedCache, error)
+	GetFeedCache(ctx context.Context, feedID, format string) (*FeedCache, error)
+	GetFeedCacheStatus(ctx context.Context, feedID string) (*FeedCache, error)
+	ListFeedCacheStatusByUserID(ctx context.Context, userID string) (map[string]*FeedCache, error)
 	SetFeedCache(ctx context.Context, cache *FeedCache) error
+	SetFeedCacheError(ctx context.Context, feedID, lastError string, userStatus []UsernameStatus) error
+	ListFeedsDueForRefresh(ctx context.Context, expiringBefore, now time.Time, limit int) ([]Feed, error)
+	DeferFeedRefresh(ctx context.Context, feedID string, until time.Time) error
 	InvalidateFeedCache(ctx context.Context, feedID string) error
+	GetFeedCacheETags(ctx context.Context, feedID string) (map[string]string, error)
+
+	GetUserArticleCache(ctx context.Context, username string) (*UserArticleCache, error)
+	SetUserArticleCache(ctx context.Context, cache *UserArticleCache) error
+
+	UpsertArticles(ctx context.Context, username string, articles []leetcode.Article, seenAt time.Time) ([]string, error)
+	ListArticlesByUsername(ctx context.Context, username string, limit int, since *time.Time) ([]Article, error)
+
+	GetArticleBodies(ctx context.Context, uuids []string) (map[string]*ArticleBody, error)
+	SetArticleBody(ctx context.Context, body *ArticleBody) error
+
+	UpsertWebSubSubscription(ctx context.Context, sub *WebSubSubscription) error
+	DeleteWebSubSubscription(ctx context.Context, topic, callback string) error
+	DeleteWebSubSubscriptionsByFeed(ctx context.Context, feedID string) error
+	ListWebSubSubscriptions(ctx context.Context, feedID, format string, now time.Time) ([]WebSubSubscription, error)
+	CountWebSubSubscriptions(ctx context.Context, feedID, format, exceptTopic, exceptCallback string, now time.Time) (int, error)
+
+	CreateWebhook(ctx context.Context, hook *Webhook) error
+	GetWebhookByID(ctx context.Context, id string) (*Webhook, error)
+	UpdateWebhook(ctx context.Context, hook *Webhook) error
+	DeleteWebhook(ctx context.Context, id string) error
+	ListWebhooksByFeedID(ctx context.Context, feedID string) ([]Webhook, error)
+	RecordFeedItems(ctx context.Context, feedID string, guids []string, seenAt time.Time) (newGUIDs []string, baseline bool, err error)
+	ResetFeedItems(ctx context.Context, feedID string) error
+	AddWebhookDelivery(ctx context.Context, delivery *WebhookDelivery) error
+	ListWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]WebhookDelivery, error)
+
+	GetDigest(ctx context.Context, feedID string) (*Digest, error)
+	UpsertDigest(ctx context.Context, digest *Digest) error
+	UpdateDigestState(ctx context.Context, digest *Digest) error
+	DeleteDigest(ctx context.Context, feedID string) error
+	ListDigestsDue(ctx context.Context, now time.Time, limit int) ([]Digest, error)
+	ListDigestRecipients(ctx context.Context, feedID string) ([]DigestRecipient, error)
+	SetDigestRecipients(ctx context.Context, feedID string, recipients []DigestRecipient) error
+	ConfirmDigestRecipient(ctx context.Context, token string, at time.Time) (*DigestRecipient, error)
+	UnsubscribeDigestRecipient(ctx context.Context, token string, at time.Time) (*DigestRecipient, error)
+	ListSentDigestItems(ctx context.Context, feedID string) (map[string]map[string]bool, error)
+	RecordDigestItems(ctx context.Context, feedID, email string, guids []string, sentAt, pruneBefore time.Time) error
+
+	CountStats(ctx context.Context) (*Stats, error)
 
+	Ping(ctx context.Context) error
+	MigrationVersion(ctx context.Context) (int64, error)
 	Close() error
 }
 
@@ -42,7 +96,12 @@ type Store interface {
 func NewStore(dsn string) (Store, error) {
 	switch {
 	case strings.HasPrefix(dsn, "file:"), dsn == ":memory:", strings.HasPrefix(dsn, ":memory:"), strings.HasPrefix(dsn, "libsql://"):
-		return NewSQLStore(dsn)
+		s, err := NewSQLStore(dsn)
+		if err != nil {
+			// a nil *SQLStore would make a non-nil Store
+			return nil, err
+		}
+		return s, nil
 	default:
 		return nil, fmt.Errorf("unsupported database DSN: %s (expected file:, :memory:, or libsql://)", dsn)
 	}
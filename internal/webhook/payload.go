@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"leetcode-rss/internal/rss"
+	"leetcode-rss/internal/store"
+)
+
+// Payload formats a webhook can be registered with.
+const (
+	FormatJSON    = "json"    // the item as structured JSON, see itemPayload
+	FormatSlack   = "slack"   // a Slack incoming webhook message
+	FormatDiscord = "discord" // a Discord webhook message with one embed
+)
+
+// Formats lists every payload format.
+var Formats = []string{FormatJSON, FormatSlack, FormatDiscord}
+
+// EventItemCreated is the only event sent so far, in X-Webhook-Event and the
+// JSON payload.
+const EventItemCreated = "item.created"
+
+// Discord rejects embeds with longer fields.
+const (
+	discordTitleLimit       = 256
+	discordDescriptionLimit = 4096
+)
+
+type itemPayload struct {
+	Event string          `json:"event"`
+	Feed  feedPayload     `json:"feed"`
+	Item  itemPayloadItem `json:"item"`
+}
+
+type feedPayload struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type itemPayloadItem struct {
+	GUID        string   `json:"guid"`
+	Title       string   `json:"title"`
+	Link        string   `json:"link"`
+	PublishedAt string   `json:"published_at"`
+	Author      string   `json:"author"`
+	AuthorURL   string   `json:"author_url,omitempty"`
+	Summary     string   `json:"summary"`
+	Categories  []string `json:"categories"`
+}
+
+type slackPayload struct {
+	Text        string `json:"text"`
+	UnfurlLinks bool   `json:"unfurl_links"`
+}
+
+type discordPayload struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	URL         string         `json:"url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Timestamp   string         `json:"timestamp,omitempty"`
+	Author      *discordAuthor `json:"author,omitempty"`
+	Footer      *discordFooter `json:"footer,omitempty"`
+}
+
+type discordAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type discordFooter struct {
+	Text string `json:"text"`
+}
+
+// Render builds the request body announcing item in the given format.
+func Render(format string, feed *store.Feed, item rss.Item) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		categories := item.Categories
+		if categories == nil {
+			categories = []string{}
+		}
+		return json.Marshal(itemPayload{
+			Event: EventItemCreated,
+			Feed:  feedPayload{ID: feed.ID, Name: feed.Name},
+			Item: itemPayloadItem{
+				GUID:        item.GUID,
+				Title:       item.Title,
+				Link:        item.Link,
+				PublishedAt: item.PubDate.UTC().Format(time.RFC3339),
+				Author:      item.Author,
+				AuthorURL:   item.AuthorURI,
+				Summary:     item.Summary,
+				Categories:  categories,
+			},
+		})
+
+	case FormatSlack:
+		var b strings.Builder
+		fmt.Fprintf(&b, "*<%s|%s>*", item.Link, slackEscape(item.Title))
+		if item.Author != "" {
+			fmt.Fprintf(&b, " by %s", slackEscape(item.Author))
+		}
+		if len(item.Categories) > 0 {
+			fmt.Fprintf(&b, "\n%s", slackEscape(strings.Join(item.Categories, " · ")))
+		}
+		if feed.Name != "" {
+			fmt.Fprintf(&b, "\n_%s_", slackEscape(feed.Name))
+		}
+		return json.Marshal(slackPayload{Text: b.String()})
+
+	case FormatDiscord:
+		embed := discordEmbed{
+			Title:       truncate(item.Title, discordTitleLimit),
+			URL:         item.Link,
+			Description: truncate(item.Summary, discordDescriptionLimit),
+			Timestamp:   item.PubDate.UTC().Format(time.RFC3339),
+		}
+		if item.Author != "" {
+			embed.Author = &discordAuthor{Name: item.Author, URL: item.AuthorURI}
+		}
+		if feed.Name != "" {
+			embed.Footer = &discordFooter{Text: feed.Name}
+		}
+		return json.Marshal(discordPayload{Embeds: []discordEmbed{embed}})
+
+	default:
+		return nil, fmt.Errorf("unsupported webhook payload format %q", format)
+	}
+}
+
+// slackEscape escapes the characters Slack treats as markup.
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// truncate shortens s to at most limit runes, marking the cut with "…".
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,254 @@
+// Package webhook notifies owner-registered URLs of new items in their
+// feeds. Each new item is POSTed as its own signed request, retried with
+// backoff, and every outcome is logged in the store.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"leetcode-rss/internal/netguard"
+	"leetcode-rss/internal/rss"
+	"leetcode-rss/internal/store"
+)
+
+// maxItemsPerBuild bounds the notifications one rebuild sends to a webhook;
+// older new items beyond it are recorded as seen but not announced.
+const maxItemsPerBuild = 20
+
+// maxDrainedBody bounds how much of a response is read so its connection
+// can be reused. Response bodies are never kept: the delivery log only
+// records the status, so webhooks cannot be used to read other services.
+const maxDrainedBody = 4 << 10
+
+// Store is the subset of store.Store used to find webhooks, track seen items
+// and log deliveries.
+type Store interface {
+	ListWebhooksByFeedID(ctx context.Context, feedID string) ([]store.Webhook, error)
+	RecordFeedItems(ctx context.Context, feedID string, guids []string, seenAt time.Time) ([]string, bool, error)
+	AddWebhookDelivery(ctx context.Context, delivery *store.WebhookDelivery) error
+}
+
+// Options tunes a Dispatcher. Zero values fall back to the defaults noted.
+type Options struct {
+	Attempts   int           // attempts per webhook and item (3)
+	RetryDelay time.Duration // delay before the first retry, doubling after (5s)
+	Client     *http.Client  // used for deliveries (10s timeout, public addresses only)
+}
+
+// Dispatcher delivers new feed items to webhooks in the background.
+type Dispatcher struct {
+	store Store
+	opts  Options
+	wg    sync.WaitGroup
+
+	// ctx is cancelled by Wait so deliveries stop waiting to retry
+	ctx    context.Context
+	stop   context.CancelFunc
+	mu     sync.Mutex
+	closed bool
+}
+
+func NewDispatcher(s Store, opts Options) *Dispatcher {
+	if opts.Attempts < 1 {
+		opts.Attempts = 3
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = 5 * time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = netguard.NewClient(10 * time.Second)
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	return &Dispatcher{store: s, opts: opts, ctx: ctx, stop: stop}
+}
+
+// Notify records the items of a rebuilt feed, newest first, and sends the
+// ones the feed has not produced before to each of its enabled webhooks.
+// Items are only tracked while a feed has enabled webhooks, and the first
+// recorded build is a baseline that sends nothing. Recording happens before
+// Notify returns; deliveries continue in the background.
+func (d *Dispatcher) Notify(ctx context.Context, feed *store.Feed, items []rss.Item) error {
+	hooks, err := d.store.ListWebhooksByFeedID(ctx, feed.ID)
+	if err != nil {
+		return fmt.Errorf("list webhooks: %w", err)
+	}
+	enabled := hooks[:0]
+	for _, h := range hooks {
+		if h.Enabled {
+			enabled = append(enabled, h)
+		}
+	}
+	if len(enabled) == 0 || len(items) == 0 {
+		return nil
+	}
+
+	guids := make([]string, 0, len(items))
+	for _, it := range items {
+		guids = append(guids, it.GUID)
+	}
+	newGUIDs, baseline, err := d.store.RecordFeedItems(ctx, feed.ID, guids, time.Now())
+	if err != nil {
+		return fmt.Errorf("record feed items: %w", err)
+	}
+	if baseline {
+		log.Printf("webhook: recorded %d baseline items for feed %s", len(guids), feed.ID)
+		return nil
+	}
+	if len(newGUIDs) == 0 {
+		return nil
+	}
+
+	isNew := make(map[string]bool, len(newGUIDs))
+	for _, guid := range newGUIDs {
+		isNew[guid] = true
+	}
+	var fresh []rss.Item
+	for _, it := range items {
+		if isNew[it.GUID] {
+			fresh = append(fresh, it)
+		}
+	}
+	if len(fresh) > maxItemsPerBuild {
+		log.Printf("webhook: feed %s has %d new items, announcing the newest %d", feed.ID, len(fresh), maxItemsPerBuild)
+		fresh = fresh[:maxItemsPerBuild]
+	}
+	// announce in publication order
+	for i, j := 0, len(fresh)-1; i < j; i, j = i+1, j-1 {
+		fresh[i], fresh[j] = fresh[j], fresh[i]
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		log.Printf("webhook: shutting down, not announcing %d items of feed %s", len(fresh), feed.ID)
+		return nil
+	}
+	snapshot := *feed
+	for _, hook := range enabled {
+		hook := hook
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for i, it := range fresh {
+				if d.ctx.Err() != nil {
+					log.Printf("webhook: shutting down, not announcing %d items of feed %s to webhook %s", len(fresh)-i, snapshot.ID, hook.ID)
+					return
+				}
+				d.deliver(d.ctx, &snapshot, hook, it)
+			}
+		}()
+	}
+	return nil
+}
+
+// Wait stops deliveries from retrying or moving on to further items and
+// blocks until the attempts under way are done. Later Notify calls still
+// record items but announce nothing.
+func (d *Dispatcher) Wait() {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+	d.stop()
+	d.wg.Wait()
+}
+
+// deliver sends one item to one webhook, retrying with exponential backoff,
+// and logs the outcome. Once ctx is done no further attempt is made; the
+// attempt under way and the log entry are not cut short.
+func (d *Dispatcher) deliver(ctx context.Context, feed *store.Feed, hook store.Webhook, item rss.Item) {
+	started := time.Now()
+	delivery := &store.WebhookDelivery{WebhookID: hook.ID, ItemGUID: item.GUID}
+	waitCtx := ctx
+	ctx = context.WithoutCancel(ctx)
+
+	body, err := Render(hook.PayloadFormat, feed, item)
+	if err != nil {
+		delivery.Error = err.Error()
+	} else {
+	attempts:
+		for attempt := 1; attempt <= d.opts.Attempts; attempt++ {
+			if attempt > 1 {
+				timer := time.NewTimer(d.opts.RetryDelay << (attempt - 2))
+				select {
+				case <-waitCtx.Done():
+					timer.Stop()
+					delivery.Error += " (retry abandoned at shutdown)"
+					break attempts
+				case <-timer.C:
+				}
+			}
+			delivery.Attempts = attempt
+			status, err := d.post(ctx, hook, body)
+			delivery.ResponseStatus = status
+			if err == nil {
+				delivery.Success = true
+				delivery.Error = ""
+				break
+			}
+			delivery.Error = err.Error()
+			// the request itself is wrong; resending it will not help
+			if status >= 400 && status < 500 && status != http.StatusTooManyRequests && status != http.StatusRequestTimeout {
+				break
+			}
+		}
+	}
+
+	delivery.Duration = time.Since(started)
+	delivery.CreatedAt = time.Now()
+	if !delivery.Success {
+		log.Printf("webhook: failed to deliver item %s of feed %s to webhook %s: %s", item.GUID, feed.ID, hook.ID, delivery.Error)
+	}
+	if err := d.store.AddWebhookDelivery(ctx, delivery); err != nil {
+		log.Printf("webhook: failed to log delivery to webhook %s: %v", hook.ID, err)
+	}
+}
+
+// post sends body to the webhook and returns the response status, or 0 when
+// no response was received.
+func (d *Dispatcher) post(ctx context.Context, hook store.Webhook, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "leetcode-rss/1.0")
+	req.Header.Set("X-Webhook-Event", EventItemCreated)
+	req.Header.Set("X-Webhook-ID", hook.ID)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+Sign(hook.Secret, timestamp, body))
+
+	resp, err := d.opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainedBody))
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+}
+
+// Sign returns the hex HMAC-SHA256, keyed by secret, of timestamp + "." +
+// body, as sent in X-Webhook-Signature. Covering the timestamp lets
+// receivers reject replayed requests.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
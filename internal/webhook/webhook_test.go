@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"leetcode-rss/internal/rss"
+	"leetcode-rss/internal/store"
+)
+
+// memStore serves fixed webhooks, treats every item as new and keeps the
+// logged deliveries.
+type memStore struct {
+	hooks []store.Webhook
+
+	mu         sync.Mutex
+	deliveries []store.WebhookDelivery
+}
+
+func (m *memStore) ListWebhooksByFeedID(context.Context, string) ([]store.Webhook, error) {
+	return append([]store.Webhook(nil), m.hooks...), nil
+}
+
+func (m *memStore) RecordFeedItems(_ context.Context, _ string, guids []string, _ time.Time) ([]string, bool, error) {
+	return guids, false, nil
+}
+
+func (m *memStore) AddWebhookDelivery(ctx context.Context, d *store.WebhookDelivery) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries = append(m.deliveries, *d)
+	return nil
+}
+
+func TestWaitAbandonsRetries(t *testing.T) {
+	received := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		select {
+		case received <- struct{}{}:
+		default:
+		}
+	}))
+	defer srv.Close()
+
+	s := &memStore{hooks: []store.Webhook{{ID: "hook", URL: srv.URL, PayloadFormat: "json", Enabled: true}}}
+	d := NewDispatcher(s, Options{
+		Attempts:   3,
+		RetryDelay: time.Hour,
+		// the default client refuses the loopback address of httptest servers
+		Client: &http.Client{Timeout: 5 * time.Second},
+	})
+	feed := &store.Feed{ID: "feed"}
+	items := []rss.Item{{GUID: "a", Title: "A"}, {GUID: "b", Title: "B"}}
+	if err := d.Notify(context.Background(), feed, items); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	// the first attempt has failed once the server answered; the delivery
+	// is then waiting to retry
+	<-received
+	time.Sleep(50 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		d.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait did not return while a retry was pending")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.deliveries) != 1 {
+		t.Fatalf("logged %d deliveries, want only the abandoned oldest item", len(s.deliveries))
+	}
+	if got := s.deliveries[0]; got.Success || got.Attempts != 1 || got.ItemGUID != "b" {
+		// items are announced oldest first
+		t.Errorf("delivery = %+v, want one failed attempt for item b", got)
+	}
+
+	if err := d.Notify(context.Background(), feed, items); err != nil {
+		t.Fatalf("Notify after Wait: %v", err)
+	}
+}
@@ -0,0 +1,397 @@
+// Package websub implements the hub side of WebSub for the service's own
+// feeds: it verifies the intent of subscribers and pushes every new version
+// of a feed document to them.
+//
+// See https://www.w3.org/TR/websub/.
+package websub
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"leetcode-rss/internal/netguard"
+	"leetcode-rss/internal/store"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Subscription modes accepted in hub.mode.
+const (
+	ModeSubscribe   = "subscribe"
+	ModeUnsubscribe = "unsubscribe"
+)
+
+const (
+	// MaxSecretLength is the longest hub.secret the spec allows, in bytes.
+	MaxSecretLength = 200
+
+	deliveryConcurrency = 4
+	maxChallengeReply   = 1024
+
+	// verifyWorkers verify requests from a queue of at most verifyQueueSize;
+	// requests arriving while it is full are turned away.
+	verifyWorkers   = 4
+	verifyQueueSize = 100
+)
+
+var (
+	// ErrTooManySubscriptions is returned by Accept when the topic already
+	// has MaxPerTopic subscribers.
+	ErrTooManySubscriptions = errors.New("too many subscriptions to this topic")
+	// ErrBusy is returned by Accept when the verification queue is full or
+	// the hub is shutting down.
+	ErrBusy = errors.New("too many pending verifications")
+)
+
+// Store is the subset of store.Store that persists subscriptions.
+type Store interface {
+	UpsertWebSubSubscription(ctx context.Context, sub *store.WebSubSubscription) error
+	DeleteWebSubSubscription(ctx context.Context, topic, callback string) error
+	ListWebSubSubscriptions(ctx context.Context, feedID, format string, now time.Time) ([]store.WebSubSubscription, error)
+	CountWebSubSubscriptions(ctx context.Context, feedID, format, exceptTopic, exceptCallback string, now time.Time) (int, error)
+}
+
+// Options tunes a Hub. Zero values fall back to the defaults noted.
+type Options struct {
+	DefaultLease time.Duration // lease granted when none is requested (10 days)
+	MaxLease     time.Duration // longest lease granted (30 days)
+	MaxPerTopic  int           // subscriptions kept per feed format (100)
+	Attempts     int           // delivery attempts per subscriber and update (3)
+	RetryDelay   time.Duration // delay before the first retry, doubling after (5s)
+	// Client is used for verification and delivery (10s timeout, public
+	// addresses only).
+	Client *http.Client
+}
+
+// Hub verifies subscriptions and distributes feed updates. Verification and
+// delivery run in the background so callers never wait on subscribers.
+type Hub struct {
+	// URL is the hub endpoint advertised in feeds.
+	URL string
+
+	store Store
+	opts  Options
+	wg    sync.WaitGroup
+
+	mu     sync.Mutex // guards sending on queue against closing it
+	queue  chan Request
+	closed bool
+}
+
+func NewHub(s Store, hubURL string, opts Options) *Hub {
+	if opts.DefaultLease <= 0 {
+		opts.DefaultLease = 10 * 24 * time.Hour
+	}
+	if opts.MaxLease <= 0 {
+		opts.MaxLease = 30 * 24 * time.Hour
+	}
+	if opts.DefaultLease > opts.MaxLease {
+		opts.DefaultLease = opts.MaxLease
+	}
+	if opts.MaxPerTopic < 1 {
+		opts.MaxPerTopic = 100
+	}
+	if opts.Attempts < 1 {
+		opts.Attempts = 3
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = 5 * time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = netguard.NewClient(10 * time.Second)
+	}
+	h := &Hub{URL: hubURL, store: s, opts: opts, queue: make(chan Request, verifyQueueSize)}
+	h.wg.Add(verifyWorkers)
+	for range verifyWorkers {
+		go func() {
+			defer h.wg.Done()
+			for req := range h.queue {
+				h.apply(req)
+			}
+		}()
+	}
+	return h
+}
+
+// Request is a (un)subscription request whose topic has been resolved to
+// one format of a feed.
+type Request struct {
+	Mode     string
+	Topic    string
+	Callback string
+	Secret   string
+	// Lease is the requested lease; zero asks for the default.
+	Lease time.Duration
+
+	FeedID string
+	Format string
+}
+
+// Accept queues req for verification with its subscriber, to be applied
+// once the subscriber confirms, and the caller answers the request with 202
+// Accepted. It returns ErrTooManySubscriptions for a new subscription to a
+// full topic and ErrBusy when the queue is full.
+func (h *Hub) Accept(ctx context.Context, req Request) error {
+	if req.Mode == ModeSubscribe {
+		if err := h.checkLimit(ctx, req); err != nil {
+			return err
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return ErrBusy
+	}
+	select {
+	case h.queue <- req:
+		return nil
+	default:
+		return ErrBusy
+	}
+}
+
+// checkLimit returns ErrTooManySubscriptions when subscribing req would
+// exceed MaxPerTopic. Renewals of an existing subscription always pass.
+func (h *Hub) checkLimit(ctx context.Context, req Request) error {
+	n, err := h.store.CountWebSubSubscriptions(ctx, req.FeedID, req.Format, req.Topic, req.Callback, time.Now())
+	if err != nil {
+		return fmt.Errorf("count subscriptions: %w", err)
+	}
+	if n >= h.opts.MaxPerTopic {
+		return ErrTooManySubscriptions
+	}
+	return nil
+}
+
+// apply verifies one queued request and saves it.
+func (h *Hub) apply(req Request) {
+	ctx := context.Background()
+
+	lease := h.lease(req.Lease)
+	if err := h.verify(ctx, req, lease); err != nil {
+		log.Printf("websub: %s of %s to feed %s not verified: %v", req.Mode, req.Callback, req.FeedID, err)
+		return
+	}
+
+	var err error
+	switch req.Mode {
+	case ModeSubscribe:
+		// other subscriptions may have been verified while this one waited
+		if err = h.checkLimit(ctx, req); err != nil {
+			break
+		}
+		now := time.Now()
+		err = h.store.UpsertWebSubSubscription(ctx, &store.WebSubSubscription{
+			Topic:     req.Topic,
+			Callback:  req.Callback,
+			FeedID:    req.FeedID,
+			Format:    req.Format,
+			Secret:    req.Secret,
+			ExpiresAt: now.Add(lease),
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	case ModeUnsubscribe:
+		err = h.store.DeleteWebSubSubscription(ctx, req.Topic, req.Callback)
+		if errors.Is(err, store.ErrNotFound) {
+			err = nil
+		}
+	}
+	if err != nil {
+		log.Printf("websub: failed to save %s of %s to feed %s: %v", req.Mode, req.Callback, req.FeedID, err)
+		return
+	}
+	log.Printf("websub: verified %s of %s to feed %s (%s)", req.Mode, req.Callback, req.FeedID, req.Format)
+}
+
+// Wait stops accepting requests and blocks until the queued verifications
+// and every delivery started so far are done.
+func (h *Hub) Wait() {
+	h.mu.Lock()
+	if !h.closed {
+		h.closed = true
+		close(h.queue)
+	}
+	h.mu.Unlock()
+	h.wg.Wait()
+}
+
+// lease clamps a requested lease to the hub's limits.
+func (h *Hub) lease(requested time.Duration) time.Duration {
+	if requested <= 0 {
+		return h.opts.DefaultLease
+	}
+	return min(requested, h.opts.MaxLease)
+}
+
+// verify confirms the subscriber's intent by echoing a random challenge
+// through its callback.
+func (h *Hub) verify(ctx context.Context, req Request, lease time.Duration) error {
+	challenge, err := newChallenge()
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(req.Callback)
+	if err != nil {
+		return fmt.Errorf("parse callback: %w", err)
+	}
+	q := u.Query()
+	q.Set("hub.mode", req.Mode)
+	q.Set("hub.topic", req.Topic)
+	q.Set("hub.challenge", challenge)
+	if req.Mode == ModeSubscribe {
+		q.Set("hub.lease_seconds", strconv.Itoa(int(lease.Seconds())))
+	}
+	u.RawQuery = q.Encode()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.opts.Client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("callback returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxChallengeReply))
+	if err != nil {
+		return fmt.Errorf("read callback response: %w", err)
+	}
+	if string(body) != challenge {
+		return errors.New("callback did not echo the challenge")
+	}
+	return nil
+}
+
+func newChallenge() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate challenge: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Publish pushes doc, the new version of one format of a feed, to every
+// subscriber of that format. Once Wait has been called it does nothing.
+func (h *Hub) Publish(feedID, format, contentType string, doc []byte) {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return
+	}
+	h.wg.Add(1)
+	h.mu.Unlock()
+
+	go func() {
+		defer h.wg.Done()
+		ctx := context.Background()
+
+		subs, err := h.store.ListWebSubSubscriptions(ctx, feedID, format, time.Now())
+		if err != nil {
+			log.Printf("websub: failed to list subscribers of feed %s: %v", feedID, err)
+			return
+		}
+
+		var g errgroup.Group
+		g.SetLimit(deliveryConcurrency)
+		for _, sub := range subs {
+			sub := sub
+			g.Go(func() error {
+				if err := h.deliver(ctx, sub, contentType, doc); err != nil {
+					log.Printf("websub: failed to deliver feed %s to %s: %v", feedID, sub.Callback, err)
+				}
+				return nil
+			})
+		}
+		_ = g.Wait()
+	}()
+}
+
+// errGone marks a subscriber that asked to be dropped with 410 Gone.
+var errGone = errors.New("subscriber is gone")
+
+// deliver sends doc to one subscriber, retrying failed attempts with
+// exponential backoff. A subscriber answering 410 Gone is unsubscribed.
+func (h *Hub) deliver(ctx context.Context, sub store.WebSubSubscription, contentType string, doc []byte) error {
+	var err error
+	for attempt := 1; attempt <= h.opts.Attempts; attempt++ {
+		if attempt > 1 {
+			timer := time.NewTimer(h.opts.RetryDelay << (attempt - 2))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		err = h.post(ctx, sub, contentType, doc)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, errGone) {
+			if err := h.store.DeleteWebSubSubscription(ctx, sub.Topic, sub.Callback); err != nil && !errors.Is(err, store.ErrNotFound) {
+				return fmt.Errorf("unsubscribe gone subscriber: %w", err)
+			}
+			log.Printf("websub: %s is gone, unsubscribed from feed %s", sub.Callback, sub.FeedID)
+			return nil
+		}
+	}
+	return err
+}
+
+func (h *Hub) post(ctx context.Context, sub store.WebSubSubscription, contentType string, doc []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Callback, bytes.NewReader(doc))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Link", fmt.Sprintf(`<%s>; rel="hub", <%s>; rel="self"`, h.URL, sub.Topic))
+	if sub.Secret != "" {
+		req.Header.Set("X-Hub-Signature", "sha256="+Sign(sub.Secret, doc))
+	}
+
+	resp, err := h.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxChallengeReply))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return nil
+	case resp.StatusCode == http.StatusGone:
+		return errGone
+	default:
+		return fmt.Errorf("callback returned status %d", resp.StatusCode)
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 of body keyed by secret, as sent in
+// X-Hub-Signature.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
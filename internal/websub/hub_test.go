@@ -0,0 +1,274 @@
+package websub
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"leetcode-rss/internal/store"
+)
+
+// memStore keeps subscriptions in memory, keyed by topic and callback.
+type memStore struct {
+	mu   sync.Mutex
+	subs map[[2]string]store.WebSubSubscription
+}
+
+func newMemStore() *memStore {
+	return &memStore{subs: make(map[[2]string]store.WebSubSubscription)}
+}
+
+func (m *memStore) UpsertWebSubSubscription(_ context.Context, sub *store.WebSubSubscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subs[[2]string{sub.Topic, sub.Callback}] = *sub
+	return nil
+}
+
+func (m *memStore) DeleteWebSubSubscription(_ context.Context, topic, callback string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := [2]string{topic, callback}
+	if _, ok := m.subs[key]; !ok {
+		return store.ErrNotFound
+	}
+	delete(m.subs, key)
+	return nil
+}
+
+func (m *memStore) ListWebSubSubscriptions(_ context.Context, feedID, format string, now time.Time) ([]store.WebSubSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []store.WebSubSubscription
+	for _, sub := range m.subs {
+		if sub.FeedID == feedID && sub.Format == format && sub.ExpiresAt.After(now) {
+			out = append(out, sub)
+		}
+	}
+	return out, nil
+}
+
+func (m *memStore) CountWebSubSubscriptions(_ context.Context, feedID, format, exceptTopic, exceptCallback string, now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for key, sub := range m.subs {
+		if sub.FeedID == feedID && sub.Format == format && key != [2]string{exceptTopic, exceptCallback} && sub.ExpiresAt.After(now) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memStore) get(topic, callback string) (store.WebSubSubscription, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sub, ok := m.subs[[2]string{topic, callback}]
+	return sub, ok
+}
+
+const testTopic = "https://feeds.example/f/11111111-1111-1111-1111-111111111111/sec.xml"
+
+func subscribeRequest(callback string) Request {
+	return Request{
+		Mode:     ModeSubscribe,
+		Topic:    testTopic,
+		Callback: callback,
+		Secret:   "s3cret",
+		Lease:    time.Hour,
+		FeedID:   "11111111-1111-1111-1111-111111111111",
+		Format:   "rss",
+	}
+}
+
+func newTestHub(s Store, opts Options) *Hub {
+	// the default client refuses the loopback addresses of httptest servers
+	opts.Client = &http.Client{Timeout: 5 * time.Second}
+	opts.RetryDelay = time.Millisecond
+	return NewHub(s, "https://feeds.example/websub", opts)
+}
+
+func TestAcceptVerifiesIntent(t *testing.T) {
+	var got map[string]string
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		got = map[string]string{
+			"mode":  q.Get("hub.mode"),
+			"topic": q.Get("hub.topic"),
+			"lease": q.Get("hub.lease_seconds"),
+			"own":   q.Get("own"),
+		}
+		io.WriteString(w, q.Get("hub.challenge"))
+	}))
+	defer subscriber.Close()
+
+	s := newMemStore()
+	hub := newTestHub(s, Options{})
+	callback := subscriber.URL + "/cb?own=kept"
+	if err := hub.Accept(context.Background(), subscribeRequest(callback)); err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+	hub.Wait()
+
+	want := map[string]string{"mode": ModeSubscribe, "topic": testTopic, "lease": "3600", "own": "kept"}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("verification %s = %q, want %q", k, got[k], v)
+		}
+	}
+	sub, ok := s.get(testTopic, callback)
+	if !ok {
+		t.Fatal("verified subscription was not saved")
+	}
+	if sub.Secret != "s3cret" || time.Until(sub.ExpiresAt) > time.Hour {
+		t.Errorf("saved subscription = %+v", sub)
+	}
+}
+
+func TestAcceptRejectsWrongChallenge(t *testing.T) {
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "not the challenge")
+	}))
+	defer subscriber.Close()
+
+	s := newMemStore()
+	hub := newTestHub(s, Options{})
+	if err := hub.Accept(context.Background(), subscribeRequest(subscriber.URL)); err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+	hub.Wait()
+
+	if _, ok := s.get(testTopic, subscriber.URL); ok {
+		t.Error("subscription saved without an echoed challenge")
+	}
+}
+
+func TestAcceptLimitsSubscriptionsPerTopic(t *testing.T) {
+	s := newMemStore()
+	hub := newTestHub(s, Options{MaxPerTopic: 1})
+	defer hub.Wait()
+
+	existing := subscribeRequest("https://a.example/cb")
+	s.UpsertWebSubSubscription(context.Background(), &store.WebSubSubscription{
+		Topic: existing.Topic, Callback: existing.Callback, FeedID: existing.FeedID, Format: existing.Format,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+
+	if err := hub.Accept(context.Background(), subscribeRequest("https://b.example/cb")); !errors.Is(err, ErrTooManySubscriptions) {
+		t.Errorf("new subscription to a full topic: err = %v, want ErrTooManySubscriptions", err)
+	}
+	// the subscriber at the limit can still renew
+	if err := hub.checkLimit(context.Background(), existing); err != nil {
+		t.Errorf("renewal: %v", err)
+	}
+}
+
+func TestPublishSignsBody(t *testing.T) {
+	doc := []byte("<rss>new</rss>")
+	var signature, contentType, link string
+	var body []byte
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("X-Hub-Signature")
+		contentType = r.Header.Get("Content-Type")
+		link = r.Header.Get("Link")
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer subscriber.Close()
+
+	s := newMemStore()
+	req := subscribeRequest(subscriber.URL)
+	s.UpsertWebSubSubscription(context.Background(), &store.WebSubSubscription{
+		Topic: req.Topic, Callback: req.Callback, FeedID: req.FeedID, Format: req.Format,
+		Secret: req.Secret, ExpiresAt: time.Now().Add(time.Hour),
+	})
+	hub := newTestHub(s, Options{})
+	hub.Publish(req.FeedID, req.Format, "application/rss+xml", doc)
+	hub.Wait()
+
+	if string(body) != string(doc) {
+		t.Errorf("body = %q, want %q", body, doc)
+	}
+	if contentType != "application/rss+xml" {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+	if want := `<https://feeds.example/websub>; rel="hub", <` + testTopic + `>; rel="self"`; link != want {
+		t.Errorf("Link = %q, want %q", link, want)
+	}
+	mac := hmac.New(sha256.New, []byte(req.Secret))
+	mac.Write(doc)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); signature != want {
+		t.Errorf("X-Hub-Signature = %q, want %q", signature, want)
+	}
+}
+
+func TestPublishUnsubscribesGoneSubscriber(t *testing.T) {
+	var calls int
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer subscriber.Close()
+
+	s := newMemStore()
+	req := subscribeRequest(subscriber.URL)
+	s.UpsertWebSubSubscription(context.Background(), &store.WebSubSubscription{
+		Topic: req.Topic, Callback: req.Callback, FeedID: req.FeedID, Format: req.Format,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	hub := newTestHub(s, Options{Attempts: 3})
+	hub.Publish(req.FeedID, req.Format, "application/rss+xml", []byte("<rss/>"))
+	hub.Wait()
+
+	if _, ok := s.get(req.Topic, req.Callback); ok {
+		t.Error("subscriber answering 410 is still subscribed")
+	}
+	if calls != 1 {
+		t.Errorf("delivered %d times, want 1 (410 is not retried)", calls)
+	}
+}
+
+func TestAcceptAfterWaitIsBusy(t *testing.T) {
+	hub := newTestHub(newMemStore(), Options{})
+	hub.Wait()
+	req := subscribeRequest("https://a.example/cb")
+	req.Mode = ModeUnsubscribe
+	if err := hub.Accept(context.Background(), req); !errors.Is(err, ErrBusy) {
+		t.Errorf("Accept after Wait: err = %v, want ErrBusy", err)
+	}
+}
+
+func TestPublishAfterWaitIsDropped(t *testing.T) {
+	var calls int
+	var mu sync.Mutex
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+	}))
+	defer subscriber.Close()
+
+	s := newMemStore()
+	req := subscribeRequest(subscriber.URL)
+	s.UpsertWebSubSubscription(context.Background(), &store.WebSubSubscription{
+		Topic: req.Topic, Callback: req.Callback, FeedID: req.FeedID, Format: req.Format,
+		Secret: req.Secret, ExpiresAt: time.Now().Add(time.Hour),
+	})
+	hub := newTestHub(s, Options{})
+	hub.Wait()
+	hub.Publish(req.FeedID, req.Format, "application/rss+xml", []byte("<rss/>"))
+	hub.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 0 {
+		t.Errorf("delivered %d times after Wait, want 0", calls)
+	}
+}
@@ -0,0 +1,48 @@
+-- +goose Up
+-- Allow feeds to backfill beyond a single upstream page and to limit
+-- articles by publication date.
+
+-- SQLite cannot relax a CHECK constraint in place, so rebuild the table.
+CREATE TABLE feeds_new (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    usernames TEXT NOT NULL,  -- JSON array of LeetCode usernames
+    first_per_user INTEGER NOT NULL DEFAULT 15 CHECK (first_per_user >= 1 AND first_per_user <= 500),
+    since TEXT,               -- RFC3339 cutoff; NULL means no cutoff
+    enabled INTEGER NOT NULL DEFAULT 1,
+    created_at TEXT NOT NULL DEFAULT (datetime('now')),
+    updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+INSERT INTO feeds_new (id, user_id, name, secret, usernames, first_per_user, enabled, created_at, updated_at)
+SELECT id, user_id, name, secret, usernames, first_per_user, enabled, created_at, updated_at FROM feeds;
+
+DROP TABLE feeds;
+ALTER TABLE feeds_new RENAME TO feeds;
+
+CREATE INDEX idx_feeds_user_id ON feeds(user_id);
+CREATE UNIQUE INDEX idx_feeds_id_secret ON feeds(id, secret);
+
+-- +goose Down
+CREATE TABLE feeds_old (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    usernames TEXT NOT NULL,
+    first_per_user INTEGER NOT NULL DEFAULT 15 CHECK (first_per_user >= 1 AND first_per_user <= 50),
+    enabled INTEGER NOT NULL DEFAULT 1,
+    created_at TEXT NOT NULL DEFAULT (datetime('now')),
+    updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+INSERT INTO feeds_old (id, user_id, name, secret, usernames, first_per_user, enabled, created_at, updated_at)
+SELECT id, user_id, name, secret, usernames, MIN(first_per_user, 50), enabled, created_at, updated_at FROM feeds;
+
+DROP TABLE feeds;
+ALTER TABLE feeds_old RENAME TO feeds;
+
+CREATE INDEX idx_feeds_user_id ON feeds(user_id);
+CREATE UNIQUE INDEX idx_feeds_id_secret ON feeds(id, secret);
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Per-username article cache shared by every feed that follows the username
+
+CREATE TABLE user_article_cache (
+    username TEXT PRIMARY KEY,
+    fetch_limit INTEGER NOT NULL,  -- articles requested upstream; 0 means no limit
+    since TEXT,                    -- RFC3339 cutoff used for the fetch; NULL means none
+    articles TEXT NOT NULL,        -- JSON array of LeetCode articles, most recent first
+    fetched_at TEXT NOT NULL,
+    expires_at TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS user_article_cache;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- Article history: every solution article we have seen, so feeds can be
+-- rendered from our own data and survive upstream outages
+
+CREATE TABLE articles (
+    uuid TEXT PRIMARY KEY,
+    topic_id INTEGER NOT NULL,
+    username TEXT NOT NULL,
+    title TEXT NOT NULL,
+    slug TEXT NOT NULL,
+    created_at TEXT NOT NULL,          -- createdAt exactly as returned by LeetCode
+    published_at TEXT,                 -- created_at normalized to UTC for ordering; NULL if unparseable
+    hit_count INTEGER NOT NULL DEFAULT 0,
+    question_slug TEXT NOT NULL DEFAULT '',
+    question_title TEXT NOT NULL DEFAULT '',
+    first_seen_at TEXT NOT NULL,
+    last_seen_at TEXT NOT NULL
+);
+
+-- Index for rendering a username's most recent articles
+CREATE INDEX idx_articles_username_published ON articles(username, published_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS articles;
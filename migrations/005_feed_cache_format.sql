@@ -0,0 +1,35 @@
+-- +goose Up
+-- Cache each rendered format (RSS, Atom, ...) of a feed separately
+
+CREATE TABLE feed_cache_new (
+    feed_id TEXT NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    format TEXT NOT NULL DEFAULT 'rss',
+    xml BLOB,                  -- rendered document, whatever the format
+    etag TEXT,
+    last_built_at TEXT,
+    expires_at TEXT,
+    last_error TEXT,
+    PRIMARY KEY (feed_id, format)
+);
+
+INSERT INTO feed_cache_new (feed_id, format, xml, etag, last_built_at, expires_at, last_error)
+SELECT feed_id, 'rss', xml, etag, last_built_at, expires_at, last_error FROM feed_cache;
+
+DROP TABLE feed_cache;
+ALTER TABLE feed_cache_new RENAME TO feed_cache;
+
+-- +goose Down
+CREATE TABLE feed_cache_old (
+    feed_id TEXT PRIMARY KEY REFERENCES feeds(id) ON DELETE CASCADE,
+    xml BLOB,
+    etag TEXT,
+    last_built_at TEXT,
+    expires_at TEXT,
+    last_error TEXT
+);
+
+INSERT INTO feed_cache_old (feed_id, xml, etag, last_built_at, expires_at, last_error)
+SELECT feed_id, xml, etag, last_built_at, expires_at, last_error FROM feed_cache WHERE format = 'rss';
+
+DROP TABLE feed_cache;
+ALTER TABLE feed_cache_old RENAME TO feed_cache;
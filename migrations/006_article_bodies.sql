@@ -0,0 +1,18 @@
+-- +goose Up
+-- Optional full article bodies in feed items
+
+ALTER TABLE feeds ADD COLUMN include_content INTEGER NOT NULL DEFAULT 0;
+
+-- Solution article bodies, fetched once per article and reused by every feed
+CREATE TABLE article_bodies (
+    uuid TEXT PRIMARY KEY,
+    topic_id INTEGER NOT NULL,
+    content TEXT NOT NULL,    -- Markdown exactly as returned by LeetCode
+    updated_at TEXT,          -- updatedAt as returned by LeetCode, if any
+    fetched_at TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS article_bodies;
+
+ALTER TABLE feeds DROP COLUMN include_content;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Per-feed item filter rules
+
+ALTER TABLE feeds ADD COLUMN filter TEXT;  -- JSON filter rules; NULL means no filtering
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN filter;
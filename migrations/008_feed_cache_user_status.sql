@@ -0,0 +1,7 @@
+-- +goose Up
+-- Per-username outcome of the last build, so owners can see which username broke a feed
+
+ALTER TABLE feed_cache ADD COLUMN user_status TEXT;  -- JSON array of username statuses
+
+-- +goose Down
+ALTER TABLE feed_cache DROP COLUMN user_status;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Build statistics for the feed status endpoint
+
+ALTER TABLE feed_cache ADD COLUMN item_count INTEGER;         -- items in the last successful build
+ALTER TABLE feed_cache ADD COLUMN build_duration_ms INTEGER;  -- wall time of the last successful build
+
+-- +goose Down
+ALTER TABLE feed_cache DROP COLUMN build_duration_ms;
+ALTER TABLE feed_cache DROP COLUMN item_count;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- WebSub subscriptions to public feeds, one per (topic, callback)
+
+CREATE TABLE websub_subscriptions (
+    topic TEXT NOT NULL,              -- feed URL as the subscriber requested it
+    callback TEXT NOT NULL,
+    feed_id TEXT NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    format TEXT NOT NULL,             -- rss.Format of the topic
+    secret TEXT,                      -- hub.secret for signing deliveries, if any
+    expires_at TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    PRIMARY KEY (topic, callback)
+);
+
+CREATE INDEX idx_websub_subscriptions_feed ON websub_subscriptions(feed_id, format);
+
+-- +goose Down
+DROP TABLE IF EXISTS websub_subscriptions;
@@ -0,0 +1,43 @@
+-- +goose Up
+-- Outbound webhooks notified of new feed items
+
+CREATE TABLE feed_webhooks (
+    id TEXT PRIMARY KEY,
+    feed_id TEXT NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,             -- HMAC key for X-Webhook-Signature
+    payload_format TEXT NOT NULL,     -- json, slack or discord
+    enabled INTEGER NOT NULL DEFAULT 1,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+CREATE INDEX idx_feed_webhooks_feed_id ON feed_webhooks(feed_id);
+
+-- Items a feed has already produced, so rebuilds can tell which are new
+CREATE TABLE feed_items (
+    feed_id TEXT NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    guid TEXT NOT NULL,
+    first_seen_at TEXT NOT NULL,
+    PRIMARY KEY (feed_id, guid)
+);
+
+-- Outcome of each webhook delivery, newest kept per webhook
+CREATE TABLE webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id TEXT NOT NULL REFERENCES feed_webhooks(id) ON DELETE CASCADE,
+    item_guid TEXT NOT NULL,
+    success INTEGER NOT NULL,
+    attempts INTEGER NOT NULL,
+    response_status INTEGER,          -- last HTTP status, NULL if no response
+    error TEXT,
+    duration_ms INTEGER NOT NULL,
+    created_at TEXT NOT NULL
+);
+
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id, id);
+
+-- +goose Down
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS feed_items;
+DROP TABLE IF EXISTS feed_webhooks;
@@ -0,0 +1,30 @@
+-- +goose Up
+-- Periodic email digests of new feed items
+
+CREATE TABLE feed_digests (
+    feed_id TEXT PRIMARY KEY REFERENCES feeds(id) ON DELETE CASCADE,
+    frequency TEXT NOT NULL,          -- daily or weekly
+    enabled INTEGER NOT NULL DEFAULT 1,
+    next_run_at TEXT NOT NULL,        -- when the next digest is due
+    covered_until TEXT,               -- items published up to here were covered by a digest
+    last_sent_at TEXT,
+    last_item_count INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+CREATE INDEX idx_feed_digests_next_run_at ON feed_digests(enabled, next_run_at);
+
+CREATE TABLE digest_recipients (
+    feed_id TEXT NOT NULL REFERENCES feed_digests(feed_id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    unsubscribe_token TEXT NOT NULL UNIQUE,
+    unsubscribed_at TEXT,             -- set when the recipient opts out; owners cannot re-add them
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (feed_id, email)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS digest_recipients;
+DROP TABLE IF EXISTS feed_digests;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Digest mode: one item per day or week instead of one per article
+
+ALTER TABLE feeds ADD COLUMN group_by TEXT NOT NULL DEFAULT '';      -- '', day or week
+ALTER TABLE feeds ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';   -- IANA zone for period boundaries
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN timezone;
+ALTER TABLE feeds DROP COLUMN group_by;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Background refresh backoff for feeds whose builds keep failing
+
+ALTER TABLE feed_cache ADD COLUMN consecutive_failures INTEGER NOT NULL DEFAULT 0;  -- failed builds since the last success
+ALTER TABLE feed_cache ADD COLUMN next_attempt_at TEXT;                            -- the refresher skips the feed until then
+
+-- +goose Down
+ALTER TABLE feed_cache DROP COLUMN next_attempt_at;
+ALTER TABLE feed_cache DROP COLUMN consecutive_failures;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Items each feed's digest has already sent, so items that reach the feed
+-- late are sent once instead of being skipped by a time cutoff
+
+CREATE TABLE digest_items (
+    feed_id TEXT NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    guid TEXT NOT NULL,
+    sent_at TEXT NOT NULL,
+    PRIMARY KEY (feed_id, guid)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS digest_items;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- Track sent digest items per recipient, so a digest that reached only some
+-- recipients can be retried for the others without repeating it
+
+CREATE TABLE digest_recipient_items (
+    feed_id TEXT NOT NULL,
+    email TEXT NOT NULL,
+    guid TEXT NOT NULL,
+    sent_at TEXT NOT NULL,
+    PRIMARY KEY (feed_id, email, guid),
+    FOREIGN KEY (feed_id, email) REFERENCES digest_recipients(feed_id, email) ON DELETE CASCADE
+);
+
+INSERT INTO digest_recipient_items (feed_id, email, guid, sent_at)
+SELECT i.feed_id, r.email, i.guid, i.sent_at
+FROM digest_items i
+JOIN digest_recipients r ON r.feed_id = i.feed_id;
+
+DROP TABLE digest_items;
+
+-- +goose Down
+CREATE TABLE digest_items (
+    feed_id TEXT NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    guid TEXT NOT NULL,
+    sent_at TEXT NOT NULL,
+    PRIMARY KEY (feed_id, guid)
+);
+
+INSERT OR IGNORE INTO digest_items (feed_id, guid, sent_at)
+SELECT feed_id, guid, MIN(sent_at) FROM digest_recipient_items GROUP BY feed_id, guid;
+
+DROP TABLE IF EXISTS digest_recipient_items;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Double opt-in: digests go only to recipients who confirmed their address.
+-- Recipients added before confirmation was required count as confirmed.
+
+ALTER TABLE digest_recipients ADD COLUMN confirmed_at TEXT;  -- NULL until the recipient confirms
+
+UPDATE digest_recipients SET confirmed_at = created_at;
+
+-- +goose Down
+ALTER TABLE digest_recipients DROP COLUMN confirmed_at;